@@ -0,0 +1,183 @@
+package ssr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrTeamNotFound is returned when a team does not exist.
+	ErrTeamNotFound = NewError(KindNotFound, "team not found")
+	// ErrRepositoryMemberNotFound is returned when a team is not a member of a repository.
+	ErrRepositoryMemberNotFound = NewError(KindNotFound, "repository member not found")
+	// ErrInvalidRole is returned for a role that is not one of the roles.
+	ErrInvalidRole = NewError(KindInvalid, "invalid role")
+)
+
+// Role is what a user may do on a repository and its scans, findings, suppressions and policies.
+// Each role allows what the previous ones do.
+type Role string
+
+const (
+	// RoleReader can read, e.g. an auditor.
+	RoleReader Role = "reader"
+	// RoleMaintainer can also submit scans, update the repository, triage findings and manage suppressions
+	// and policies, e.g. the team that owns the repository.
+	RoleMaintainer Role = "maintainer"
+	// RoleAdmin can also delete the repository, manage its members, create repositories and global policies,
+	// e.g. the security team.
+	RoleAdmin Role = "admin"
+)
+
+// ParseRole returns r if it is a role.
+func ParseRole(r string) (Role, error) {
+	switch role := Role(r); role {
+	case RoleReader, RoleMaintainer, RoleAdmin:
+		return role, nil
+	}
+	return "", fmt.Errorf("%w %q", ErrInvalidRole, r)
+}
+
+// UnmarshalJSON only accepts a role, or an empty string for no role.
+func (r *Role) UnmarshalJSON(b []byte) error {
+	var v string
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if v == "" {
+		*r = ""
+		return nil
+	}
+	role, err := ParseRole(v)
+	if err != nil {
+		return err
+	}
+	*r = role
+	return nil
+}
+
+// Allows reports whether r allows what role does. No role allows nothing.
+func (r Role) Allows(role Role) bool {
+	return r.rank() >= role.rank() && r.rank() > 0
+}
+
+func (r Role) rank() int {
+	switch r {
+	case RoleReader:
+		return 1
+	case RoleMaintainer:
+		return 2
+	case RoleAdmin:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// max returns the role that allows the most out of r and role.
+func (r Role) max(role Role) Role {
+	if role.rank() > r.rank() {
+		return role
+	}
+	return r
+}
+
+// Team groups users, e.g. a product team. Users are members of a team when they are added to it,
+// or when the identity provider puts them in a group of the same name.
+type Team struct {
+	ID             uint64 `json:"id"`
+	OrganizationID uint64 `json:"organization_id" gorm:"uniqueIndex:idx_team_organization_name;not null"`
+	Name           string `json:"name" gorm:"uniqueIndex:idx_team_organization_name;not null"`
+	// Role applies to every repository, e.g. admin for the security team and reader for auditors.
+	Role      Role      `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (Team) TableName() string {
+	return "team"
+}
+
+// TeamMember adds a user to a team.
+type TeamMember struct {
+	TeamID uint64 `json:"team_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID string `json:"user_id" gorm:"primaryKey"`
+}
+
+func (TeamMember) TableName() string {
+	return "team_member"
+}
+
+// RepositoryMember gives a team a role on a repository.
+type RepositoryMember struct {
+	RepositoryID uint64 `json:"repository_id" gorm:"primaryKey;autoIncrement:false"`
+	TeamID       uint64 `json:"team_id" gorm:"primaryKey;autoIncrement:false"`
+	Role         Role   `json:"role" gorm:"not null"`
+}
+
+func (RepositoryMember) TableName() string {
+	return "repository_member"
+}
+
+// Access is what a user may do, as resolved from their role, their teams and the repository memberships
+// of their teams.
+type Access struct {
+	// Role applies to every repository.
+	Role Role
+	// Repositories holds the roles of the user on the repositories that their teams are members of.
+	Repositories map[uint64]Role
+}
+
+// Grant gives the user role on every repository, unless they already have a role that allows more.
+func (a *Access) Grant(role Role) {
+	a.Role = a.Role.max(role)
+}
+
+// GrantRepository gives the user role on a repository, unless they already have a role that allows more.
+func (a *Access) GrantRepository(repositoryID uint64, role Role) {
+	if a.Repositories == nil {
+		a.Repositories = make(map[uint64]Role)
+	}
+	a.Repositories[repositoryID] = a.Repositories[repositoryID].max(role)
+}
+
+// Can reports whether the user may do what role allows on a repository.
+func (a *Access) Can(repositoryID uint64, role Role) bool {
+	return a.Role.max(a.Repositories[repositoryID]).Allows(role)
+}
+
+// ReadsAll reports whether the user can read every repository.
+func (a *Access) ReadsAll() bool {
+	return a.Role.Allows(RoleReader)
+}
+
+// Readable returns the repositories that the user can read besides those of their global role.
+func (a *Access) Readable() []uint64 {
+	ids := make([]uint64, 0, len(a.Repositories))
+	for id, role := range a.Repositories {
+		if role.Allows(RoleReader) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// AccessService manages users, teams and repository memberships. The other services restrict what
+// the user of their context may do accordingly, a context without user, e.g. of the worker, being unrestricted.
+type AccessService interface {
+	// SaveUser creates or updates a user, e.g. to give it a global role.
+	SaveUser(ctx context.Context, u *User) error
+	ListUsers(ctx context.Context) ([]*User, error)
+	CreateTeam(ctx context.Context, t *Team) error
+	ListTeams(ctx context.Context) ([]*Team, error)
+	DeleteTeam(ctx context.Context, id uint64) error
+	AddTeamMember(ctx context.Context, m *TeamMember) error
+	RemoveTeamMember(ctx context.Context, m *TeamMember) error
+	// SetRepositoryMember gives a team a role on a repository, replacing the role it had.
+	SetRepositoryMember(ctx context.Context, m *RepositoryMember) error
+	ListRepositoryMembers(ctx context.Context, repositoryID uint64) ([]*RepositoryMember, error)
+	RemoveRepositoryMember(ctx context.Context, repositoryID, teamID uint64) error
+	// Access resolves what the user of ctx may do, nil meaning unrestricted.
+	Access(ctx context.Context) (*Access, error)
+}
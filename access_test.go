@@ -0,0 +1,47 @@
+package ssr
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleAllows(t *testing.T) {
+	assert.True(t, RoleAdmin.Allows(RoleMaintainer))
+	assert.True(t, RoleMaintainer.Allows(RoleMaintainer))
+	assert.False(t, RoleReader.Allows(RoleMaintainer))
+	assert.False(t, Role("").Allows(RoleReader))
+	assert.False(t, Role("").Allows(""))
+}
+
+func TestRoleUnmarshalJSON(t *testing.T) {
+	var m RepositoryMember
+	require.NoError(t, json.Unmarshal([]byte(`{"role": "maintainer"}`), &m))
+	assert.Equal(t, RoleMaintainer, m.Role)
+
+	err := json.Unmarshal([]byte(`{"role": "owner"}`), &m)
+	assert.True(t, errors.Is(err, ErrInvalidRole))
+}
+
+func TestAccess(t *testing.T) {
+	a := &Access{}
+	assert.False(t, a.ReadsAll())
+	assert.False(t, a.Can(1, RoleReader))
+
+	a.GrantRepository(1, RoleMaintainer)
+	a.GrantRepository(1, RoleReader)
+	a.GrantRepository(2, RoleReader)
+	assert.True(t, a.Can(1, RoleMaintainer))
+	assert.False(t, a.Can(2, RoleMaintainer))
+	assert.False(t, a.Can(3, RoleReader))
+	assert.ElementsMatch(t, []uint64{1, 2}, a.Readable())
+
+	a.Grant(RoleReader)
+	assert.True(t, a.ReadsAll())
+	assert.True(t, a.Can(3, RoleReader))
+	assert.False(t, a.Can(3, RoleMaintainer))
+	assert.True(t, a.Can(1, RoleMaintainer))
+}
@@ -0,0 +1,11 @@
+package ssr
+
+import (
+	"context"
+)
+
+// Analyzer inspects the source code checked out in dir and reports what it found.
+type Analyzer interface {
+	Name() string
+	Analyze(ctx context.Context, dir string) (Findings, error)
+}
@@ -0,0 +1,181 @@
+package ssr
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrAPIKeyNotFound is returned when an API key does not exist.
+	ErrAPIKeyNotFound = NewError(KindNotFound, "API key not found")
+	// ErrUnauthorized is returned for a request without bearer token, or with an invalid one, e.g. an unknown,
+	// expired or revoked API key.
+	ErrUnauthorized = NewError(KindUnauthorized, "missing or invalid bearer token")
+	// ErrForbidden is returned when a user lacks the scope or the role that a request requires.
+	ErrForbidden = NewError(KindForbidden, "not allowed")
+	// ErrInvalidScope is returned for a scope that is not one of Scopes.
+	ErrInvalidScope = NewError(KindInvalid, "invalid scope")
+)
+
+// Scope grants an API key access to a group of endpoints.
+type Scope string
+
+const (
+	ScopeScansRead         Scope = "scans:read"
+	ScopeScansWrite        Scope = "scans:write"
+	ScopeRepositoriesRead  Scope = "repositories:read"
+	ScopeRepositoriesWrite Scope = "repositories:write"
+	// ScopeRepositoriesAdmin allows deleting repositories and managing global policies.
+	ScopeRepositoriesAdmin Scope = "repositories:admin"
+	// ScopeKeysAdmin allows issuing, listing and revoking API keys.
+	ScopeKeysAdmin Scope = "keys:admin"
+	// ScopeUsersAdmin allows managing users, teams and the members of repositories.
+	ScopeUsersAdmin Scope = "users:admin"
+	// ScopeWebhooksAdmin allows managing webhooks and reading their deliveries.
+	ScopeWebhooksAdmin Scope = "webhooks:admin"
+)
+
+// Scopes lists every scope.
+var Scopes = []Scope{ScopeScansRead, ScopeScansWrite, ScopeRepositoriesRead, ScopeRepositoriesWrite, ScopeRepositoriesAdmin, ScopeKeysAdmin, ScopeUsersAdmin, ScopeWebhooksAdmin}
+
+// ParseScope returns s if it is one of Scopes.
+func ParseScope(s string) (Scope, error) {
+	for _, scope := range Scopes {
+		if string(scope) == s {
+			return scope, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q", ErrInvalidScope, s)
+}
+
+// UnmarshalJSON only accepts one of Scopes.
+func (s *Scope) UnmarshalJSON(b []byte) error {
+	var v string
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	scope, err := ParseScope(v)
+	if err != nil {
+		return err
+	}
+	*s = scope
+	return nil
+}
+
+// ScopeList is stored as a JSON array.
+type ScopeList []Scope
+
+func (l ScopeList) Value() (driver.Value, error) {
+	return json.Marshal(l)
+}
+
+func (l *ScopeList) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, &l)
+}
+
+// apiKeyPrefix starts every API key, so that leaked keys are easy to spot.
+const apiKeyPrefix = "ssr_"
+
+// APIKey authenticates the requests of a client. Only the SHA-256 hash of the key is stored: the key itself
+// is returned once, when it is issued.
+type APIKey struct {
+	ID             uint64 `json:"id"`
+	OrganizationID uint64 `json:"organization_id" gorm:"index;not null"`
+	Name           string `json:"name"`
+	// Prefix is the beginning of the key, to tell keys apart without storing them.
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-" gorm:"uniqueIndex;not null"`
+	Scopes     ScopeList  `json:"scopes" gorm:"type:jsonb"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	// Role is what the key may do on the repositories of its organization, or on RepositoryID only if set.
+	// Like a user, the key also has the roles of the teams that it is a member of, as "apikey:<id>".
+	Role         Role    `json:"role" gorm:"not null"`
+	RepositoryID *uint64 `json:"repository_id,omitempty" gorm:"index"`
+}
+
+func (APIKey) TableName() string {
+	return "api_key"
+}
+
+// NewAPIKey generates a key and returns it along with the APIKey to store, which only holds its hash.
+// The key is a reader until it is given another role.
+func NewAPIKey(name string, scopes []Scope, expiresAt *time.Time) (*APIKey, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return &APIKey{
+		Name:      name,
+		Prefix:    key[:len(apiKeyPrefix)+6],
+		Hash:      HashAPIKey(key),
+		Scopes:    scopes,
+		Role:      RoleReader,
+		ExpiresAt: expiresAt,
+	}, key, nil
+}
+
+// HashAPIKey returns the hash that a key is stored and looked up by.
+// Keys are random, so a fast hash does not make them easier to guess.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(key)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Active reports whether k can be used at the given time.
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || k.ExpiresAt.After(now)
+}
+
+// HasScope reports whether k grants scope.
+func (k *APIKey) HasScope(scope Scope) bool {
+	return k.User().HasScope(scope)
+}
+
+// User is the user that the requests authenticated by k are made by.
+// It is identified by the ID of k, since names are not unique.
+func (k *APIKey) User() *User {
+	return &User{
+		ID:             "apikey:" + strconv.FormatUint(k.ID, 10),
+		OrganizationID: k.OrganizationID,
+		Role:           k.Role,
+		RepositoryID:   k.RepositoryID,
+		Scopes:         k.Scopes,
+	}
+}
+
+// IsAPIKey reports whether a bearer token looks like an API key rather than a token of the identity provider.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+
+type APIKeyService interface {
+	Create(ctx context.Context, k *APIKey) error
+	// Authenticate returns the active key whose hash matches key and records that it was used.
+	// It returns ErrUnauthorized if there is none.
+	Authenticate(ctx context.Context, key string) (*APIKey, error)
+	List(ctx context.Context) ([]*APIKey, error)
+	// Revoke disables a key for good. Revoked keys are kept, so that they show up when listing keys.
+	Revoke(ctx context.Context, id uint64) error
+}
@@ -0,0 +1,69 @@
+package ssr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	k, key, err := NewAPIKey("ci", []Scope{ScopeScansWrite}, nil)
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(key, "ssr_"))
+	assert.True(t, strings.HasPrefix(key, k.Prefix))
+	assert.Equal(t, HashAPIKey(key), k.Hash)
+	assert.NotContains(t, k.Hash, key)
+	assert.Equal(t, RoleReader, k.Role, "keys are readers unless given another role")
+
+	other, otherKey, err := NewAPIKey("ci", []Scope{ScopeScansWrite}, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, otherKey)
+	assert.NotEqual(t, k.Hash, other.Hash)
+}
+
+func TestAPIKeyActive(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+	later := now.Add(time.Hour)
+
+	assert.True(t, (&APIKey{}).Active(now))
+	assert.True(t, (&APIKey{ExpiresAt: &later}).Active(now))
+	assert.False(t, (&APIKey{ExpiresAt: &earlier}).Active(now))
+	assert.False(t, (&APIKey{RevokedAt: &earlier}).Active(now))
+}
+
+func TestAPIKeyHasScope(t *testing.T) {
+	k := &APIKey{Scopes: ScopeList{ScopeScansRead, ScopeScansWrite}}
+	assert.True(t, k.HasScope(ScopeScansWrite))
+	assert.False(t, k.HasScope(ScopeRepositoriesAdmin))
+}
+
+func TestScopeUnmarshalJSON(t *testing.T) {
+	var scopes []Scope
+	require.NoError(t, json.Unmarshal([]byte(`["scans:read", "keys:admin"]`), &scopes))
+	assert.Equal(t, []Scope{ScopeScansRead, ScopeKeysAdmin}, scopes)
+
+	err := json.Unmarshal([]byte(`["scans:delete"]`), &scopes)
+	assert.True(t, errors.Is(err, ErrInvalidScope))
+	assert.Equal(t, KindInvalid, KindOf(err))
+}
+
+func TestUser(t *testing.T) {
+	u := (&APIKey{ID: 3, Name: "ci", Scopes: ScopeList{ScopeScansWrite}, Role: RoleMaintainer}).User()
+	assert.Equal(t, "apikey:3", u.Name())
+	assert.Equal(t, RoleMaintainer, u.Role)
+	assert.True(t, u.HasScope(ScopeScansWrite))
+
+	ctx := NewContextWithUser(context.Background(), &User{ID: "alice", Email: "alice@example.com", EmailVerified: true})
+	require.NotNil(t, UserFromContext(ctx))
+	assert.Equal(t, "alice@example.com", UserFromContext(ctx).Name())
+	assert.Equal(t, "alice", (&User{ID: "alice", Email: "alice@example.com"}).Name(), "an unverified email is not used")
+	assert.Nil(t, UserFromContext(context.Background()))
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/quantonganh/ssr"
+)
+
+type commandAnalyzer struct {
+	config ssr.AnalyzerConfig
+}
+
+func newAnalyzers(configs []ssr.AnalyzerConfig) []ssr.Analyzer {
+	analyzers := make([]ssr.Analyzer, 0, len(configs))
+	for _, c := range configs {
+		analyzers = append(analyzers, &commandAnalyzer{config: c})
+	}
+	return analyzers
+}
+
+func (a *commandAnalyzer) Name() string {
+	return a.config.Name
+}
+
+func (a *commandAnalyzer) Analyze(ctx context.Context, dir string) (ssr.Findings, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, a.config.Command, a.config.Args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	// Tools such as gosec and gitleaks exit with a non-zero code when they find something,
+	// so a failure only counts if there is no report to read.
+	var exitErr *exec.ExitError
+	if err != nil && (!errors.As(err, &exitErr) || stdout.Len() == 0) {
+		return nil, errors.Wrapf(err, "%s failed: %s", a.config.Name, strings.TrimSpace(stderr.String()))
+	}
+
+	var findings ssr.Findings
+	if a.config.Format == "" {
+		if err := json.Unmarshal(stdout.Bytes(), &findings); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s output", a.config.Name)
+		}
+	} else {
+		importer, ok := ssr.LookupImporter(a.config.Format)
+		if !ok {
+			return nil, errors.Errorf("unsupported format %q for analyzer %s", a.config.Format, a.config.Name)
+		}
+		if findings, err = importer.Import(&stdout); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s output", a.config.Name)
+		}
+	}
+
+	// Some tools report absolute paths, make them relative to the root of the repository.
+	for i := range findings {
+		if rel, err := filepath.Rel(dir, findings[i].Location.Path); err == nil && filepath.IsAbs(findings[i].Location.Path) {
+			findings[i].Location.Path = filepath.ToSlash(rel)
+		}
+	}
+	return findings, nil
+}
+
+// checkout makes a shallow clone of a branch of the repository into dir, an empty branch meaning the default one.
+func checkout(ctx context.Context, repo *ssr.Repository, branch, dir string) error {
+	url, err := cloneURL(repo)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if branch != "" {
+		if err := checkBranch(ctx, branch); err != nil {
+			return err
+		}
+		args = append(args, "--branch", branch)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append(args, "--", url, dir)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "failed to clone %s: %s", url, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// checkBranch checks that branch is a valid branch name, which the client of the scan is free to set,
+// before it is passed to git.
+func checkBranch(ctx context.Context, branch string) error {
+	if strings.HasPrefix(branch, "-") {
+		return errors.Errorf("invalid branch: %s", branch)
+	}
+	if err := exec.CommandContext(ctx, "git", "check-ref-format", "--branch", branch).Run(); err != nil {
+		return errors.Wrapf(err, "invalid branch: %s", branch)
+	}
+	return nil
+}
+
+func cloneURL(repo *ssr.Repository) (string, error) {
+	switch strings.ToLower(repo.Provider) {
+	case "github":
+		return fmt.Sprintf("https://github.com/%s.git", repo.FullName), nil
+	case "gitlab":
+		return fmt.Sprintf("https://gitlab.com/%s.git", repo.FullName), nil
+	case "bitbucket":
+		return fmt.Sprintf("https://bitbucket.org/%s.git", repo.FullName), nil
+	default:
+		return "", errors.Errorf("unsupported provider %q for repository %s", repo.Provider, repo.FullName)
+	}
+}
@@ -0,0 +1,79 @@
+// +build !integration
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+)
+
+func TestCommandAnalyzer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssr-analyzer-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("ssr findings", func(t *testing.T) {
+		a := &commandAnalyzer{config: ssr.AnalyzerConfig{
+			Name:    "echo",
+			Command: "sh",
+			Args:    []string{"-c", `echo '[{"type": "sast", "rule_id": "G402", "location": {"path": "main.go"}}]'`},
+		}}
+		findings, err := a.Analyze(context.Background(), dir)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(findings))
+		assert.Equal(t, "main.go", findings[0].Location.Path)
+	})
+
+	t.Run("tool report with a non-zero exit code", func(t *testing.T) {
+		report := fmt.Sprintf(`[{"RuleID": "aws-access-token", "File": "%s/config/aws.go", "StartLine": 10}]`, dir)
+		a := &commandAnalyzer{config: ssr.AnalyzerConfig{
+			Name:    "gitleaks",
+			Command: "sh",
+			Args:    []string{"-c", fmt.Sprintf("echo '%s'; exit 1", report)},
+			Format:  "gitleaks",
+		}}
+		findings, err := a.Analyze(context.Background(), dir)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(findings))
+		assert.Equal(t, ssr.FindingSecret, findings[0].Type)
+		assert.Equal(t, "config/aws.go", findings[0].Location.Path)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		a := &commandAnalyzer{config: ssr.AnalyzerConfig{
+			Name:    "broken",
+			Command: "sh",
+			Args:    []string{"-c", "echo 'no such package' >&2; exit 2"},
+		}}
+		_, err := a.Analyze(context.Background(), dir)
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		a := &commandAnalyzer{config: ssr.AnalyzerConfig{
+			Name:    "bandit",
+			Command: "echo",
+			Args:    []string{"{}"},
+			Format:  "bandit",
+		}}
+		_, err := a.Analyze(context.Background(), dir)
+		assert.Error(t, err)
+	})
+}
+
+func TestCheckBranch(t *testing.T) {
+	for _, branch := range []string{"main", "feature/login", "release-1.2"} {
+		assert.NoError(t, checkBranch(context.Background(), branch), branch)
+	}
+	for _, branch := range []string{"--upload-pack=touch /tmp/pwned", "-b", "feature..login", "feature login"} {
+		assert.Error(t, checkBranch(context.Background(), branch), branch)
+	}
+}
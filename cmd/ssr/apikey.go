@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/quantonganh/ssr"
+)
+
+const apiKeyUsage = "usage: ssr apikey create -name NAME -scopes SCOPE[,SCOPE...] [-role ROLE] [-repository ID] [-expires DURATION] [-organization ID] | list | revoke ID"
+
+// apiKey runs the apikey subcommand: create issues a key and prints it, list prints the keys without them,
+// and revoke disables a key. It lets the first admin key be issued without going through the API.
+func apiKey(ctx context.Context, apiKeyService ssr.APIKeyService, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(apiKeyUsage)
+	}
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		name := fs.String("name", "", "name of the key")
+		scopes := fs.String("scopes", "", "comma-separated scopes of the key")
+		expires := fs.Duration("expires", 0, "lifetime of the key, zero meaning that it does not expire")
+		organizationID := fs.Uint64("organization", ssr.DefaultOrganizationID, "ID of the organization of the key")
+		role := fs.String("role", string(ssr.RoleReader), "role of the key on the repositories of its organization")
+		repositoryID := fs.Uint64("repository", 0, "ID of the only repository of the key, zero meaning every repository")
+		if err := fs.Parse(args[1:]); err != nil || *name == "" || *scopes == "" || *organizationID == 0 || fs.NArg() > 0 {
+			return errors.New(apiKeyUsage)
+		}
+		keyRole, err := ssr.ParseRole(*role)
+		if err != nil {
+			return err
+		}
+
+		var scopeList []ssr.Scope
+		for _, s := range strings.Split(*scopes, ",") {
+			scope, err := ssr.ParseScope(strings.TrimSpace(s))
+			if err != nil {
+				return err
+			}
+			scopeList = append(scopeList, scope)
+		}
+		var expiresAt *time.Time
+		if *expires > 0 {
+			t := time.Now().Add(*expires)
+			expiresAt = &t
+		}
+
+		k, key, err := ssr.NewAPIKey(*name, scopeList, expiresAt)
+		if err != nil {
+			return err
+		}
+		k.OrganizationID = *organizationID
+		k.Role = keyRole
+		if *repositoryID != 0 {
+			k.RepositoryID = repositoryID
+		}
+		if err := apiKeyService.Create(ctx, k); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "created API key %d, it will not be shown again:\n%s\n", k.ID, key)
+	case "list":
+		if len(args) != 1 {
+			return errors.New(apiKeyUsage)
+		}
+		keys, err := apiKeyService.List(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tORGANIZATION\tNAME\tPREFIX\tSCOPES\tROLE\tREPOSITORY\tEXPIRES AT\tLAST USED AT\tREVOKED AT")
+		for _, k := range keys {
+			scopes := make([]string, 0, len(k.Scopes))
+			for _, s := range k.Scopes {
+				scopes = append(scopes, string(s))
+			}
+			repository := "-"
+			if k.RepositoryID != nil {
+				repository = strconv.FormatUint(*k.RepositoryID, 10)
+			}
+			fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", k.ID, k.OrganizationID, k.Name, k.Prefix, strings.Join(scopes, ","),
+				k.Role, repository, formatTime(k.ExpiresAt), formatTime(k.LastUsedAt), formatTime(k.RevokedAt))
+		}
+		return tw.Flush()
+	case "revoke":
+		if len(args) != 2 {
+			return errors.New(apiKeyUsage)
+		}
+		id, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return errors.New(apiKeyUsage)
+		}
+		if err := apiKeyService.Revoke(ctx, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "revoked API key %d\n", id)
+	default:
+		return errors.New(apiKeyUsage)
+	}
+	return nil
+}
+
+// formatTime prints an optional time, "-" standing for no time.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
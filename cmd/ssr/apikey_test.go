@@ -0,0 +1,73 @@
+// +build !integration
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+	"github.com/quantonganh/ssr/mocks"
+)
+
+func TestAPIKey(t *testing.T) {
+	apiKeyService := new(mocks.APIKeyService)
+	apiKeyService.On("Create", mock.Anything, mock.MatchedBy(func(k *ssr.APIKey) bool {
+		return k.Name == "admin" && k.OrganizationID == 2 && k.HasScope(ssr.ScopeKeysAdmin) && k.HasScope(ssr.ScopeScansRead) && k.ExpiresAt != nil &&
+			k.Role == ssr.RoleAdmin && k.RepositoryID == nil
+	})).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(1).(*ssr.APIKey).ID = 1
+	})
+	apiKeyService.On("Create", mock.Anything, mock.MatchedBy(func(k *ssr.APIKey) bool {
+		return k.Name == "ci" && k.Role == ssr.RoleReader && k.RepositoryID != nil && *k.RepositoryID == 3
+	})).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(1).(*ssr.APIKey).ID = 2
+	})
+	repositoryID := uint64(3)
+	apiKeyService.On("List", mock.Anything).Return([]*ssr.APIKey{
+		{ID: 1, OrganizationID: 2, Name: "admin", Prefix: "ssr_abcdef", Scopes: ssr.ScopeList{ssr.ScopeKeysAdmin}, Role: ssr.RoleAdmin},
+		{ID: 2, OrganizationID: 1, Name: "ci", Prefix: "ssr_ghijkl", Scopes: ssr.ScopeList{ssr.ScopeScansRead}, Role: ssr.RoleReader, RepositoryID: &repositoryID},
+	}, nil)
+	apiKeyService.On("Revoke", mock.Anything, uint64(1)).Return(nil)
+
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		err := apiKey(context.Background(), apiKeyService, args, &out)
+		return out.String(), err
+	}
+
+	out, err := run("create", "-name", "admin", "-scopes", "keys:admin,scans:read", "-role", "admin", "-expires", "720h", "-organization", "2")
+	require.NoError(t, err)
+	assert.Contains(t, out, "created API key 1")
+	assert.Contains(t, out, "\nssr_")
+
+	out, err = run("create", "-name", "ci", "-scopes", "scans:read", "-repository", "3")
+	require.NoError(t, err)
+	assert.Contains(t, out, "created API key 2")
+
+	_, err = run("create", "-name", "admin", "-scopes", "keys:everything")
+	assert.ErrorIs(t, err, ssr.ErrInvalidScope)
+
+	_, err = run("create", "-name", "admin", "-scopes", "keys:admin", "-role", "owner")
+	assert.ErrorIs(t, err, ssr.ErrInvalidRole)
+
+	out, err = run("list")
+	require.NoError(t, err)
+	assert.Equal(t, "ID  ORGANIZATION  NAME   PREFIX      SCOPES      ROLE    REPOSITORY  EXPIRES AT  LAST USED AT  REVOKED AT\n"+
+		"1   2             admin  ssr_abcdef  keys:admin  admin   -           -           -             -\n"+
+		"2   1             ci     ssr_ghijkl  scans:read  reader  3           -           -             -\n", out)
+
+	out, err = run("revoke", "1")
+	require.NoError(t, err)
+	assert.Equal(t, "revoked API key 1\n", out)
+
+	for _, args := range [][]string{nil, {"create", "-name", "admin"}, {"revoke"}, {"revoke", "one"}, {"rotate"}} {
+		_, err := run(args...)
+		assert.EqualError(t, err, apiKeyUsage)
+	}
+}
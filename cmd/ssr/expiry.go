@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/quantonganh/ssr"
+)
+
+const defaultExpiryInterval = 5 * time.Minute
+
+// expireSuppressions periodically re-opens the findings of the suppressions that have expired,
+// until ctx is cancelled.
+func expireSuppressions(ctx context.Context, suppressionService ssr.SuppressionService, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultExpiryInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := suppressionService.Expire(ctx, time.Now())
+			if err != nil {
+				log.Printf("suppression: %+v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("suppression: re-opened %d finding(s) of expired suppressions", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// +build !integration
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/quantonganh/ssr/mocks"
+)
+
+func TestExpireSuppressions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	suppressionService := new(mocks.SuppressionService)
+	suppressionService.On("Expire", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(2), nil).Once().Run(func(mock.Arguments) {
+		cancel()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		expireSuppressions(ctx, suppressionService, 10*time.Millisecond)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expireSuppressions did not return after ctx was cancelled")
+	}
+	suppressionService.AssertExpectations(t)
+}
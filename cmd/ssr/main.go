@@ -6,8 +6,11 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"sync"
+	"time"
 
 	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -17,6 +20,9 @@ import (
 	"github.com/quantonganh/ssr/postgresql"
 )
 
+// defaultRequestTimeout bounds how long an API request may run.
+const defaultRequestTimeout = 30 * time.Second
+
 func main() {
 	viper.AddConfigPath(".")
 	viper.SetConfigName("config")
@@ -26,12 +32,48 @@ func main() {
 	}
 
 	viper.SetDefault("http.addr", ":8080")
+	viper.SetDefault("http.requesttimeout", defaultRequestTimeout)
+	viper.SetDefault("db.migrate", true)
+	viper.SetDefault("auth.oidc.emailclaim", "email")
+	viper.SetDefault("auth.oidc.groupsclaim", "groups")
+	viper.SetDefault("worker.poolsize", defaultPoolSize)
+	viper.SetDefault("worker.pollinterval", defaultPollInterval)
+	viper.SetDefault("worker.leaseduration", defaultLeaseDuration)
+	viper.SetDefault("worker.maxattempts", defaultMaxAttempts)
+	viper.SetDefault("suppression.expiryinterval", defaultExpiryInterval)
+	viper.SetDefault("webhook.pollinterval", defaultWebhookPollInterval)
+	viper.SetDefault("webhook.maxattempts", defaultWebhookMaxAttempts)
+	viper.SetDefault("webhook.backoff", defaultWebhookBackoff)
+	viper.SetDefault("webhook.timeout", defaultWebhookTimeout)
+	viper.SetDefault("outbox.pollinterval", defaultOutboxPollInterval)
+	viper.SetDefault("outbox.batchsize", defaultOutboxBatchSize)
+	viper.SetDefault("outbox.retention", defaultOutboxRetention)
+	viper.SetDefault("outbox.lease", defaultOutboxLease)
 
 	var config *ssr.Config
 	if err := viper.Unmarshal(&config); err != nil {
 		log.Fatal(err)
 	}
 
+	if len(os.Args) > 1 {
+		var err error
+		switch os.Args[1] {
+		case "migrate":
+			err = runMigrate(config, os.Args[2:])
+		case "apikey":
+			err = runAPIKey(config, os.Args[2:])
+		case "organization":
+			err = runOrganization(config, os.Args[2:])
+		default:
+			err = fmt.Errorf("unknown command: %s", os.Args[1])
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	app, err := NewApp(config)
 	if err != nil {
 		log.Fatal(err)
@@ -63,27 +105,155 @@ func main() {
 type app struct {
 	config *ssr.Config
 	httpServer *http.Server
+	worker *worker
+	dispatcher *dispatcher
+	relay *relay
+	suppressionService ssr.SuppressionService
+
+	wg sync.WaitGroup
 }
 
-func NewApp(config *ssr.Config) (*app, error) {
+func openDB(config *ssr.Config) (*gorm.DB, error) {
 	psqlConn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable", config.DB.Host, config.DB.Port, config.DB.User, config.DB.Password, config.DB.Name)
+	return gorm.Open(postgres.Open(psqlConn), &gorm.Config{})
+}
 
-	db, err := gorm.Open(postgres.Open(psqlConn), &gorm.Config{})
+func runMigrate(config *ssr.Config, args []string) error {
+	db, err := openDB(config)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	m, err := postgresql.NewMigrator(db)
+	if err != nil {
+		return err
 	}
+	return migrate(context.Background(), m, args, os.Stdout)
+}
 
-	if err := db.AutoMigrate(&ssr.Repository{}, &ssr.Scan{}); err != nil {
+func runAPIKey(config *ssr.Config, args []string) error {
+	db, err := openDB(config)
+	if err != nil {
+		return err
+	}
+	return apiKey(context.Background(), postgresql.NewAPIKeyService(db), args, os.Stdout)
+}
+
+func runOrganization(config *ssr.Config, args []string) error {
+	db, err := openDB(config)
+	if err != nil {
+		return err
+	}
+	return organization(context.Background(), postgresql.NewOrganizationService(db), args, os.Stdout)
+}
+
+func NewApp(config *ssr.Config) (*app, error) {
+	if len(config.Worker.Analyzers) > 0 {
+		if err := checkLeaseDuration(config.Worker.LeaseDuration); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := openDB(config)
+	if err != nil {
 		return nil, err
 	}
 
-	return &app{
+	if config.DB.Migrate {
+		m, err := postgresql.NewMigrator(db)
+		if err != nil {
+			return nil, err
+		}
+		applied, err := m.Up(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, migration := range applied {
+			log.Printf("migrate: applied %d_%s", migration.Version, migration.Name)
+		}
+	}
+
+	repositoryService := postgresql.NewRepositoryService(db)
+	a := &app{
 		config: config,
 		httpServer: http.NewServer(
-			postgresql.NewRepositoryService(db),
+			repositoryService,
 			postgresql.NewScanService(db),
 		),
-	}, nil
+	}
+	a.httpServer.RequestTimeout = config.HTTP.RequestTimeout
+	a.httpServer.FindingService = postgresql.NewFindingService(db)
+	a.suppressionService = postgresql.NewSuppressionService(db)
+	a.httpServer.SuppressionService = a.suppressionService
+	a.httpServer.PolicyService = postgresql.NewPolicyService(db)
+	a.httpServer.AccessService = postgresql.NewAccessService(db)
+	a.httpServer.WebhookService = postgresql.NewWebhookService(db)
+	a.httpServer.AllowPrivateWebhooks = config.Webhook.AllowPrivate
+	a.httpServer.APIKeyService = postgresql.NewAPIKeyService(db)
+	if config.Auth.OIDC.Issuer != "" {
+		if a.httpServer.JWTAuthenticator, err = newJWTAuthenticator(config); err != nil {
+			return nil, err
+		}
+		a.httpServer.JWTAuthenticator.Organizations = postgresql.NewOrganizationService(db)
+	}
+
+	if len(config.Worker.Analyzers) > 0 {
+		a.worker = newWorker(
+			postgresql.NewScanQueue(db, workerID(), config.Worker.LeaseDuration, config.Worker.MaxAttempts),
+			repositoryService,
+			newAnalyzers(config.Worker.Analyzers),
+			config.Worker.PoolSize,
+			config.Worker.PollInterval,
+			config.Worker.LeaseDuration,
+		)
+	}
+
+	a.dispatcher = newDispatcher(
+		postgresql.NewWebhookQueue(db),
+		http.NewWebhookSender(config.Webhook.Timeout, config.Webhook.AllowPrivate),
+		config.Webhook.PollInterval,
+		config.Webhook.MaxAttempts,
+		config.Webhook.Backoff,
+		config.Webhook.Timeout,
+	)
+
+	sink, err := newSink(config.Outbox.Sink)
+	if err != nil {
+		return nil, err
+	}
+	if sink != nil {
+		a.relay = newRelay(
+			postgresql.NewOutbox(db, config.Outbox.Lease),
+			sink,
+			config.Outbox.PollInterval,
+			config.Outbox.BatchSize,
+			config.Outbox.Retention,
+		)
+	}
+
+	return a, nil
+}
+
+// newJWTAuthenticator accepts the tokens of the configured identity provider.
+func newJWTAuthenticator(config *ssr.Config) (*http.JWTAuthenticator, error) {
+	oidc := config.Auth.OIDC
+	if oidc.JWKS == "" {
+		return nil, errors.New("auth.oidc.jwks is required along with auth.oidc.issuer")
+	}
+
+	a := http.NewJWTAuthenticator(oidc.Issuer, oidc.Audience, http.NewJWKS(oidc.JWKS))
+	a.EmailClaim = oidc.EmailClaim
+	a.GroupsClaim = oidc.GroupsClaim
+	a.OrganizationClaim = oidc.OrganizationClaim
+	for _, g := range oidc.Groups {
+		for _, s := range g.Scopes {
+			scope, err := ssr.ParseScope(s)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid scope of group %s", g.Name)
+			}
+			a.GroupScopes[g.Name] = append(a.GroupScopes[g.Name], scope)
+		}
+	}
+	return a, nil
 }
 
 func (a *app) Run(ctx context.Context) error {
@@ -91,6 +261,30 @@ func (a *app) Run(ctx context.Context) error {
 	if err := a.httpServer.Open(); err != nil {
 		return err
 	}
+
+	if a.worker != nil {
+		a.worker.Start(ctx)
+	}
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		expireSuppressions(ctx, a.suppressionService, a.config.Suppression.ExpiryInterval)
+	}()
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.dispatcher.run(ctx)
+	}()
+
+	if a.relay != nil {
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			a.relay.run(ctx)
+		}()
+	}
 	return nil
 }
 
@@ -101,5 +295,13 @@ func (a *app) Close() error {
 		}
 	}
 
+	if a.worker != nil {
+		a.worker.Wait()
+	}
+	a.wg.Wait()
+
+	if a.relay != nil {
+		return a.relay.sink.Close()
+	}
 	return nil
 }
\ No newline at end of file
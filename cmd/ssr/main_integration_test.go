@@ -6,7 +6,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
 	"testing"
 	"time"
 
@@ -19,6 +21,18 @@ import (
 
 var scanID uuid.UUID
 
+// newRequest authenticates requests with the API key of the SSR_API_KEY environment variable.
+func newRequest(method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if key := os.Getenv("SSR_API_KEY"); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	return req, nil
+}
+
 func TestScanService(t *testing.T) {
 	t.Run("create scan", testCreateScan)
 
@@ -60,7 +74,7 @@ func testCreateScan(t *testing.T) {
 	body, err := json.Marshal(scan)
 	require.NoError(t, err)
 
-	req, err := http.NewRequest(http.MethodPost, "http://localhost:8080/scans/1", bytes.NewBuffer(body))
+	req, err := newRequest(http.MethodPost, "http://localhost:8080/scans/1", bytes.NewBuffer(body))
 	require.NoError(t, err)
 
 	client := http.Client{}
@@ -75,7 +89,7 @@ func testCreateScan(t *testing.T) {
 }
 
 func testGetScan(t *testing.T) {
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost:8080/scans/%s", scanID), nil)
+	req, err := newRequest(http.MethodGet, fmt.Sprintf("http://localhost:8080/scans/%s", scanID), nil)
 	require.NoError(t, err)
 
 	client := http.Client{}
@@ -119,7 +133,7 @@ func testUpdateScan(t *testing.T) {
 	body, err := json.Marshal(scan)
 	require.NoError(t, err)
 
-	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("http://localhost:8080/scans/%s", scanID), bytes.NewBuffer(body))
+	req, err := newRequest(http.MethodPut, fmt.Sprintf("http://localhost:8080/scans/%s", scanID), bytes.NewBuffer(body))
 	require.NoError(t, err)
 
 	client := http.Client{}
@@ -133,7 +147,7 @@ func testUpdateScan(t *testing.T) {
 }
 
 func testDeleteScan(t *testing.T) {
-	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("http://localhost:8080/scans/%s", scanID), nil)
+	req, err := newRequest(http.MethodDelete, fmt.Sprintf("http://localhost:8080/scans/%s", scanID), nil)
 	require.NoError(t, err)
 
 	client := http.Client{}
@@ -143,7 +157,7 @@ func testDeleteScan(t *testing.T) {
 }
 
 func testListScans(t *testing.T) {
-	req, err := http.NewRequest(http.MethodGet, "http://localhost:8080/scans?limit=1&page=1", nil)
+	req, err := newRequest(http.MethodGet, "http://localhost:8080/scans?limit=1&page=1", nil)
 	require.NoError(t, err)
 
 	client := http.Client{}
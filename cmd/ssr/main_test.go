@@ -0,0 +1,46 @@
+// +build !integration
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+)
+
+func TestNewJWTAuthenticator(t *testing.T) {
+	config := &ssr.Config{}
+	config.Auth.OIDC.Issuer = "https://idp.example.com"
+	config.Auth.OIDC.GroupsClaim = "roles"
+	config.Auth.OIDC.OrganizationClaim = "org"
+	config.Auth.OIDC.Groups = []ssr.GroupConfig{
+		{Name: "auditors", Scopes: []string{"scans:read", "repositories:read"}},
+	}
+
+	_, err := newJWTAuthenticator(config)
+	assert.Error(t, err, "a key set is required")
+
+	config.Auth.OIDC.JWKS = "https://idp.example.com/jwks.json"
+	a, err := newJWTAuthenticator(config)
+	require.NoError(t, err)
+	assert.Equal(t, "roles", a.GroupsClaim)
+	assert.Equal(t, "org", a.OrganizationClaim)
+	assert.Equal(t, []ssr.Scope{ssr.ScopeScansRead, ssr.ScopeRepositoriesRead}, a.GroupScopes["auditors"])
+
+	config.Auth.OIDC.Groups[0].Scopes = []string{"scans:everything"}
+	_, err = newJWTAuthenticator(config)
+	assert.ErrorIs(t, err, ssr.ErrInvalidScope)
+}
+
+func TestNewAppRejectsShortLease(t *testing.T) {
+	config := &ssr.Config{}
+	config.Worker.Analyzers = []ssr.AnalyzerConfig{{}}
+	config.Worker.LeaseDuration = 2 * time.Nanosecond
+
+	_, err := NewApp(config)
+	assert.Error(t, err)
+}
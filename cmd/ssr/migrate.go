@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/quantonganh/ssr/postgresql"
+)
+
+const migrateUsage = "usage: ssr migrate up|down|status"
+
+type migrator interface {
+	Up(ctx context.Context) ([]postgresql.Migration, error)
+	Down(ctx context.Context) (*postgresql.Migration, error)
+	Status(ctx context.Context) ([]postgresql.MigrationStatus, error)
+}
+
+// migrate runs the migrate subcommand: up applies the pending migrations, down reverts the last applied one,
+// and status lists every migration along with when it was applied.
+func migrate(ctx context.Context, m migrator, args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(w, "no pending migration")
+		}
+		for _, migration := range applied {
+			fmt.Fprintf(w, "applied %d_%s\n", migration.Version, migration.Name)
+		}
+	case "down":
+		reverted, err := m.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			fmt.Fprintln(w, "no applied migration")
+			return nil
+		}
+		fmt.Fprintf(w, "reverted %d_%s\n", reverted.Version, reverted.Name)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return tw.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// +build !integration
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr/postgresql"
+)
+
+type fakeMigrator struct {
+	statuses []postgresql.MigrationStatus
+}
+
+func (m *fakeMigrator) Up(ctx context.Context) ([]postgresql.Migration, error) {
+	var applied []postgresql.Migration
+	now := time.Now()
+	for i, s := range m.statuses {
+		if s.AppliedAt == nil {
+			m.statuses[i].AppliedAt = &now
+			applied = append(applied, s.Migration)
+		}
+	}
+	return applied, nil
+}
+
+func (m *fakeMigrator) Down(ctx context.Context) (*postgresql.Migration, error) {
+	for i := len(m.statuses) - 1; i >= 0; i-- {
+		if m.statuses[i].AppliedAt != nil {
+			m.statuses[i].AppliedAt = nil
+			return &m.statuses[i].Migration, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *fakeMigrator) Status(ctx context.Context) ([]postgresql.MigrationStatus, error) {
+	return m.statuses, nil
+}
+
+func TestMigrate(t *testing.T) {
+	appliedAt := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	m := &fakeMigrator{
+		statuses: []postgresql.MigrationStatus{
+			{Migration: postgresql.Migration{Version: 1, Name: "baseline"}, AppliedAt: &appliedAt},
+			{Migration: postgresql.Migration{Version: 2, Name: "normalize_findings"}},
+		},
+	}
+
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		err := migrate(context.Background(), m, args, &out)
+		return out.String(), err
+	}
+
+	out, err := run("status")
+	require.NoError(t, err)
+	assert.Equal(t, "VERSION  NAME                APPLIED AT\n1        baseline            2021-12-01T00:00:00Z\n2        normalize_findings  pending\n", out)
+
+	out, err = run("up")
+	require.NoError(t, err)
+	assert.Equal(t, "applied 2_normalize_findings\n", out)
+
+	out, err = run("up")
+	require.NoError(t, err)
+	assert.Equal(t, "no pending migration\n", out)
+
+	out, err = run("down")
+	require.NoError(t, err)
+	assert.Equal(t, "reverted 2_normalize_findings\n", out)
+
+	for _, args := range [][]string{nil, {"sideways"}, {"up", "2"}} {
+		_, err := run(args...)
+		assert.EqualError(t, err, migrateUsage)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/quantonganh/ssr"
+)
+
+const organizationUsage = "usage: ssr organization create NAME | list"
+
+// organization runs the organization subcommand: create adds a tenant and list prints them. Organizations are only
+// managed from the command line, the API only ever serves the organization of its user.
+func organization(ctx context.Context, organizationService ssr.OrganizationService, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(organizationUsage)
+	}
+
+	switch args[0] {
+	case "create":
+		if len(args) != 2 || args[1] == "" {
+			return errors.New(organizationUsage)
+		}
+		o := &ssr.Organization{Name: args[1]}
+		if err := organizationService.Create(ctx, o); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "created organization %d\n", o.ID)
+	case "list":
+		if len(args) != 1 {
+			return errors.New(organizationUsage)
+		}
+		organizations, err := organizationService.List(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME")
+		for _, o := range organizations {
+			fmt.Fprintf(tw, "%d\t%s\n", o.ID, o.Name)
+		}
+		return tw.Flush()
+	default:
+		return errors.New(organizationUsage)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+// +build !integration
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+	"github.com/quantonganh/ssr/mocks"
+)
+
+func TestOrganization(t *testing.T) {
+	organizationService := new(mocks.OrganizationService)
+	organizationService.On("Create", mock.Anything, &ssr.Organization{Name: "payments"}).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(1).(*ssr.Organization).ID = 2
+	})
+	organizationService.On("List", mock.Anything).Return([]*ssr.Organization{
+		{ID: 1, Name: "default"},
+		{ID: 2, Name: "payments"},
+	}, nil)
+
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		err := organization(context.Background(), organizationService, args, &out)
+		return out.String(), err
+	}
+
+	out, err := run("create", "payments")
+	require.NoError(t, err)
+	assert.Equal(t, "created organization 2\n", out)
+
+	out, err = run("list")
+	require.NoError(t, err)
+	assert.Equal(t, "ID  NAME\n1   default\n2   payments\n", out)
+
+	for _, args := range [][]string{nil, {"create"}, {"create", "a", "b"}, {"list", "all"}, {"delete", "2"}} {
+		_, err := run(args...)
+		assert.EqualError(t, err, organizationUsage)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/quantonganh/ssr"
+	"github.com/quantonganh/ssr/file"
+)
+
+const (
+	defaultOutboxPollInterval = time.Second
+	defaultOutboxBatchSize    = 100
+	defaultOutboxRetention    = 7 * 24 * time.Hour
+	// defaultOutboxLease bounds how long a relay may take to publish a batch before another relay publishes it again.
+	defaultOutboxLease = time.Minute
+
+	// outboxPurgeInterval is how often the changes published before the retention are deleted.
+	outboxPurgeInterval = time.Hour
+)
+
+// newSink opens the sink named by the outbox configuration, nil if none is.
+func newSink(name string) (ssr.Sink, error) {
+	switch {
+	case name == "":
+		return nil, nil
+	case name == "stdout":
+		return file.OpenSink("-")
+	case strings.HasPrefix(name, "file:"):
+		return file.OpenSink(strings.TrimPrefix(name, "file:"))
+	}
+	return nil, errors.Errorf("unsupported outbox sink: %s", name)
+}
+
+// relay publishes the changes recorded in the outbox to a sink.
+type relay struct {
+	outbox ssr.Outbox
+	sink   ssr.Sink
+
+	pollInterval time.Duration
+	batchSize    int
+	retention    time.Duration
+}
+
+func newRelay(outbox ssr.Outbox, sink ssr.Sink, pollInterval time.Duration, batchSize int, retention time.Duration) *relay {
+	if pollInterval <= 0 {
+		pollInterval = defaultOutboxPollInterval
+	}
+	if batchSize <= 0 {
+		batchSize = defaultOutboxBatchSize
+	}
+	if retention <= 0 {
+		retention = defaultOutboxRetention
+	}
+	return &relay{
+		outbox:       outbox,
+		sink:         sink,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+		retention:    retention,
+	}
+}
+
+// run publishes the changes until ctx is cancelled. A batch that the sink fails to publish is published again once
+// its lease expired, the published changes are purged once they are older than the retention.
+func (r *relay) run(ctx context.Context) {
+	var purgedAt time.Time
+	for {
+		n, err := r.outbox.Relay(ctx, r.batchSize, r.sink)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("outbox: %+v", err)
+		}
+		if n > 0 {
+			continue
+		}
+
+		if time.Since(purgedAt) >= outboxPurgeInterval && ctx.Err() == nil {
+			purgedAt = time.Now()
+			purged, err := r.outbox.Purge(ctx, purgedAt.Add(-r.retention))
+			if err != nil {
+				log.Printf("outbox: %+v", err)
+			} else if purged > 0 {
+				log.Printf("outbox: purged %d published change(s)", purged)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.pollInterval):
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// +build !integration
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+)
+
+type fakeOutbox struct {
+	mu      sync.Mutex
+	changes []*ssr.Change
+	// failures is how many relays fail before the sink is called.
+	failures  int
+	published int
+	purged    []time.Time
+}
+
+func (o *fakeOutbox) Relay(ctx context.Context, limit int, sink ssr.Sink) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.failures > 0 {
+		o.failures--
+		return 0, errors.New("connection refused")
+	}
+	batch := o.changes[o.published:]
+	if len(batch) > limit {
+		batch = batch[:limit]
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	if err := sink.Publish(ctx, batch); err != nil {
+		return 0, err
+	}
+	o.published += len(batch)
+	return len(batch), nil
+}
+
+func (o *fakeOutbox) Purge(ctx context.Context, before time.Time) (int64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.purged = append(o.purged, before)
+	return 0, nil
+}
+
+func TestRelay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "relay")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "changes.jsonl")
+
+	sink, err := newSink("file:" + path)
+	require.NoError(t, err)
+
+	o := &fakeOutbox{failures: 1}
+	for i := 0; i < 5; i++ {
+		o.changes = append(o.changes, &ssr.Change{ID: uuid.New(), Sequence: uint64(i + 1), Type: ssr.ChangeScanUpdated})
+	}
+	r := newRelay(o, sink, time.Millisecond, 2, 0)
+	assert.Equal(t, defaultOutboxRetention, r.retention)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.run(ctx)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		return o.published == len(o.changes) && len(o.purged) > 0
+	}, time.Second, time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("relay did not stop")
+	}
+	require.NoError(t, sink.Close())
+
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(b), []byte("\n"))
+	require.Len(t, lines, 5)
+	assert.Contains(t, string(lines[4]), o.changes[4].ID.String())
+
+	require.Len(t, o.purged, 1)
+	assert.WithinDuration(t, time.Now().Add(-defaultOutboxRetention), o.purged[0], 5*time.Second)
+}
+
+func TestNewSink(t *testing.T) {
+	sink, err := newSink("")
+	require.NoError(t, err)
+	assert.Nil(t, sink)
+
+	sink, err = newSink("stdout")
+	require.NoError(t, err)
+	assert.NotNil(t, sink)
+
+	_, err = newSink("kafka://localhost:9092")
+	assert.Error(t, err)
+}
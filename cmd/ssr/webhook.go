@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/quantonganh/ssr"
+)
+
+const (
+	defaultWebhookPollInterval = 5 * time.Second
+	defaultWebhookMaxAttempts  = 8
+	defaultWebhookBackoff      = 30 * time.Second
+	defaultWebhookTimeout      = 10 * time.Second
+
+	// webhookBatchSize is how many deliveries are claimed at once.
+	webhookBatchSize = 10
+)
+
+// dispatcher sends the deliveries of webhooks, retrying the failed ones with exponential backoff.
+type dispatcher struct {
+	queue  ssr.WebhookQueue
+	sender ssr.WebhookSender
+
+	pollInterval time.Duration
+	maxAttempts  int
+	backoff      time.Duration
+	// lease is how long a batch stays claimed: long enough to send every delivery of the batch.
+	lease time.Duration
+}
+
+func newDispatcher(queue ssr.WebhookQueue, sender ssr.WebhookSender, pollInterval time.Duration, maxAttempts int, backoff, timeout time.Duration) *dispatcher {
+	if pollInterval <= 0 {
+		pollInterval = defaultWebhookPollInterval
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = defaultWebhookMaxAttempts
+	}
+	if backoff <= 0 {
+		backoff = defaultWebhookBackoff
+	}
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &dispatcher{
+		queue:        queue,
+		sender:       sender,
+		pollInterval: pollInterval,
+		maxAttempts:  maxAttempts,
+		backoff:      backoff,
+		lease:        timeout * (webhookBatchSize + 1),
+	}
+}
+
+// run sends the due deliveries until ctx is cancelled.
+func (d *dispatcher) run(ctx context.Context) {
+	for {
+		n, err := d.dispatch(ctx)
+		if err != nil {
+			log.Printf("webhook: %+v", err)
+		}
+		if n > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.pollInterval):
+		}
+	}
+}
+
+// dispatch claims a batch of due deliveries and attempts each of them once.
+// It reports how many deliveries were claimed.
+func (d *dispatcher) dispatch(ctx context.Context) (int, error) {
+	if ctx.Err() != nil {
+		return 0, nil
+	}
+
+	deliveries, err := d.queue.Claim(ctx, webhookBatchSize, d.lease)
+	if err != nil {
+		return 0, err
+	}
+	for _, delivery := range deliveries {
+		if delivery.Webhook == nil {
+			delivery.Record(time.Now(), 0, ssr.ErrWebhookNotFound, 1, d.backoff)
+		} else {
+			status, err := d.sender.Send(ctx, delivery.Webhook, delivery)
+			if ctx.Err() != nil {
+				// Shutting down: the lease will expire and the delivery will be attempted again.
+				return len(deliveries), nil
+			}
+			delivery.Record(time.Now(), status, err, d.maxAttempts, d.backoff)
+		}
+		if delivery.Status == ssr.DeliveryFailed {
+			log.Printf("webhook: giving up delivery %d of webhook %d after %d attempt(s): %s", delivery.ID, delivery.WebhookID, delivery.Attempts, delivery.Error)
+		}
+		if err := d.queue.Save(ctx, delivery); err != nil {
+			return len(deliveries), err
+		}
+	}
+	return len(deliveries), nil
+}
@@ -0,0 +1,131 @@
+// +build !integration
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+	ssrhttp "github.com/quantonganh/ssr/http"
+)
+
+type fakeWebhookQueue struct {
+	mu         sync.Mutex
+	deliveries []*ssr.WebhookDelivery
+	saved      []ssr.WebhookDelivery
+}
+
+// Claim hands out the pending deliveries regardless of their next attempt, so that retries run right away.
+func (q *fakeWebhookQueue) Claim(ctx context.Context, limit int, lease time.Duration) ([]*ssr.WebhookDelivery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var due []*ssr.WebhookDelivery
+	for _, d := range q.deliveries {
+		if d.Status == ssr.DeliveryPending && len(due) < limit {
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+func (q *fakeWebhookQueue) Save(ctx context.Context, d *ssr.WebhookDelivery) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.saved = append(q.saved, *d)
+	return nil
+}
+
+func TestDispatcher(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []*http.Request
+		bodies   [][]byte
+	)
+	failures := 2
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := ioutil.ReadAll(r.Body)
+		received = append(received, r)
+		bodies = append(bodies, body)
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	webhook := &ssr.Webhook{ID: 2, URL: receiver.URL, Secret: "s3cr3t", Events: ssr.EventTypeList{ssr.EventScanSucceeded}}
+	e := &ssr.Event{ID: uuid.New(), Type: ssr.EventScanSucceeded, RepositoryID: 1, ScanID: uuid.New(), ScanStatus: ssr.Success}
+	delivery, err := ssr.NewWebhookDelivery(webhook.ID, e, time.Now())
+	require.NoError(t, err)
+	delivery.ID = 1
+	delivery.Webhook = webhook
+	orphan, err := ssr.NewWebhookDelivery(3, e, time.Now())
+	require.NoError(t, err)
+	orphan.ID = 2
+
+	q := &fakeWebhookQueue{deliveries: []*ssr.WebhookDelivery{delivery, orphan}}
+	d := newDispatcher(q, ssrhttp.NewWebhookSender(time.Second, true), time.Millisecond, 5, time.Minute, time.Second)
+
+	for i := 0; i < 3; i++ {
+		_, err := d.dispatch(context.Background())
+		require.NoError(t, err)
+	}
+	n, err := d.dispatch(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	assert.Equal(t, ssr.DeliverySucceeded, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, http.StatusOK, delivery.ResponseStatus)
+	assert.NotNil(t, delivery.DeliveredAt)
+
+	assert.Equal(t, ssr.DeliveryFailed, orphan.Status)
+	assert.Equal(t, ssr.ErrWebhookNotFound.Error(), orphan.Error)
+
+	require.Len(t, q.saved, 4)
+	assert.Equal(t, ssr.DeliveryPending, q.saved[0].Status)
+	assert.Equal(t, "unexpected status: 503", q.saved[0].Error)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), *q.saved[0].NextAttemptAt, 5*time.Second)
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), *q.saved[2].NextAttemptAt, 5*time.Second)
+
+	require.Len(t, received, 3)
+	for i, r := range received {
+		assert.Equal(t, e.ID.String(), r.Header.Get(ssrhttp.HeaderEventID))
+		assert.True(t, ssr.VerifySignature("s3cr3t", bodies[i], r.Header.Get(ssrhttp.HeaderSignature)))
+	}
+}
+
+func TestDispatcherRun(t *testing.T) {
+	q := &fakeWebhookQueue{}
+	d := newDispatcher(q, ssrhttp.NewWebhookSender(time.Second, true), time.Millisecond, 0, 0, 0)
+	assert.Equal(t, defaultWebhookMaxAttempts, d.maxAttempts)
+	assert.Equal(t, defaultWebhookBackoff, d.backoff)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.run(ctx)
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("dispatcher did not stop")
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/quantonganh/ssr"
+)
+
+const (
+	defaultPoolSize      = 1
+	defaultPollInterval  = 5 * time.Second
+	defaultLeaseDuration = time.Minute
+	defaultMaxAttempts   = 3
+
+	// minLeaseDuration is the shortest lease that can be configured: the heartbeat renews the lease three times per
+	// lease duration, and would otherwise write to the database continuously, or panic below 3ns.
+	minLeaseDuration = time.Second
+)
+
+// checkLeaseDuration rejects a configured lease duration that is shorter than minLeaseDuration.
+func checkLeaseDuration(d time.Duration) error {
+	if d < minLeaseDuration {
+		return errors.Errorf("worker.leaseduration must be at least %s, got %s", minLeaseDuration, d)
+	}
+	return nil
+}
+
+// worker runs a pool of goroutines that take queued scans, analyze the repository they belong to
+// and store the result.
+type worker struct {
+	queue             ssr.ScanQueue
+	repositoryService ssr.RepositoryService
+	analyzers         []ssr.Analyzer
+
+	poolSize      int
+	pollInterval  time.Duration
+	leaseDuration time.Duration
+	checkout      func(ctx context.Context, repo *ssr.Repository, branch, dir string) error
+
+	wg sync.WaitGroup
+}
+
+func newWorker(queue ssr.ScanQueue, repositoryService ssr.RepositoryService, analyzers []ssr.Analyzer, poolSize int, pollInterval, leaseDuration time.Duration) *worker {
+	if poolSize <= 0 {
+		poolSize = defaultPoolSize
+	}
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	if leaseDuration <= 0 {
+		leaseDuration = defaultLeaseDuration
+	}
+	return &worker{
+		queue:             queue,
+		repositoryService: repositoryService,
+		analyzers:         analyzers,
+		poolSize:          poolSize,
+		pollInterval:      pollInterval,
+		leaseDuration:     leaseDuration,
+		checkout:          checkout,
+	}
+}
+
+// Start spawns the pool along with a reaper that requeues the scans abandoned by dead workers.
+// The goroutines exit once ctx is cancelled.
+func (w *worker) Start(ctx context.Context) {
+	for i := 0; i < w.poolSize; i++ {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.loop(ctx)
+		}()
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.reap(ctx)
+	}()
+}
+
+// Wait blocks until all goroutines of the pool have returned.
+func (w *worker) Wait() {
+	w.wg.Wait()
+}
+
+func (w *worker) loop(ctx context.Context) {
+	for {
+		processed, err := w.processNext(ctx)
+		if err != nil {
+			log.Printf("worker: %+v", err)
+		}
+		if processed {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.pollInterval):
+		}
+	}
+}
+
+// processNext claims a single scan and runs it to completion.
+// It reports whether a scan was claimed.
+func (w *worker) processNext(ctx context.Context) (bool, error) {
+	if ctx.Err() != nil {
+		return false, nil
+	}
+
+	scan, err := w.queue.Claim()
+	if err != nil {
+		return false, err
+	}
+	if scan == nil {
+		return false, nil
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	leaseLost := make(chan struct{})
+	go w.heartbeat(runCtx, scan, cancel, leaseLost)
+
+	status := ssr.Success
+	findings, err := w.run(runCtx, scan)
+	if err != nil {
+		log.Printf("worker: scan %s failed: %+v", scan.ID, err)
+		status = ssr.Failure
+		findings = nil
+	}
+
+	select {
+	case <-leaseLost:
+		return true, errors.Wrapf(ssr.ErrLeaseLost, "abandoning scan %s", scan.ID)
+	default:
+	}
+	if ctx.Err() != nil {
+		// Shutting down: the lease will expire and another worker will pick the scan up.
+		return true, nil
+	}
+
+	if err := w.queue.Finish(scan.ID, status, findings); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// heartbeat renews the lease of scan until ctx is done.
+// If the lease is lost, it closes leaseLost and cancels the run.
+func (w *worker) heartbeat(ctx context.Context, scan *ssr.Scan, cancel context.CancelFunc, leaseLost chan<- struct{}) {
+	ticker := time.NewTicker(w.leaseDuration / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := w.queue.Heartbeat(scan.ID)
+			if errors.Is(err, ssr.ErrLeaseLost) {
+				close(leaseLost)
+				cancel()
+				return
+			}
+			if err != nil {
+				log.Printf("worker: %+v", err)
+			}
+		}
+	}
+}
+
+// reap periodically returns the scans whose lease has expired to the queue.
+func (w *worker) reap(ctx context.Context) {
+	ticker := time.NewTicker(w.leaseDuration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := w.queue.Requeue()
+			if err != nil {
+				log.Printf("worker: %+v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("worker: requeued %d abandoned scan(s)", n)
+			}
+		}
+	}
+}
+
+func (w *worker) run(ctx context.Context, scan *ssr.Scan) (ssr.Findings, error) {
+	repo, err := w.repositoryService.Get(ctx, scan.RepositoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := ioutil.TempDir("", "ssr-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create working directory")
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	if err := w.checkout(ctx, repo, scan.Branch, src); err != nil {
+		return nil, err
+	}
+
+	findings := ssr.Findings{}
+	for _, a := range w.analyzers {
+		f, err := a.Analyze(ctx, src)
+		if err != nil {
+			return nil, errors.Wrapf(err, "analyzer %s", a.Name())
+		}
+		findings = append(findings, f...)
+	}
+	return findings, nil
+}
+
+// workerID identifies this process as the owner of the scans it claims.
+func workerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "ssr"
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.New().String()[:8])
+}
@@ -0,0 +1,196 @@
+// +build !integration
+
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+	"github.com/quantonganh/ssr/mocks"
+)
+
+type fakeQueue struct {
+	mu        sync.Mutex
+	queued    []*ssr.Scan
+	finished  map[uuid.UUID]ssr.Status
+	findings  map[uuid.UUID]ssr.Findings
+	leaseLost bool
+}
+
+func (q *fakeQueue) Claim() (*ssr.Scan, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.queued) == 0 {
+		return nil, nil
+	}
+	scan := q.queued[0]
+	q.queued = q.queued[1:]
+	scan.Status = ssr.InProgress
+	return scan, nil
+}
+
+func (q *fakeQueue) Heartbeat(id uuid.UUID) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.leaseLost {
+		return ssr.ErrLeaseLost
+	}
+	return nil
+}
+
+func (q *fakeQueue) Requeue() (int64, error) {
+	return 0, nil
+}
+
+func (q *fakeQueue) Finish(id uuid.UUID, status ssr.Status, findings ssr.Findings) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.finished[id] = status
+	q.findings[id] = findings
+	return nil
+}
+
+type fakeAnalyzer struct {
+	findings ssr.Findings
+	err      error
+	block    bool
+}
+
+func (a *fakeAnalyzer) Name() string {
+	return "fake"
+}
+
+func (a *fakeAnalyzer) Analyze(ctx context.Context, dir string) (ssr.Findings, error) {
+	if a.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return a.findings, a.err
+}
+
+func TestWorker(t *testing.T) {
+	finding := ssr.Finding{
+		Type:   "sast",
+		RuleID: "G404",
+		Location: ssr.Location{
+			Path: "util/util.go",
+		},
+	}
+	repo := &ssr.Repository{
+		ID:       1,
+		Provider: "GitHub",
+		FullName: "quantonganh/ssr",
+	}
+	repoService := new(mocks.RepositoryService)
+	repoService.On("Get", mock.Anything, uint64(1)).Return(repo, nil)
+	repoService.On("Get", mock.Anything, uint64(2)).Return(nil, ssr.ErrRepositoryNotFound)
+
+	t.Run("success", func(t *testing.T) {
+		scan := &ssr.Scan{ID: uuid.New(), RepositoryID: 1}
+		q := newFakeQueue(scan)
+		w := newTestWorker(q, repoService, &fakeAnalyzer{findings: ssr.Findings{finding}})
+
+		processed, err := w.processNext(context.Background())
+		require.NoError(t, err)
+		assert.True(t, processed)
+		assert.Equal(t, ssr.Success, q.finished[scan.ID])
+		assert.Equal(t, ssr.Findings{finding}, q.findings[scan.ID])
+	})
+
+	t.Run("analyzer failure", func(t *testing.T) {
+		scan := &ssr.Scan{ID: uuid.New(), RepositoryID: 1}
+		q := newFakeQueue(scan)
+		w := newTestWorker(q, repoService, &fakeAnalyzer{err: errors.New("boom")})
+
+		processed, err := w.processNext(context.Background())
+		require.NoError(t, err)
+		assert.True(t, processed)
+		assert.Equal(t, ssr.Failure, q.finished[scan.ID])
+		assert.Empty(t, q.findings[scan.ID])
+	})
+
+	t.Run("unknown repository", func(t *testing.T) {
+		scan := &ssr.Scan{ID: uuid.New(), RepositoryID: 2}
+		q := newFakeQueue(scan)
+		w := newTestWorker(q, repoService, &fakeAnalyzer{})
+
+		processed, err := w.processNext(context.Background())
+		require.NoError(t, err)
+		assert.True(t, processed)
+		assert.Equal(t, ssr.Failure, q.finished[scan.ID])
+	})
+
+	t.Run("lease lost", func(t *testing.T) {
+		scan := &ssr.Scan{ID: uuid.New(), RepositoryID: 1}
+		q := newFakeQueue(scan)
+		q.leaseLost = true
+		w := newTestWorker(q, repoService, &fakeAnalyzer{block: true})
+
+		processed, err := w.processNext(context.Background())
+		assert.True(t, processed)
+		assert.True(t, errors.Is(err, ssr.ErrLeaseLost))
+		assert.Empty(t, q.finished)
+	})
+
+	t.Run("empty queue", func(t *testing.T) {
+		w := newTestWorker(newFakeQueue(), repoService, &fakeAnalyzer{})
+
+		processed, err := w.processNext(context.Background())
+		require.NoError(t, err)
+		assert.False(t, processed)
+	})
+
+	t.Run("pool drains the queue", func(t *testing.T) {
+		scans := make([]*ssr.Scan, 10)
+		for i := range scans {
+			scans[i] = &ssr.Scan{ID: uuid.New(), RepositoryID: 1}
+		}
+		q := newFakeQueue(scans...)
+		w := newTestWorker(q, repoService, &fakeAnalyzer{findings: ssr.Findings{finding}})
+		w.poolSize = 3
+
+		ctx, cancel := context.WithCancel(context.Background())
+		w.Start(ctx)
+		require.Eventually(t, func() bool {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			return len(q.finished) == len(scans)
+		}, time.Second, 10*time.Millisecond)
+		cancel()
+		w.Wait()
+	})
+}
+
+func newFakeQueue(scans ...*ssr.Scan) *fakeQueue {
+	return &fakeQueue{
+		queued:   scans,
+		finished: make(map[uuid.UUID]ssr.Status),
+		findings: make(map[uuid.UUID]ssr.Findings),
+	}
+}
+
+func newTestWorker(q ssr.ScanQueue, repoService ssr.RepositoryService, analyzer ssr.Analyzer) *worker {
+	w := newWorker(q, repoService, []ssr.Analyzer{analyzer}, 1, 10*time.Millisecond, 30*time.Millisecond)
+	w.checkout = func(ctx context.Context, repo *ssr.Repository, branch, dir string) error {
+		return nil
+	}
+	return w
+}
+
+func TestCheckLeaseDuration(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second, 2 * time.Nanosecond, 500 * time.Millisecond} {
+		assert.Error(t, checkLeaseDuration(d), d.String())
+	}
+	for _, d := range []time.Duration{time.Second, time.Minute} {
+		assert.NoError(t, checkLeaseDuration(d), d.String())
+	}
+}
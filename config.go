@@ -1,15 +1,99 @@
 package ssr
 
+import (
+	"time"
+)
+
 type Config struct {
 	HTTP struct {
 		Addr string
+		// RequestTimeout bounds how long a request may take, zero meaning no limit.
+		RequestTimeout time.Duration
 	}
 
 	DB struct {
-		Host string
-		Port int
-		User string
+		Host     string
+		Port     int
+		User     string
 		Password string
-		Name string
+		Name     string
+		// Migrate applies the pending migrations on startup, otherwise they are applied with `ssr migrate up`.
+		Migrate bool
+	}
+
+	Worker struct {
+		PoolSize     int
+		PollInterval time.Duration
+		// LeaseDuration is how long a scan stays claimed by a worker without a heartbeat
+		// before it is handed out again.
+		LeaseDuration time.Duration
+		MaxAttempts   int
+		Analyzers     []AnalyzerConfig
+	}
+
+	Auth struct {
+		OIDC struct {
+			// Issuer is the identity provider whose tokens are accepted, only API keys are without it.
+			Issuer   string
+			Audience string
+			// JWKS is the URL or the path of the key set that tokens are signed with.
+			JWKS        string
+			EmailClaim  string
+			GroupsClaim string
+			// OrganizationClaim names the claim that holds the organization of the user, all users belonging
+			// to the default organization without it.
+			OrganizationClaim string
+			// Groups grants scopes to the members of groups of the identity provider.
+			Groups []GroupConfig
+		}
+	}
+
+	Suppression struct {
+		// ExpiryInterval is how often the findings of expired suppressions are re-opened.
+		ExpiryInterval time.Duration
 	}
+
+	Webhook struct {
+		// PollInterval is how often the deliveries that are due are looked for.
+		PollInterval time.Duration
+		// MaxAttempts is how many times a delivery is attempted before it is marked as failed.
+		MaxAttempts int
+		// Backoff is the delay before the first retry of a delivery, doubled on each retry.
+		Backoff time.Duration
+		// Timeout bounds each attempt.
+		Timeout time.Duration
+		// AllowPrivate lets webhooks post to loopback, private and link-local addresses, for receivers that run
+		// next to the server.
+		AllowPrivate bool
+	}
+
+	Outbox struct {
+		// Sink is where the changes to scans and findings are published: "stdout" or "file:PATH". Without a sink,
+		// changes are kept in the outbox until one is configured.
+		Sink string
+		// PollInterval is how often unpublished changes are looked for.
+		PollInterval time.Duration
+		// BatchSize is how many changes are published at once.
+		BatchSize int
+		// Retention is how long published changes are kept in the outbox.
+		Retention time.Duration
+		// Lease is how long a relay has to publish a batch before another relay may publish it again.
+		Lease time.Duration
+	}
+}
+
+// AnalyzerConfig describes an external tool that the worker runs against a checked out repository.
+// The command is executed from the root of the checkout and prints its report to stdout.
+// Format names the importer that reads the report, an empty format meaning ssr findings as JSON.
+type AnalyzerConfig struct {
+	Name    string
+	Command string
+	Args    []string
+	Format  string
+}
+
+// GroupConfig grants scopes to the members of a group of the identity provider.
+type GroupConfig struct {
+	Name   string
+	Scopes []string
 }
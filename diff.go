@@ -0,0 +1,48 @@
+package ssr
+
+import (
+	"github.com/google/uuid"
+)
+
+// ScanDiff classifies the findings of a scan against the findings of a base scan.
+type ScanDiff struct {
+	ScanID uuid.UUID `json:"scan_id"`
+	BaseID uuid.UUID `json:"base_id"`
+	// New findings are reported by the scan but not by the base scan.
+	New Findings `json:"new"`
+	// Fixed findings are reported by the base scan but not by the scan.
+	Fixed Findings `json:"fixed"`
+	// Persisting findings are reported by both, as they are in the scan.
+	Persisting Findings `json:"persisting"`
+}
+
+// Diff matches the findings of scan with those of base by fingerprint.
+func Diff(base, scan *Scan) *ScanDiff {
+	diff := &ScanDiff{
+		ScanID:     scan.ID,
+		BaseID:     base.ID,
+		New:        Findings{},
+		Fixed:      Findings{},
+		Persisting: Findings{},
+	}
+
+	seen := make(map[string]bool, len(scan.Findings))
+	inBase := make(map[string]bool, len(base.Findings))
+	for _, f := range base.Findings {
+		inBase[f.Fingerprint] = true
+	}
+	for _, f := range scan.Findings {
+		seen[f.Fingerprint] = true
+		if inBase[f.Fingerprint] {
+			diff.Persisting = append(diff.Persisting, f)
+		} else {
+			diff.New = append(diff.New, f)
+		}
+	}
+	for _, f := range base.Findings {
+		if !seen[f.Fingerprint] {
+			diff.Fixed = append(diff.Fixed, f)
+		}
+	}
+	return diff
+}
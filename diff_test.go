@@ -0,0 +1,32 @@
+package ssr
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	finding := func(fingerprint string, line int64) Finding {
+		return Finding{
+			RuleID:      "G404",
+			Location:    Location{Path: "util/util.go", Positions: Positions{Begin: Begin{Line: line}}},
+			Fingerprint: fingerprint,
+		}
+	}
+	base := &Scan{ID: uuid.New(), Findings: Findings{finding("a", 10), finding("b", 20)}}
+	scan := &Scan{ID: uuid.New(), Findings: Findings{finding("b", 25), finding("c", 30)}}
+
+	diff := Diff(base, scan)
+	assert.Equal(t, scan.ID, diff.ScanID)
+	assert.Equal(t, base.ID, diff.BaseID)
+	assert.Equal(t, Findings{finding("c", 30)}, diff.New)
+	assert.Equal(t, Findings{finding("a", 10)}, diff.Fixed)
+	assert.Equal(t, Findings{finding("b", 25)}, diff.Persisting, "persisting findings are reported as they are in the scan")
+
+	diff = Diff(scan, scan)
+	assert.Empty(t, diff.New)
+	assert.Empty(t, diff.Fixed)
+	assert.Len(t, diff.Persisting, 2)
+}
@@ -0,0 +1,63 @@
+package ssr
+
+import (
+	"errors"
+)
+
+// ErrorKind classifies the errors of the domain, so that transports can map them to their own status codes
+// without knowing every error.
+type ErrorKind string
+
+const (
+	// KindInternal is the kind of the errors that are not domain errors.
+	KindInternal     ErrorKind = "internal"
+	KindNotFound     ErrorKind = "not_found"
+	KindConflict     ErrorKind = "conflict"
+	KindInvalid      ErrorKind = "invalid"
+	KindUnauthorized ErrorKind = "unauthorized"
+	KindForbidden    ErrorKind = "forbidden"
+)
+
+// Error is a domain error of a given kind.
+type Error struct {
+	Kind    ErrorKind
+	Message string
+}
+
+// NewError returns a domain error, to be compared with errors.Is.
+func NewError(kind ErrorKind, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) ErrorKind() ErrorKind {
+	return e.Kind
+}
+
+// KindOf returns the kind of the first domain error in the chain of err, or KindInternal if there is none.
+func KindOf(err error) ErrorKind {
+	var k interface{ ErrorKind() ErrorKind }
+	if errors.As(err, &k) {
+		return k.ErrorKind()
+	}
+	return KindInternal
+}
+
+// ErrorMessage returns the message of the first domain error in the chain of err, without the context
+// it was wrapped with, or an empty string if there is none.
+func ErrorMessage(err error) string {
+	var k interface {
+		error
+		ErrorKind() ErrorKind
+	}
+	if errors.As(err, &k) {
+		return k.Error()
+	}
+	return ""
+}
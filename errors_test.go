@@ -0,0 +1,23 @@
+package ssr
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKindOf(t *testing.T) {
+	assert.Equal(t, KindNotFound, KindOf(errors.Wrap(ErrScanNotFound, "failed to select scan")))
+	assert.Equal(t, KindConflict, KindOf(&TransitionError{From: Success, To: Queued}))
+	assert.Equal(t, KindConflict, KindOf(ErrLeaseLost))
+	assert.Equal(t, KindInvalid, KindOf(fmt.Errorf("%w %q", ErrInvalidSeverity, "urgent")))
+	assert.Equal(t, KindInternal, KindOf(errors.New("connection refused")))
+	assert.Equal(t, KindInternal, KindOf(nil))
+}
+
+func TestErrorMessage(t *testing.T) {
+	assert.Equal(t, "scan not found", ErrorMessage(errors.Wrap(ErrScanNotFound, "failed to select scan")))
+	assert.Empty(t, ErrorMessage(errors.New("connection refused")))
+}
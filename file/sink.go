@@ -0,0 +1,75 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/quantonganh/ssr"
+)
+
+// Sink writes changes to a file or to the standard output, as JSON lines.
+type Sink struct {
+	mu sync.Mutex
+	w  io.Writer
+	// f is the file opened by the sink, synced after every batch.
+	f *os.File
+}
+
+// NewSink returns a sink writing to w, which is not closed with the sink.
+func NewSink(w io.Writer) *Sink {
+	return &Sink{
+		w: w,
+	}
+}
+
+// OpenSink returns a sink appending to the file at path, or writing to the standard output if path is "-".
+func OpenSink(path string) (*Sink, error) {
+	if path == "-" {
+		return NewSink(os.Stdout), nil
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open sink: %s", path)
+	}
+	return &Sink{
+		w: f,
+		f: f,
+	}, nil
+}
+
+// Publish writes a batch of changes at once, so that lines of concurrent batches are not interleaved.
+func (s *Sink) Publish(ctx context.Context, changes []*ssr.Change) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, c := range changes {
+		if err := enc.Encode(c); err != nil {
+			return errors.Wrapf(err, "failed to encode change: %s", c.ID)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(buf.Bytes()); err != nil {
+		return errors.Wrap(err, "failed to write changes")
+	}
+	if s.f != nil {
+		if err := s.f.Sync(); err != nil {
+			return errors.Wrap(err, "failed to sync changes")
+		}
+	}
+	return nil
+}
+
+func (s *Sink) Close() error {
+	if s.f == nil {
+		return nil
+	}
+	return s.f.Close()
+}
@@ -0,0 +1,69 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+)
+
+func TestSink(t *testing.T) {
+	changes := []*ssr.Change{
+		{ID: uuid.New(), Sequence: 1, Type: ssr.ChangeScanCreated, Key: "a", RepositoryID: 1, Data: ssr.Payload(`{"status":0}`), CreatedAt: time.Now()},
+		{ID: uuid.New(), Sequence: 2, Type: ssr.ChangeScanDeleted, Key: "a", RepositoryID: 1, Data: ssr.Payload(`{"id":"a"}`), CreatedAt: time.Now()},
+	}
+
+	var buf bytes.Buffer
+	sink := NewSink(&buf)
+	require.NoError(t, sink.Publish(context.Background(), changes))
+	require.NoError(t, sink.Close())
+
+	scanner := bufio.NewScanner(&buf)
+	var lines []map[string]interface{}
+	for scanner.Scan() {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 2)
+	assert.Equal(t, changes[0].ID.String(), lines[0]["id"])
+	assert.Equal(t, "scan.deleted", lines[1]["type"])
+	assert.Equal(t, map[string]interface{}{"id": "a"}, lines[1]["data"])
+	assert.NotContains(t, lines[0], "PublishedAt")
+}
+
+func TestOpenSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sink")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "changes.jsonl")
+
+	for i := 0; i < 2; i++ {
+		sink, err := OpenSink(path)
+		require.NoError(t, err)
+		require.NoError(t, sink.Publish(context.Background(), []*ssr.Change{{ID: uuid.New(), Type: ssr.ChangeScanUpdated}}))
+		require.NoError(t, sink.Close())
+	}
+
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(b, []byte("\n")))
+
+	_, err = OpenSink(filepath.Join(dir, "missing", "changes.jsonl"))
+	assert.Error(t, err)
+
+	stdout, err := OpenSink("-")
+	require.NoError(t, err)
+	assert.NoError(t, stdout.Close())
+}
@@ -0,0 +1,139 @@
+package ssr
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// Statuses of a finding tracked in a repository.
+// Open and fixed follow the scans of the repository, the other ones are triage decisions.
+const (
+	// FindingOpen is a finding that the latest successful scan of its repository still reports.
+	FindingOpen = "open"
+	// FindingConfirmed is a finding that was confirmed to be a vulnerability.
+	FindingConfirmed = "confirmed"
+	// FindingFalsePositive is a finding that was dismissed as a false positive.
+	FindingFalsePositive = "false_positive"
+	// FindingAcceptedRisk is a finding that was acknowledged but will not be fixed.
+	FindingAcceptedRisk = "accepted_risk"
+	// FindingFixed is a finding that a later successful scan of its repository no longer reports.
+	FindingFixed = "fixed"
+)
+
+// ValidFindingStatus reports whether status is one of the statuses of a finding.
+func ValidFindingStatus(status string) bool {
+	switch status {
+	case FindingOpen, FindingConfirmed, FindingFalsePositive, FindingAcceptedRisk, FindingFixed:
+		return true
+	default:
+		return false
+	}
+}
+
+// ErrFindingNotFound is returned when a fingerprint is not tracked in a repository.
+var ErrFindingNotFound = NewError(KindNotFound, "finding not found")
+
+// Triage is the decision of a security engineer about a finding.
+type Triage struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+	Actor  string `json:"actor"`
+}
+
+// findingSorts lists the fields that findings can be sorted on.
+var findingSorts = map[string]bool{
+	"id":       true,
+	"severity": true,
+	"path":     true,
+	"rule_id":  true,
+}
+
+// ParseFindingSort splits sort into the field to sort findings on and whether the order is descending.
+// A field prefixed with "-" sorts in descending order, an empty sort orders findings by id.
+func ParseFindingSort(sort string) (field string, desc bool, err error) {
+	field = strings.TrimPrefix(sort, "-")
+	desc = field != sort
+	if field == "" {
+		field = "id"
+	}
+	if !findingSorts[field] {
+		return "", false, errors.Errorf("cannot sort findings on %q", field)
+	}
+	return field, desc, nil
+}
+
+// SortValue returns the value of the field that f is sorted on.
+func (f Finding) SortValue(field string) string {
+	switch field {
+	case "severity":
+		return string(f.Metadata.Severity)
+	case "path":
+		return f.Location.Path
+	case "rule_id":
+		return f.RuleID
+	default:
+		return ""
+	}
+}
+
+// FindingFilter selects a page of findings. Empty fields match every finding.
+type FindingFilter struct {
+	Type       FindingType
+	RuleID     string
+	Severity   Severity
+	PathPrefix string
+	Status     string
+	Suppressed *bool
+	// Sort is the field to sort on, see ParseFindingSort.
+	Sort string
+	// After is the cursor of the previous page.
+	After *FindingCursor
+	Limit int
+}
+
+// FindingCursor points at the last finding of a page, the next page starts right after it.
+type FindingCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	ID    uint64 `json:"id"`
+}
+
+// Encode renders the cursor as an opaque string.
+func (c FindingCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeFindingCursor reads a cursor rendered by FindingCursor.Encode.
+func DecodeFindingCursor(s string) (*FindingCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid cursor")
+	}
+	var c FindingCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, errors.Wrap(err, "invalid cursor")
+	}
+	return &c, nil
+}
+
+// FindingPage is a page of findings. NextCursor is empty on the last page.
+type FindingPage struct {
+	Findings   Findings `json:"findings"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+type FindingService interface {
+	// ListScanFindings returns a page of the findings of a scan.
+	ListScanFindings(ctx context.Context, scanID uuid.UUID, filter FindingFilter) (*FindingPage, error)
+	// ListRepositoryFindings returns a page of the findings tracked in a repository, each one as it was last seen.
+	ListRepositoryFindings(ctx context.Context, repositoryID uint64, filter FindingFilter) (*FindingPage, error)
+	// TriageFinding records a triage decision about a fingerprint of a repository.
+	// The decision applies to the findings of every scan of the repository with that fingerprint.
+	TriageFinding(ctx context.Context, repositoryID uint64, fingerprint string, triage Triage) (*RepositoryFinding, error)
+}
@@ -0,0 +1,40 @@
+package ssr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFindingSort(t *testing.T) {
+	for sort, want := range map[string]struct {
+		field string
+		desc  bool
+	}{
+		"":          {"id", false},
+		"-id":       {"id", true},
+		"severity":  {"severity", false},
+		"-severity": {"severity", true},
+		"path":      {"path", false},
+		"-rule_id":  {"rule_id", true},
+	} {
+		field, desc, err := ParseFindingSort(sort)
+		require.NoError(t, err, sort)
+		assert.Equal(t, want.field, field, sort)
+		assert.Equal(t, want.desc, desc, sort)
+	}
+
+	_, _, err := ParseFindingSort("-description")
+	assert.Error(t, err)
+}
+
+func TestFindingCursor(t *testing.T) {
+	cursor := FindingCursor{Sort: "path", Value: "connectors/tls.go", ID: 42}
+	decoded, err := DecodeFindingCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, cursor, *decoded)
+
+	_, err = DecodeFindingCursor("not a cursor")
+	assert.Error(t, err)
+}
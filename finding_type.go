@@ -0,0 +1,68 @@
+package ssr
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidFindingType is returned for a type that is neither a known finding type nor an alias of one.
+var ErrInvalidFindingType = NewError(KindInvalid, "invalid finding type")
+
+// FindingType is the kind of analysis that produced a finding.
+type FindingType string
+
+const (
+	// FindingSAST is a weakness found in the source code.
+	FindingSAST FindingType = "sast"
+	// FindingSCA is a vulnerable dependency.
+	FindingSCA FindingType = "sca"
+	// FindingSecret is a credential committed to the repository.
+	FindingSecret FindingType = "secret"
+	// FindingIaC is a misconfiguration of infrastructure as code.
+	FindingIaC FindingType = "iac"
+	// FindingLicense is a dependency whose license is not allowed.
+	FindingLicense FindingType = "license"
+)
+
+// findingTypeAliases maps the vocabularies of common tools to finding types, keys in lower case.
+var findingTypeAliases = map[string]FindingType{
+	"sast":             FindingSAST,
+	"code":             FindingSAST,
+	"sca":              FindingSCA,
+	"dependency":       FindingSCA,
+	"vulnerability":    FindingSCA,
+	"secret":           FindingSecret,
+	"secrets":          FindingSecret,
+	"iac":              FindingIaC,
+	"misconfiguration": FindingIaC,
+	"misconfig":        FindingIaC,
+	"license":          FindingLicense,
+	"licence":          FindingLicense,
+}
+
+// ParseFindingType maps t, or a known alias of it, to a finding type regardless of case.
+func ParseFindingType(t string) (FindingType, error) {
+	if findingType, ok := findingTypeAliases[strings.ToLower(strings.TrimSpace(t))]; ok {
+		return findingType, nil
+	}
+	return "", fmt.Errorf("%w %q", ErrInvalidFindingType, t)
+}
+
+// UnmarshalJSON accepts any case and the aliases of ParseFindingType. An empty type is left unset.
+func (t *FindingType) UnmarshalJSON(b []byte) error {
+	var v string
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if v == "" {
+		*t = ""
+		return nil
+	}
+	findingType, err := ParseFindingType(v)
+	if err != nil {
+		return err
+	}
+	*t = findingType
+	return nil
+}
@@ -0,0 +1,87 @@
+package ssr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Fingerprint sets a fingerprint on every finding that identifies it across the scans of a repository.
+//
+// The fingerprint hashes the tool, the rule, the path and the normalized snippet of a finding, or its
+// rule name and description when there is no snippet, but not its line number: a finding keeps its
+// fingerprint when the code above it moves. Findings that would otherwise share a fingerprint,
+// e.g. the same call repeated in a file, are told apart by their order of appearance in the file.
+func (fs Findings) Fingerprint() {
+	type key struct {
+		i    int
+		base string
+	}
+	keys := make([]key, len(fs))
+	for i := range fs {
+		keys[i] = key{i: i, base: fs[i].fingerprintBase()}
+	}
+	sort.SliceStable(keys, func(a, b int) bool {
+		return fs[keys[a].i].Location.Positions.Begin.Line < fs[keys[b].i].Location.Positions.Begin.Line
+	})
+
+	occurrences := make(map[string]int)
+	for _, k := range keys {
+		occurrences[k.base]++
+		sum := sha256.Sum256([]byte(k.base + "\x00" + strconv.Itoa(occurrences[k.base])))
+		fs[k.i].Fingerprint = hex.EncodeToString(sum[:])
+	}
+}
+
+func (f *Finding) fingerprintBase() string {
+	context := NormalizeSnippet(f.Location.Snippet)
+	if context == "" {
+		context = f.Metadata.Name + "\x00" + f.Metadata.Description
+	}
+	return strings.Join([]string{f.Tool, f.RuleID, f.Location.Path, context}, "\x00")
+}
+
+// snippetLineNumber matches the line numbers that tools such as gosec prefix snippets with.
+var snippetLineNumber = regexp.MustCompile(`^\d+:\s?`)
+
+// NormalizeSnippet drops line number prefixes, indentation and repeated spaces from a snippet,
+// so that reformatting the code around a finding does not change its fingerprint.
+func NormalizeSnippet(snippet string) string {
+	var lines []string
+	for _, line := range strings.Split(snippet, "\n") {
+		line = snippetLineNumber.ReplaceAllString(strings.TrimSpace(line), "")
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RepositoryFinding tracks a fingerprint across the scans of a repository.
+type RepositoryFinding struct {
+	RepositoryID uint64    `json:"repository_id" gorm:"primaryKey;autoIncrement:false"`
+	Fingerprint  string    `json:"fingerprint" gorm:"primaryKey"`
+	RuleID       string    `json:"rule_id"`
+	Path         string    `json:"path"`
+	FirstSeenAt  time.Time `json:"first_seen_at"`
+	LastSeenAt   time.Time `json:"last_seen_at"`
+	FirstScanID  uuid.UUID `json:"first_scan_id" gorm:"type:uuid"`
+	LastScanID   uuid.UUID `json:"last_scan_id" gorm:"type:uuid"`
+	// Status is FindingOpen until a successful scan no longer reports the fingerprint, or until it is triaged.
+	Status string `json:"status" gorm:"not null;default:open;index"`
+	// TriageReason, TriagedBy and TriagedAt record the last triage decision about the fingerprint.
+	TriageReason string    `json:"triage_reason,omitempty"`
+	TriagedBy    string    `json:"triaged_by,omitempty"`
+	TriagedAt    time.Time `json:"triaged_at"`
+}
+
+func (RepositoryFinding) TableName() string {
+	return "repository_finding"
+}
@@ -0,0 +1,61 @@
+package ssr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindingsFingerprint(t *testing.T) {
+	finding := func(line int64, snippet string) Finding {
+		return Finding{
+			Type:   "sast",
+			RuleID: "G404",
+			Tool:   "gosec",
+			Location: Location{
+				Path:      "util/util.go",
+				Positions: Positions{Begin: Begin{Line: line}},
+				Snippet:   snippet,
+			},
+			Metadata: Metadata{
+				Description: "Use of weak random number generator (math/rand instead of crypto/rand)",
+			},
+		}
+	}
+
+	yesterday := Findings{finding(32, "32: return rand.Intn(n)")}
+	yesterday.Fingerprint()
+	assert.Len(t, yesterday[0].Fingerprint, 64)
+
+	t.Run("line shift", func(t *testing.T) {
+		today := Findings{finding(40, "40: \treturn  rand.Intn(n)")}
+		today.Fingerprint()
+		assert.Equal(t, yesterday[0].Fingerprint, today[0].Fingerprint)
+	})
+
+	t.Run("different code", func(t *testing.T) {
+		today := Findings{finding(32, "32: return rand.Int63()")}
+		today.Fingerprint()
+		assert.NotEqual(t, yesterday[0].Fingerprint, today[0].Fingerprint)
+	})
+
+	t.Run("repeated code", func(t *testing.T) {
+		today := Findings{finding(80, "return rand.Intn(n)"), finding(40, "return rand.Intn(n)")}
+		today.Fingerprint()
+		assert.NotEqual(t, today[0].Fingerprint, today[1].Fingerprint)
+		assert.Equal(t, yesterday[0].Fingerprint, today[1].Fingerprint, "the first occurrence in the file keeps its fingerprint")
+	})
+
+	t.Run("without snippet", func(t *testing.T) {
+		a := Findings{finding(32, "")}
+		b := Findings{finding(33, "")}
+		a.Fingerprint()
+		b.Fingerprint()
+		assert.Equal(t, a[0].Fingerprint, b[0].Fingerprint)
+		assert.NotEqual(t, yesterday[0].Fingerprint, a[0].Fingerprint)
+	})
+}
+
+func TestNormalizeSnippet(t *testing.T) {
+	assert.Equal(t, "TLSClientConfig: &tls.Config{\nInsecureSkipVerify: true,\n},", NormalizeSnippet("59: \tTLSClientConfig: &tls.Config{\n60: \t\tInsecureSkipVerify:   true,\n\n61: \t},\n"))
+}
@@ -0,0 +1,55 @@
+package ssr
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// gitleaksLeak is an entry of the output of `gitleaks detect --report-format json`.
+// The secret itself is deliberately not imported.
+type gitleaksLeak struct {
+	Description string `json:"Description"`
+	RuleID      string `json:"RuleID"`
+	File        string `json:"File"`
+	StartLine   int64  `json:"StartLine"`
+	EndLine     int64  `json:"EndLine"`
+	StartColumn int64  `json:"StartColumn"`
+	EndColumn   int64  `json:"EndColumn"`
+	Commit      string `json:"Commit"`
+}
+
+func init() {
+	RegisterImporter("gitleaks", ImporterFunc(importGitleaks))
+}
+
+func importGitleaks(r io.Reader) (Findings, error) {
+	var leaks []gitleaksLeak
+	if err := json.NewDecoder(r).Decode(&leaks); err != nil {
+		return nil, errors.Wrap(err, "failed to decode gitleaks report")
+	}
+
+	findings := Findings{}
+	for _, leak := range leaks {
+		findings = append(findings, Finding{
+			Type:   FindingSecret,
+			RuleID: leak.RuleID,
+			Tool:   "gitleaks",
+			Location: Location{
+				Path: leak.File,
+				Positions: Positions{
+					Begin: Begin{Line: leak.StartLine, Column: leak.StartColumn},
+					End:   End{Line: leak.EndLine, Column: leak.EndColumn},
+				},
+			},
+			Metadata: Metadata{
+				Description: leak.Description,
+				// a leaked secret is always worth rotating
+				Severity: SeverityHigh,
+				Commit:   leak.Commit,
+			},
+		})
+	}
+	return findings, nil
+}
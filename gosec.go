@@ -0,0 +1,101 @@
+package ssr
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// gosecReport is the output of `gosec -fmt=json`.
+type gosecReport struct {
+	Issues []struct {
+		Severity string `json:"severity"`
+		CWE      struct {
+			ID  string `json:"id"`
+			URL string `json:"url"`
+		} `json:"cwe"`
+		RuleID  string `json:"rule_id"`
+		Details string `json:"details"`
+		File    string `json:"file"`
+		Code    string `json:"code"`
+		Line    string `json:"line"`
+		Column  string `json:"column"`
+	} `json:"Issues"`
+}
+
+func init() {
+	RegisterImporter("gosec", ImporterFunc(importGosec))
+}
+
+func importGosec(r io.Reader) (Findings, error) {
+	var report gosecReport
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, errors.Wrap(err, "failed to decode gosec report")
+	}
+
+	findings := Findings{}
+	for _, issue := range report.Issues {
+		// line is either a single line or a range such as "60-62"
+		begin, end := issue.Line, ""
+		if i := strings.Index(issue.Line, "-"); i > 0 {
+			begin, end = issue.Line[:i], issue.Line[i+1:]
+		}
+		column, _ := strconv.ParseInt(issue.Column, 10, 64)
+		beginLine, endLine := parseLine(begin), parseLine(end)
+
+		f := Finding{
+			Type:   FindingSAST,
+			RuleID: issue.RuleID,
+			Tool:   "gosec",
+			Location: Location{
+				Path: issue.File,
+				Positions: Positions{
+					Begin: Begin{Line: beginLine, Column: column},
+					End:   End{Line: endLine},
+				},
+				Snippet: gosecSnippet(issue.Code, beginLine, endLine),
+			},
+			Metadata: Metadata{
+				Description: issue.Details,
+				Severity:    toSeverity(issue.Severity),
+				HelpURI:     issue.CWE.URL,
+			},
+		}
+		if issue.CWE.ID != "" {
+			f.Metadata.Name = "CWE-" + issue.CWE.ID
+		}
+		findings = append(findings, f)
+	}
+	return findings, nil
+}
+
+func parseLine(s string) int64 {
+	line, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return line
+}
+
+// gosecSnippet keeps the flagged lines of the code that gosec reports along with a few lines of context.
+// Each line of the code is prefixed with its number, e.g. "60: InsecureSkipVerify: true,".
+func gosecSnippet(code string, begin, end int64) string {
+	if end < begin {
+		end = begin
+	}
+
+	var lines []string
+	for _, line := range strings.Split(code, "\n") {
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		if n := parseLine(line[:i]); n >= begin && n <= end {
+			lines = append(lines, strings.TrimPrefix(line[i+1:], " "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/quantonganh/ssr"
+)
+
+// SaveUserHandler creates or updates the user of the identity provider named in the path, e.g. to give them a global role.
+func (s *Server) SaveUserHandler(w http.ResponseWriter, r *http.Request) error {
+	var u ssr.User
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		return jsonError(err)
+	}
+	u.ID = mux.Vars(r)["userID"]
+
+	if err := s.AccessService.SaveUser(r.Context(), &u); err != nil {
+		return err
+	}
+
+	return writeJSON(w, u)
+}
+
+func (s *Server) ListUsersHandler(w http.ResponseWriter, r *http.Request) error {
+	users, err := s.AccessService.ListUsers(r.Context())
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, users)
+}
+
+// CreateTeamHandler creates a team. Members of the group of the same name at the identity provider belong to it.
+func (s *Server) CreateTeamHandler(w http.ResponseWriter, r *http.Request) error {
+	var t ssr.Team
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		return jsonError(err)
+	}
+	if t.Name == "" {
+		return NewError(nil, http.StatusBadRequest, "Bad request: name is required")
+	}
+	t.ID = 0
+
+	if err := s.AccessService.CreateTeam(r.Context(), &t); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	return writeJSON(w, t)
+}
+
+func (s *Server) ListTeamsHandler(w http.ResponseWriter, r *http.Request) error {
+	teams, err := s.AccessService.ListTeams(r.Context())
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, teams)
+}
+
+func (s *Server) DeleteTeamHandler(w http.ResponseWriter, r *http.Request) error {
+	teamID, err := parseTeamID(r)
+	if err != nil {
+		return err
+	}
+
+	return s.AccessService.DeleteTeam(r.Context(), teamID)
+}
+
+func (s *Server) AddTeamMemberHandler(w http.ResponseWriter, r *http.Request) error {
+	teamID, err := parseTeamID(r)
+	if err != nil {
+		return err
+	}
+
+	return s.AccessService.AddTeamMember(r.Context(), &ssr.TeamMember{TeamID: teamID, UserID: mux.Vars(r)["userID"]})
+}
+
+func (s *Server) RemoveTeamMemberHandler(w http.ResponseWriter, r *http.Request) error {
+	teamID, err := parseTeamID(r)
+	if err != nil {
+		return err
+	}
+
+	return s.AccessService.RemoveTeamMember(r.Context(), &ssr.TeamMember{TeamID: teamID, UserID: mux.Vars(r)["userID"]})
+}
+
+// SetRepositoryMemberHandler gives a team a role on a repository.
+func (s *Server) SetRepositoryMemberHandler(w http.ResponseWriter, r *http.Request) error {
+	repoID, err := parseRepoID(r)
+	if err != nil {
+		return err
+	}
+	teamID, err := parseTeamID(r)
+	if err != nil {
+		return err
+	}
+
+	var req struct {
+		Role ssr.Role `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return jsonError(err)
+	}
+	if req.Role == "" {
+		return NewError(nil, http.StatusBadRequest, "Bad request: role is required")
+	}
+
+	m := &ssr.RepositoryMember{RepositoryID: repoID, TeamID: teamID, Role: req.Role}
+	if err := s.AccessService.SetRepositoryMember(r.Context(), m); err != nil {
+		return err
+	}
+
+	return writeJSON(w, m)
+}
+
+func (s *Server) ListRepositoryMembersHandler(w http.ResponseWriter, r *http.Request) error {
+	repoID, err := parseRepoID(r)
+	if err != nil {
+		return err
+	}
+
+	members, err := s.AccessService.ListRepositoryMembers(r.Context(), repoID)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, members)
+}
+
+func (s *Server) RemoveRepositoryMemberHandler(w http.ResponseWriter, r *http.Request) error {
+	repoID, err := parseRepoID(r)
+	if err != nil {
+		return err
+	}
+	teamID, err := parseTeamID(r)
+	if err != nil {
+		return err
+	}
+
+	return s.AccessService.RemoveRepositoryMember(r.Context(), repoID, teamID)
+}
+
+func parseTeamID(r *http.Request) (uint64, error) {
+	teamID, err := strconv.ParseUint(mux.Vars(r)["teamID"], 10, 64)
+	if err != nil {
+		return 0, NewError(err, http.StatusBadRequest, "Bad request: invalid team ID")
+	}
+	return teamID, nil
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+	"github.com/quantonganh/ssr/mocks"
+)
+
+func TestAccessHandler(t *testing.T) {
+	accessService := new(mocks.AccessService)
+	accessService.On("SaveUser", mock.Anything, &ssr.User{ID: "alice", Role: ssr.RoleReader}).Return(nil)
+	accessService.On("ListUsers", mock.Anything).Return([]*ssr.User{{ID: "alice"}}, nil)
+	accessService.On("CreateTeam", mock.Anything, &ssr.Team{Name: "payments"}).Return(nil)
+	accessService.On("ListTeams", mock.Anything).Return([]*ssr.Team{{ID: 7, Name: "payments"}}, nil)
+	accessService.On("DeleteTeam", mock.Anything, uint64(8)).Return(ssr.ErrTeamNotFound)
+	accessService.On("AddTeamMember", mock.Anything, &ssr.TeamMember{TeamID: 7, UserID: "alice"}).Return(nil)
+	accessService.On("RemoveTeamMember", mock.Anything, &ssr.TeamMember{TeamID: 7, UserID: "alice"}).Return(nil)
+	accessService.On("SetRepositoryMember", mock.Anything, &ssr.RepositoryMember{RepositoryID: 1, TeamID: 7, Role: ssr.RoleMaintainer}).Return(nil)
+	accessService.On("SetRepositoryMember", mock.Anything, &ssr.RepositoryMember{RepositoryID: 2, TeamID: 7, Role: ssr.RoleMaintainer}).Return(ssr.ErrForbidden)
+	accessService.On("ListRepositoryMembers", mock.Anything, uint64(1)).Return([]*ssr.RepositoryMember{{RepositoryID: 1, TeamID: 7, Role: ssr.RoleMaintainer}}, nil)
+	accessService.On("RemoveRepositoryMember", mock.Anything, uint64(1), uint64(8)).Return(ssr.ErrRepositoryMemberNotFound)
+
+	s := NewServer(nil, nil)
+	s.AccessService = accessService
+
+	for name, tc := range map[string]struct {
+		method string
+		target string
+		body   string
+		status int
+	}{
+		"save user":                           {http.MethodPut, "/users/alice", `{"role": "reader"}`, http.StatusOK},
+		"save user with invalid role":         {http.MethodPut, "/users/alice", `{"role": "owner"}`, http.StatusBadRequest},
+		"list users":                          {http.MethodGet, "/users", "", http.StatusOK},
+		"create team":                         {http.MethodPost, "/teams", `{"name": "payments"}`, http.StatusCreated},
+		"create team without name":            {http.MethodPost, "/teams", `{"role": "admin"}`, http.StatusBadRequest},
+		"list teams":                          {http.MethodGet, "/teams", "", http.StatusOK},
+		"delete unknown team":                 {http.MethodDelete, "/teams/8", "", http.StatusNotFound},
+		"delete team with invalid ID":         {http.MethodDelete, "/teams/payments", "", http.StatusBadRequest},
+		"add team member":                     {http.MethodPut, "/teams/7/members/alice", "", http.StatusOK},
+		"remove team member":                  {http.MethodDelete, "/teams/7/members/alice", "", http.StatusOK},
+		"set repository member":               {http.MethodPut, "/repositories/1/members/7", `{"role": "maintainer"}`, http.StatusOK},
+		"set repository member without role":  {http.MethodPut, "/repositories/1/members/7", `{}`, http.StatusBadRequest},
+		"set repository member without right": {http.MethodPut, "/repositories/2/members/7", `{"role": "maintainer"}`, http.StatusForbidden},
+		"list repository members":             {http.MethodGet, "/repositories/1/members", "", http.StatusOK},
+		"remove unknown repository member":    {http.MethodDelete, "/repositories/1/members/8", "", http.StatusNotFound},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := serve(s, httptest.NewRequest(tc.method, tc.target, bytes.NewBufferString(tc.body)))
+			assert.Equal(t, tc.status, rr.Code)
+		})
+	}
+}
+
+func TestListScansHandlerWithUser(t *testing.T) {
+	apiKeyService := new(mocks.APIKeyService)
+	apiKeyService.On("Authenticate", mock.Anything, "ssr_reader").Return(&ssr.APIKey{ID: 2, Name: "reader", Scopes: ssr.ScopeList{ssr.ScopeScansRead}}, nil)
+
+	scanService := new(mocks.ScanService)
+	// The service restricts the scans to those of the user that the request is made by.
+	scanService.On("ListScans", mock.MatchedBy(func(ctx context.Context) bool {
+		u := ssr.UserFromContext(ctx)
+		return u != nil && u.ID == "apikey:2"
+	}), 1, 10).Return([]*ssr.Scan{}, nil)
+
+	s := NewServer(nil, scanService)
+	s.APIKeyService = apiKeyService
+
+	req := httptest.NewRequest(http.MethodGet, "/scans?page=1&limit=10", nil)
+	req.Header.Set("Authorization", "Bearer ssr_reader")
+	rr := serve(s, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var scans []*ssr.Scan
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&scans))
+	scanService.AssertExpectations(t)
+}
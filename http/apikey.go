@@ -0,0 +1,76 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/quantonganh/ssr"
+)
+
+// issuedAPIKey is the response to the creation of an API key, the only one that holds the key itself.
+type issuedAPIKey struct {
+	*ssr.APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKeyHandler issues an API key with the requested scopes and role.
+func (s *Server) CreateAPIKeyHandler(w http.ResponseWriter, r *http.Request) error {
+	var req struct {
+		Name      string      `json:"name"`
+		Scopes    []ssr.Scope `json:"scopes"`
+		ExpiresAt *time.Time  `json:"expires_at"`
+		// Role defaults to reader, RepositoryID restricts the key to a repository.
+		Role         ssr.Role `json:"role"`
+		RepositoryID *uint64  `json:"repository_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return jsonError(err)
+	}
+	if req.Name == "" {
+		return NewError(nil, http.StatusBadRequest, "Bad request: name is required")
+	}
+	if len(req.Scopes) == 0 {
+		return NewError(nil, http.StatusBadRequest, "Bad request: scopes are required")
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return NewError(nil, http.StatusBadRequest, "Bad request: expires_at must be in the future")
+	}
+
+	k, key, err := ssr.NewAPIKey(req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	if req.Role != "" {
+		k.Role = req.Role
+	}
+	k.RepositoryID = req.RepositoryID
+	if err := s.APIKeyService.Create(r.Context(), k); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	return writeJSON(w, issuedAPIKey{APIKey: k, Key: key})
+}
+
+func (s *Server) ListAPIKeysHandler(w http.ResponseWriter, r *http.Request) error {
+	keys, err := s.APIKeyService.List(r.Context())
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, keys)
+}
+
+// RevokeAPIKeyHandler disables an API key, the requests that use it are rejected from then on.
+func (s *Server) RevokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseUint(mux.Vars(r)["keyID"], 10, 64)
+	if err != nil {
+		return NewError(err, http.StatusBadRequest, "Bad request: invalid API key ID")
+	}
+
+	return s.APIKeyService.Revoke(r.Context(), id)
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+	"github.com/quantonganh/ssr/mocks"
+)
+
+func TestAuthorize(t *testing.T) {
+	apiKeyService := new(mocks.APIKeyService)
+	apiKeyService.On("Authenticate", mock.Anything, "ssr_reader").Return(&ssr.APIKey{ID: 1, Name: "reader", Scopes: ssr.ScopeList{ssr.ScopeRepositoriesRead}}, nil)
+	apiKeyService.On("Authenticate", mock.Anything, "ssr_revoked").Return(nil, ssr.ErrUnauthorized)
+
+	repositoryService := new(mocks.RepositoryService)
+	repositoryService.On("List", mock.Anything, mock.Anything).Return([]*ssr.Repository{}, nil)
+
+	s := NewServer(repositoryService, nil)
+	s.APIKeyService = apiKeyService
+
+	request := func(method, target, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		return serve(s, req)
+	}
+
+	t.Run("missing key", func(t *testing.T) {
+		rr := request(http.MethodGet, "/repositories", "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Equal(t, "Bearer", rr.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("revoked key", func(t *testing.T) {
+		rr := request(http.MethodGet, "/repositories", "ssr_revoked")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Equal(t, `Bearer error="invalid_token"`, rr.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("missing scope", func(t *testing.T) {
+		rr := request(http.MethodDelete, "/repositories/1", "ssr_reader")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		var problem Problem
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+		assert.Equal(t, "urn:ssr:problem:forbidden", problem.Type)
+	})
+
+	t.Run("granted", func(t *testing.T) {
+		rr := request(http.MethodGet, "/repositories", "ssr_reader")
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
+
+func TestAPIKeyHandler(t *testing.T) {
+	admin := &ssr.APIKey{ID: 1, Name: "admin", Scopes: ssr.ScopeList{ssr.ScopeKeysAdmin}}
+	apiKeyService := new(mocks.APIKeyService)
+	apiKeyService.On("Authenticate", mock.Anything, "ssr_admin").Return(admin, nil)
+	apiKeyService.On("Create", mock.Anything, mock.MatchedBy(func(k *ssr.APIKey) bool {
+		return k.Name == "ci" && k.HasScope(ssr.ScopeScansWrite) && k.Hash != "" && k.Role == ssr.RoleReader && k.RepositoryID == nil
+	})).Return(nil)
+	apiKeyService.On("Create", mock.Anything, mock.MatchedBy(func(k *ssr.APIKey) bool {
+		return k.Name == "deploy" && k.Role == ssr.RoleMaintainer && k.RepositoryID != nil && *k.RepositoryID == 3
+	})).Return(nil)
+	apiKeyService.On("List", mock.Anything).Return([]*ssr.APIKey{admin}, nil)
+	apiKeyService.On("Revoke", mock.Anything, uint64(1)).Return(nil)
+	apiKeyService.On("Revoke", mock.Anything, uint64(2)).Return(ssr.ErrAPIKeyNotFound)
+
+	s := NewServer(nil, nil)
+	s.APIKeyService = apiKeyService
+
+	request := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer ssr_admin")
+		return serve(s, req)
+	}
+
+	t.Run("create", func(t *testing.T) {
+		rr := request(http.MethodPost, "/apikeys", `{"name": "ci", "scopes": ["scans:write"]}`)
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		var issued struct {
+			Key    string `json:"key"`
+			Prefix string `json:"prefix"`
+			Hash   string `json:"hash"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&issued))
+		assert.True(t, strings.HasPrefix(issued.Key, issued.Prefix))
+		assert.Empty(t, issued.Hash)
+	})
+
+	t.Run("create with role", func(t *testing.T) {
+		rr := request(http.MethodPost, "/apikeys", `{"name": "deploy", "scopes": ["scans:write"], "role": "maintainer", "repository_id": 3}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+	})
+
+	t.Run("create with invalid role", func(t *testing.T) {
+		rr := request(http.MethodPost, "/apikeys", `{"name": "ci", "scopes": ["scans:write"], "role": "owner"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("create with invalid scope", func(t *testing.T) {
+		rr := request(http.MethodPost, "/apikeys", `{"name": "ci", "scopes": ["scans:delete"]}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("create without scopes", func(t *testing.T) {
+		rr := request(http.MethodPost, "/apikeys", `{"name": "ci"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		rr := request(http.MethodGet, "/apikeys", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "hash")
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request(http.MethodDelete, "/apikeys/1", "").Code)
+		assert.Equal(t, http.StatusNotFound, request(http.MethodDelete, "/apikeys/2", "").Code)
+	})
+}
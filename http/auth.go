@@ -0,0 +1,81 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/quantonganh/ssr"
+)
+
+// authorize wraps h so that it is only served to users with scope. Users are authenticated by the bearer token
+// of the request, either an API key or a token of the identity provider.
+// Authentication is disabled when the server has neither APIKeyService nor JWTAuthenticator.
+func (s *Server) authorize(scope ssr.Scope, h appHandler) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if s.APIKeyService == nil && s.JWTAuthenticator == nil {
+			return h(w, r)
+		}
+
+		token := bearerToken(r)
+		if token == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			return ssr.ErrUnauthorized
+		}
+		user, err := s.authenticate(r.Context(), token)
+		if err != nil {
+			if ssr.KindOf(err) == ssr.KindUnauthorized {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			}
+			return err
+		}
+		if !user.HasScope(scope) {
+			return ssr.ErrForbidden
+		}
+
+		return h(w, r.WithContext(ssr.NewContextWithUser(r.Context(), user)))
+	}
+}
+
+// authenticate returns the user of an API key or of a token of the identity provider.
+func (s *Server) authenticate(ctx context.Context, token string) (*ssr.User, error) {
+	if ssr.IsAPIKey(token) && s.APIKeyService != nil {
+		k, err := s.APIKeyService.Authenticate(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		return k.User(), nil
+	}
+	if s.JWTAuthenticator != nil {
+		return s.JWTAuthenticator.Authenticate(ctx, token)
+	}
+	return nil, ssr.ErrUnauthorized
+}
+
+// bearerToken returns the token of the Authorization header, or an empty string if there is none.
+func bearerToken(r *http.Request) string {
+	const prefix = "Bearer "
+	h := r.Header.Get("Authorization")
+	if len(h) < len(prefix) || !strings.EqualFold(h[:len(prefix)], prefix) {
+		return ""
+	}
+	return strings.TrimSpace(h[len(prefix):])
+}
+
+// actor returns who a request that records its author acts as, given the author named by field in its body.
+// An authenticated request acts as its user, and is forbidden to name anyone else.
+// When authentication is disabled the body names the author.
+func actor(ctx context.Context, field, named string) (string, error) {
+	u := ssr.UserFromContext(ctx)
+	if u == nil {
+		if named == "" {
+			return "", NewError(nil, http.StatusBadRequest, fmt.Sprintf("Bad request: %s is required", field))
+		}
+		return named, nil
+	}
+	if named != "" && named != u.Name() {
+		return "", NewError(nil, http.StatusForbidden, fmt.Sprintf("Forbidden: %s must be the authenticated user", field))
+	}
+	return u.Name(), nil
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/quantonganh/ssr"
+)
+
+// GetScanDiffHandler classifies the findings of a scan as new, fixed or persisting relative to a base scan.
+// The base query parameter is either the ID of another scan of the same repository, or "default"
+// for the latest successful scan of the default branch of the repository. Both scans must have succeeded, the findings
+// of other scans being partial.
+func (s *Server) GetScanDiffHandler(w http.ResponseWriter, r *http.Request) error {
+	scanID, err := uuid.Parse(mux.Vars(r)["scanID"])
+	if err != nil {
+		return NewError(err, http.StatusBadRequest, "Bad request: invalid scan ID")
+	}
+
+	scan, err := s.ScanService.GetScan(r.Context(), scanID)
+	if err != nil {
+		return err
+	}
+	if scan.Status != ssr.Success {
+		return NewError(nil, http.StatusConflict, "Conflict: scan has not succeeded")
+	}
+
+	var base *ssr.Scan
+	switch v := r.FormValue("base"); v {
+	case "":
+		return NewError(nil, http.StatusBadRequest, "Bad request: base parameter is required")
+	case "default":
+		base, err = s.ScanService.GetLatestDefaultBranchScan(r.Context(), scan.RepositoryID)
+		if errors.Is(err, ssr.ErrScanNotFound) {
+			return NewError(err, http.StatusNotFound, "No successful scan of the default branch")
+		}
+		if err != nil {
+			return err
+		}
+	default:
+		baseID, err := uuid.Parse(v)
+		if err != nil {
+			return NewError(err, http.StatusBadRequest, "Bad request: invalid base parameter")
+		}
+		if base, err = s.ScanService.GetScan(r.Context(), baseID); err != nil {
+			return err
+		}
+		if base.RepositoryID != scan.RepositoryID {
+			return NewError(nil, http.StatusBadRequest, "Bad request: base scan belongs to another repository")
+		}
+		if base.Status != ssr.Success {
+			return NewError(nil, http.StatusConflict, "Conflict: base scan has not succeeded")
+		}
+	}
+
+	return writeJSON(w, ssr.Diff(base, scan))
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+	"github.com/quantonganh/ssr/mocks"
+)
+
+func TestGetScanDiffHandler(t *testing.T) {
+	finding := func(fingerprint string) ssr.Finding {
+		return ssr.Finding{RuleID: "G404", Fingerprint: fingerprint}
+	}
+	base := &ssr.Scan{ID: uuid.New(), Status: ssr.Success, RepositoryID: 1, Findings: ssr.Findings{finding("a"), finding("b")}}
+	scan := &ssr.Scan{ID: uuid.New(), Status: ssr.Success, RepositoryID: 1, Branch: "feature", Findings: ssr.Findings{finding("b"), finding("c")}}
+	other := &ssr.Scan{ID: uuid.New(), Status: ssr.Success, RepositoryID: 2}
+	orphan := &ssr.Scan{ID: uuid.New(), Status: ssr.Success, RepositoryID: 3}
+	running := &ssr.Scan{ID: uuid.New(), Status: ssr.InProgress, RepositoryID: 1}
+
+	scanService := new(mocks.ScanService)
+	scanService.On("GetScan", mock.Anything, base.ID).Return(base, nil)
+	scanService.On("GetScan", mock.Anything, scan.ID).Return(scan, nil)
+	scanService.On("GetScan", mock.Anything, other.ID).Return(other, nil)
+	scanService.On("GetScan", mock.Anything, orphan.ID).Return(orphan, nil)
+	scanService.On("GetScan", mock.Anything, running.ID).Return(running, nil)
+	scanService.On("GetLatestDefaultBranchScan", mock.Anything, uint64(1)).Return(base, nil)
+	scanService.On("GetLatestDefaultBranchScan", mock.Anything, uint64(3)).Return(nil, ssr.ErrScanNotFound)
+
+	s := NewServer(nil, scanService)
+
+	for name, base := range map[string]string{
+		"diff against a scan":             base.ID.String(),
+		"diff against the default branch": "default",
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := serve(s, httptest.NewRequest(http.MethodGet, "/scans/"+scan.ID.String()+"/diff?base="+base, nil))
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var diff ssr.ScanDiff
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&diff))
+			assert.Equal(t, ssr.Findings{finding("c")}, diff.New)
+			assert.Equal(t, ssr.Findings{finding("a")}, diff.Fixed)
+			assert.Equal(t, ssr.Findings{finding("b")}, diff.Persisting)
+		})
+	}
+
+	t.Run("diff without a scan of the default branch", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/scans/"+orphan.ID.String()+"/diff?base=default", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	for name, url := range map[string]string{
+		"diff of an unfinished scan":      "/scans/" + running.ID.String() + "/diff?base=default",
+		"diff against an unfinished scan": "/scans/" + scan.ID.String() + "/diff?base=" + running.ID.String(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := serve(s, httptest.NewRequest(http.MethodGet, url, nil))
+			assert.Equal(t, http.StatusConflict, rr.Code)
+		})
+	}
+
+	for name, query := range map[string]string{
+		"diff without base":                         "",
+		"diff against an invalid base":              "base=latest",
+		"diff against a scan of another repository": "base=" + other.ID.String(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := serve(s, httptest.NewRequest(http.MethodGet, "/scans/"+scan.ID.String()+"/diff?"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+}
@@ -1,12 +1,20 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/hlog"
+
+	"github.com/quantonganh/ssr"
 )
 
+// ProblemContentType is the media type of error responses.
+const ProblemContentType = "application/problem+json"
+
 type appHandler func(w http.ResponseWriter, r *http.Request) error
 
 func (fn appHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -17,41 +25,98 @@ func (fn appHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("An error has occurred: %+v", err)
 
-	clientError, ok := err.(ClientError)
-	if !ok {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+	problem := NewProblem(err)
+	if id, ok := hlog.IDFromRequest(r); ok {
+		problem.RequestID = id.String()
 	}
 
-	body, err := clientError.Body()
+	body, err := json.Marshal(problem)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	status, headers := clientError.Headers()
-	for k, v := range headers {
-		w.Header().Set(k, v)
-	}
-	w.WriteHeader(status)
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	_, _ = w.Write(body)
+}
 
-	_, err = w.Write(body)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+// Problem describes an error in the format of RFC 7807.
+type Problem struct {
+	// Type identifies the kind of error, see problemType.
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// RequestID is the ID that the request is logged with.
+	RequestID string `json:"request_id,omitempty"`
+	// Errors lists the invalid fields of a request that failed validation.
+	Errors []ssr.FieldError `json:"errors,omitempty"`
+}
+
+// kindStatuses maps the kinds of domain errors to status codes.
+var kindStatuses = map[ssr.ErrorKind]int{
+	ssr.KindNotFound:     http.StatusNotFound,
+	ssr.KindConflict:     http.StatusConflict,
+	ssr.KindInvalid:      http.StatusBadRequest,
+	ssr.KindUnauthorized: http.StatusUnauthorized,
+	ssr.KindForbidden:    http.StatusForbidden,
+}
+
+// NewProblem describes err. An *Error is rendered as is, a domain error according to its kind,
+// and any other error as an internal error whose details are only logged.
+// A request that cannot be decoded, e.g. for an unknown severity or finding type, is an *Error with a 400,
+// while a *ssr.ValidationError lists the fields of a decoded request that are invalid with a 422.
+func NewProblem(err error) *Problem {
+	var p Problem
+	var httpErr *Error
+	var validationErr *ssr.ValidationError
+	switch {
+	case errors.As(err, &httpErr):
+		p.Status = httpErr.Status
+		p.Detail = httpErr.Message
+	case errors.As(err, &validationErr):
+		p.Status = http.StatusUnprocessableEntity
+		p.Detail = "invalid fields"
+		p.Errors = validationErr.Fields
+	case errors.Is(err, context.DeadlineExceeded):
+		p.Status = http.StatusServiceUnavailable
+		p.Detail = "request timed out"
+	default:
+		status, ok := kindStatuses[ssr.KindOf(err)]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		p.Status = status
+		p.Detail = ssr.ErrorMessage(err)
 	}
+
+	p.Type = problemType(p.Status)
+	p.Title = http.StatusText(p.Status)
+	return &p
 }
 
-type ClientError interface {
-	Error() string
-	Body() ([]byte, error)
-	Headers() (int, map[string]string)
+// problemType identifies a problem by the kind of domain error that matches its status.
+func problemType(status int) string {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return "urn:ssr:problem:" + string(ssr.KindInvalid)
+	case http.StatusInternalServerError, http.StatusServiceUnavailable:
+		return "urn:ssr:problem:" + string(ssr.KindInternal)
+	}
+	for kind, s := range kindStatuses {
+		if s == status {
+			return "urn:ssr:problem:" + string(kind)
+		}
+	}
+	return "about:blank"
 }
 
+// Error is an error with the status and message that the client gets.
 type Error struct {
-	Cause error `json:"-"`
-	Message string `json:"message"`
-	Status int `json:"-"`
+	Cause   error
+	Message string
+	Status  int
 }
 
 func (e *Error) Error() string {
@@ -61,18 +126,8 @@ func (e *Error) Error() string {
 	return e.Message + ": " + e.Cause.Error()
 }
 
-func (e *Error) Body() ([]byte, error) {
-	body, err := json.Marshal(e)
-	if err != nil {
-		return nil, fmt.Errorf("error while parsing response body: %v", err)
-	}
-	return body, nil
-}
-
-func (e *Error) Headers() (int, map[string]string) {
-	return e.Status, map[string]string{
-		"Content-Type": "application/json; charset=utf-8",
-	}
+func (e *Error) Unwrap() error {
+	return e.Cause
 }
 
 func NewError(err error, status int, message string) error {
@@ -81,4 +136,13 @@ func NewError(err error, status int, message string) error {
 		Status:  status,
 		Message: message,
 	}
-}
\ No newline at end of file
+}
+
+// jsonError reports a request body that cannot be decoded, naming the invalid value, e.g. a severity or a scope,
+// if that is why.
+func jsonError(err error) error {
+	if ssr.KindOf(err) == ssr.KindInvalid {
+		return NewError(err, http.StatusBadRequest, "Bad request: "+err.Error())
+	}
+	return NewError(err, http.StatusBadRequest, "Bad request: invalid JSON")
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+	"github.com/quantonganh/ssr/mocks"
+)
+
+func TestNewProblem(t *testing.T) {
+	var validationErr ssr.ValidationError
+	validationErr.Add("repository_id", "is required")
+
+	for name, tc := range map[string]struct {
+		err     error
+		problem Problem
+	}{
+		"not found": {
+			err:     errors.Wrap(ssr.ErrScanNotFound, "failed to select scan"),
+			problem: Problem{Type: "urn:ssr:problem:not_found", Title: "Not Found", Status: http.StatusNotFound, Detail: "scan not found"},
+		},
+		"conflict": {
+			err:     &ssr.TransitionError{From: ssr.Success, To: ssr.Queued},
+			problem: Problem{Type: "urn:ssr:problem:conflict", Title: "Conflict", Status: http.StatusConflict, Detail: "cannot move scan from Success to Queued"},
+		},
+		"invalid": {
+			err:     &validationErr,
+			problem: Problem{Type: "urn:ssr:problem:invalid", Title: "Unprocessable Entity", Status: http.StatusUnprocessableEntity, Detail: "invalid fields", Errors: validationErr.Fields},
+		},
+		"client error": {
+			err:     NewError(nil, http.StatusBadRequest, "Bad request: invalid scan ID"),
+			problem: Problem{Type: "urn:ssr:problem:invalid", Title: "Bad Request", Status: http.StatusBadRequest, Detail: "Bad request: invalid scan ID"},
+		},
+		"timeout": {
+			err:     errors.Wrap(context.DeadlineExceeded, "failed to select scan"),
+			problem: Problem{Type: "urn:ssr:problem:internal", Title: "Service Unavailable", Status: http.StatusServiceUnavailable, Detail: "request timed out"},
+		},
+		"internal": {
+			err:     errors.New("connection refused"),
+			problem: Problem{Type: "urn:ssr:problem:internal", Title: "Internal Server Error", Status: http.StatusInternalServerError},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, &tc.problem, NewProblem(tc.err))
+		})
+	}
+}
+
+func TestProblemResponse(t *testing.T) {
+	scanID := uuid.New()
+	scanService := new(mocks.ScanService)
+	scanService.On("GetScan", mock.Anything, scanID).Return(nil, errors.Wrapf(ssr.ErrScanNotFound, "failed to select scan: %s", scanID))
+	s := NewServer(nil, scanService)
+
+	rr := serve(s, httptest.NewRequest(http.MethodGet, "/scans/"+scanID.String(), nil))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, ProblemContentType, rr.Header().Get("Content-Type"))
+
+	var problem Problem
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+	assert.Equal(t, "scan not found", problem.Detail)
+	assert.NotEmpty(t, problem.RequestID)
+	assert.Equal(t, rr.Header().Get("Request-Id"), problem.RequestID)
+}
+
+func TestRequestTimeout(t *testing.T) {
+	scanID := uuid.New()
+	scanService := new(mocks.ScanService)
+	scanService.On("GetScan", mock.MatchedBy(func(ctx context.Context) bool {
+		_, ok := ctx.Deadline()
+		return ok
+	}), scanID).Return(nil, context.DeadlineExceeded)
+	s := NewServer(nil, scanService)
+	s.RequestTimeout = time.Second
+
+	rr := serve(s, httptest.NewRequest(http.MethodGet, "/scans/"+scanID.String(), nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	scanService.AssertExpectations(t)
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/quantonganh/ssr"
+)
+
+// ListScanFindingsHandler returns a page of the findings of a scan.
+func (s *Server) ListScanFindingsHandler(w http.ResponseWriter, r *http.Request) error {
+	scanID, err := uuid.Parse(mux.Vars(r)["scanID"])
+	if err != nil {
+		return NewError(err, http.StatusBadRequest, "Bad request: invalid scan ID")
+	}
+
+	filter, err := parseFindingFilter(r)
+	if err != nil {
+		return err
+	}
+
+	page, err := s.FindingService.ListScanFindings(r.Context(), scanID, filter)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, page)
+}
+
+// ListRepositoryFindingsHandler returns a page of the findings tracked in a repository.
+func (s *Server) ListRepositoryFindingsHandler(w http.ResponseWriter, r *http.Request) error {
+	repoID, err := parseRepoID(r)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFindingFilter(r)
+	if err != nil {
+		return err
+	}
+
+	page, err := s.FindingService.ListRepositoryFindings(r.Context(), repoID, filter)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, page)
+}
+
+// TriageFindingHandler records a triage decision about a finding of a repository.
+// The decision sticks to the fingerprint of the finding, so it carries over to later scans.
+func (s *Server) TriageFindingHandler(w http.ResponseWriter, r *http.Request) error {
+	repoID, err := parseRepoID(r)
+	if err != nil {
+		return err
+	}
+
+	var triage ssr.Triage
+	if err := json.NewDecoder(r.Body).Decode(&triage); err != nil {
+		return NewError(err, http.StatusBadRequest, "Bad request: invalid JSON")
+	}
+	if !ssr.ValidFindingStatus(triage.Status) {
+		return NewError(nil, http.StatusBadRequest, "Bad request: invalid status")
+	}
+	if triage.Actor, err = actor(r.Context(), "actor", triage.Actor); err != nil {
+		return err
+	}
+
+	finding, err := s.FindingService.TriageFinding(r.Context(), repoID, mux.Vars(r)["fingerprint"], triage)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, finding)
+}
+
+// parseFindingFilter reads the filters, sort and cursor of a findings listing from the query string.
+func parseFindingFilter(r *http.Request) (ssr.FindingFilter, error) {
+	filter := ssr.FindingFilter{
+		RuleID:     r.FormValue("rule_id"),
+		PathPrefix: r.FormValue("path"),
+		Status:     r.FormValue("status"),
+		Sort:       r.FormValue("sort"),
+		Limit:      defaultLimit,
+	}
+
+	var err error
+	if v := r.FormValue("type"); v != "" {
+		if filter.Type, err = ssr.ParseFindingType(v); err != nil {
+			return filter, NewError(err, http.StatusBadRequest, "Bad request: invalid type parameter")
+		}
+	}
+	if v := r.FormValue("severity"); v != "" {
+		if filter.Severity, err = ssr.ParseSeverity(v); err != nil {
+			return filter, NewError(err, http.StatusBadRequest, "Bad request: invalid severity parameter")
+		}
+	}
+	if v := r.FormValue("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit <= 0 {
+			return filter, NewError(err, http.StatusBadRequest, "Bad request: invalid limit parameter")
+		}
+	}
+	if _, _, err := ssr.ParseFindingSort(filter.Sort); err != nil {
+		return filter, NewError(err, http.StatusBadRequest, "Bad request: invalid sort parameter")
+	}
+	if filter.Status != "" && !ssr.ValidFindingStatus(filter.Status) {
+		return filter, NewError(nil, http.StatusBadRequest, "Bad request: invalid status parameter")
+	}
+	if v := r.FormValue("suppressed"); v != "" {
+		suppressed, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, NewError(err, http.StatusBadRequest, "Bad request: invalid suppressed parameter")
+		}
+		filter.Suppressed = &suppressed
+	}
+	if v := r.FormValue("cursor"); v != "" {
+		if filter.After, err = ssr.DecodeFindingCursor(v); err != nil {
+			return filter, NewError(err, http.StatusBadRequest, "Bad request: invalid cursor parameter")
+		}
+		// A cursor only makes sense in the order of the page it was taken from.
+		if filter.After.Sort != filter.Sort {
+			return filter, NewError(nil, http.StatusBadRequest, "Bad request: cursor does not match sort parameter")
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+	"github.com/quantonganh/ssr/mocks"
+)
+
+func TestFindingHandler(t *testing.T) {
+	scanID := uuid.New()
+	finding := ssr.Finding{
+		ID:     6,
+		Type:   "sast",
+		RuleID: "G402",
+		Location: ssr.Location{
+			Path: "connectors/tls.go",
+		},
+		Metadata: ssr.Metadata{
+			Severity: "HIGH",
+		},
+		Status: ssr.FindingOpen,
+	}
+	cursor := ssr.FindingCursor{Sort: "-severity", Value: "HIGH", ID: 6}
+
+	findingService := new(mocks.FindingService)
+	findingService.On("ListScanFindings", mock.Anything, scanID, ssr.FindingFilter{
+		RuleID:     "G402",
+		Severity:   "HIGH",
+		PathPrefix: "connectors/",
+		Sort:       "-severity",
+		Limit:      1,
+	}).Return(&ssr.FindingPage{Findings: ssr.Findings{finding}, NextCursor: cursor.Encode()}, nil)
+	findingService.On("ListScanFindings", mock.Anything, scanID, ssr.FindingFilter{
+		RuleID:     "G402",
+		Severity:   "HIGH",
+		PathPrefix: "connectors/",
+		Sort:       "-severity",
+		After:      &cursor,
+		Limit:      1,
+	}).Return(&ssr.FindingPage{Findings: ssr.Findings{}}, nil)
+	findingService.On("ListRepositoryFindings", mock.Anything, uint64(1), ssr.FindingFilter{Status: ssr.FindingOpen, Limit: defaultLimit}).
+		Return(&ssr.FindingPage{Findings: ssr.Findings{finding}}, nil)
+	findingService.On("ListRepositoryFindings", mock.Anything, uint64(2), ssr.FindingFilter{Limit: defaultLimit}).
+		Return(nil, ssr.ErrRepositoryNotFound)
+
+	triage := ssr.Triage{Status: ssr.FindingFalsePositive, Reason: "test fixture", Actor: "alice@example.com"}
+	findingService.On("TriageFinding", mock.Anything, uint64(1), "abc", triage).
+		Return(&ssr.RepositoryFinding{RepositoryID: 1, Fingerprint: "abc", Status: triage.Status, TriageReason: triage.Reason, TriagedBy: triage.Actor}, nil)
+	findingService.On("TriageFinding", mock.Anything, uint64(1), "def", triage).Return(nil, ssr.ErrFindingNotFound)
+
+	s := NewServer(nil, nil)
+	s.FindingService = findingService
+
+	t.Run("list scan findings", func(t *testing.T) {
+		url := "/scans/" + scanID.String() + "/findings?rule_id=G402&severity=HIGH&path=connectors/&sort=-severity&limit=1"
+		rr := serve(s, httptest.NewRequest(http.MethodGet, url, nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var page ssr.FindingPage
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&page))
+		require.Len(t, page.Findings, 1)
+		assert.Equal(t, "G402", page.Findings[0].RuleID)
+		assert.Equal(t, ssr.FindingOpen, page.Findings[0].Status)
+
+		rr = serve(s, httptest.NewRequest(http.MethodGet, url+"&cursor="+page.NextCursor, nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var next ssr.FindingPage
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&next))
+		assert.Empty(t, next.Findings)
+		assert.Empty(t, next.NextCursor)
+	})
+
+	t.Run("list repository findings", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/repositories/1/findings?status=open", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var page ssr.FindingPage
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&page))
+		assert.Len(t, page.Findings, 1)
+	})
+
+	t.Run("list findings of unknown repository", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/repositories/2/findings", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("triage finding", func(t *testing.T) {
+		body, err := json.Marshal(triage)
+		require.NoError(t, err)
+
+		rr := serve(s, httptest.NewRequest(http.MethodPut, "/repositories/1/findings/abc/triage", bytes.NewBuffer(body)))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var rf ssr.RepositoryFinding
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&rf))
+		assert.Equal(t, ssr.FindingFalsePositive, rf.Status)
+		assert.Equal(t, "alice@example.com", rf.TriagedBy)
+	})
+
+	t.Run("triage unknown finding", func(t *testing.T) {
+		body, err := json.Marshal(triage)
+		require.NoError(t, err)
+
+		rr := serve(s, httptest.NewRequest(http.MethodPut, "/repositories/1/findings/def/triage", bytes.NewBuffer(body)))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	for name, body := range map[string]string{
+		"triage with invalid status": `{"status": "wontfix", "actor": "alice@example.com"}`,
+		"triage without actor":       `{"status": "confirmed"}`,
+		"triage with invalid JSON":   `{"status": `,
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := serve(s, httptest.NewRequest(http.MethodPut, "/repositories/1/findings/abc/triage", bytes.NewBufferString(body)))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+
+	for name, query := range map[string]string{
+		"invalid sort":       "sort=description",
+		"invalid status":     "status=closed",
+		"invalid limit":      "limit=0",
+		"invalid cursor":     "cursor=%21",
+		"invalid suppressed": "suppressed=maybe",
+		"invalid severity":   "severity=urgent",
+		"invalid type":       "type=dast",
+		"cursor from sort":   "sort=path&cursor=" + cursor.Encode(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := serve(s, httptest.NewRequest(http.MethodGet, "/scans/"+scanID.String()+"/findings?"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+
+	findingService.AssertExpectations(t)
+}
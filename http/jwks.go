@@ -0,0 +1,268 @@
+package http
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/quantonganh/ssr"
+)
+
+const (
+	// jwksMaxAge is how long a key set is used before it is read again, so that revoked keys are dropped.
+	jwksMaxAge = time.Hour
+	// jwksMinRefresh is how often at most a key set is read again to find a key it does not have,
+	// so that tokens signed with unknown keys cannot make it hammer the identity provider.
+	jwksMinRefresh = time.Minute
+	jwksTimeout    = 10 * time.Second
+)
+
+// errUnknownKey is returned when a token is signed with a key that the key set does not have.
+var errUnknownKey = errors.Wrap(ssr.ErrUnauthorized, "unknown signing key")
+
+// JWKS is a JSON Web Key Set (RFC 7517) read from a file or fetched from a URL. It is read again when
+// a token is signed with a key that it does not have, so that the identity provider can rotate its keys.
+type JWKS struct {
+	source string
+	client *http.Client
+	// maxAge and minRefresh are jwksMaxAge and jwksMinRefresh, overridden by tests.
+	maxAge     time.Duration
+	minRefresh time.Duration
+
+	mu     sync.Mutex
+	keys   map[string]crypto.PublicKey
+	readAt time.Time
+	// failedAt is when the key set last failed to be read, it is not read again before minRefresh.
+	failedAt time.Time
+	// reading is the read in flight, nil when there is none.
+	reading *jwksRead
+}
+
+// jwksRead is a read of a key set, shared by the requests that wait for it.
+type jwksRead struct {
+	done chan struct{}
+	err  error
+}
+
+// NewJWKS returns the key set at source, an http(s) URL or the path of a file. It is read on first use.
+func NewJWKS(source string) *JWKS {
+	return &JWKS{
+		source: source,
+		client: &http.Client{Timeout: jwksTimeout},
+
+		maxAge:     jwksMaxAge,
+		minRefresh: jwksMinRefresh,
+	}
+}
+
+// Key returns the public key identified by kid. An empty kid is only accepted from a key set of one key.
+// The keys already read are used until the key set is read again, even past maxAge when reading it fails.
+// A key that cannot be looked up because the key set cannot be read is an error with status 503.
+func (s *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	key, ok := s.lookup(kid)
+	read := s.keys != nil
+	age := time.Since(s.readAt)
+	// The key set is not read again right after failing to, so that an unavailable identity provider is not hammered.
+	refresh := (!read || age > s.maxAge || (!ok && age > s.minRefresh)) && time.Since(s.failedAt) > s.minRefresh
+	s.mu.Unlock()
+
+	var err error
+	if refresh {
+		if err = s.refresh(ctx); err == nil {
+			s.mu.Lock()
+			key, ok = s.lookup(kid)
+			read = true
+			s.mu.Unlock()
+		}
+	}
+	if !ok {
+		if !read || err != nil {
+			return nil, NewError(err, http.StatusServiceUnavailable, "Service unavailable: failed to read the keys of the identity provider")
+		}
+		return nil, errUnknownKey
+	}
+	return key, nil
+}
+
+// refresh reads the key set again. Concurrent callers wait for a single read, which runs without holding s.mu
+// and outlives the request that started it, so that a canceled request does not fail the others.
+func (s *JWKS) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	read := s.reading
+	if read == nil {
+		read = &jwksRead{done: make(chan struct{})}
+		s.reading = read
+		go func() {
+			keys, err := s.read(context.Background())
+
+			s.mu.Lock()
+			if err != nil {
+				s.failedAt = time.Now()
+			} else {
+				s.keys = keys
+				s.readAt = time.Now()
+			}
+			s.reading = nil
+			s.mu.Unlock()
+
+			read.err = err
+			close(read.done)
+		}()
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-read.done:
+		return read.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *JWKS) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (s *JWKS) read(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	if !strings.HasPrefix(s.source, "https://") && !strings.HasPrefix(s.source, "http://") {
+		b, err := ioutil.ReadFile(s.source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read JWKS: %s", s.source)
+		}
+		return parseJWKS(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.source, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create JWKS request: %s", s.source)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch JWKS: %s", s.source)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to fetch JWKS: %s: %s", s.source, resp.Status)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read JWKS: %s", s.source)
+	}
+	return parseJWKS(b)
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// N and E are the modulus and exponent of RSA keys.
+	N string `json:"n"`
+	E string `json:"e"`
+	// Crv, X and Y are the curve and coordinates of EC keys.
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS reads the RSA and EC signing keys of a key set, other keys are skipped.
+func parseJWKS(b []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, errors.Wrap(err, "failed to decode JWKS")
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key crypto.PublicKey
+		var err error
+		switch k.Kty {
+		case "RSA":
+			key, err = k.rsaKey()
+		case "EC":
+			key, err = k.ecKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid JWK: %s", k.Kid)
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jwk) rsaKey() (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBigInt(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid RSA exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jwk) ecKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, errors.Errorf("unsupported curve: %s", k.Crv)
+	}
+	x, err := decodeBigInt(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBigInt(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("point is not on curve")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid base64url value")
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
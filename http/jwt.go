@@ -0,0 +1,143 @@
+package http
+
+import (
+	"context"
+	"crypto"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+
+	"github.com/quantonganh/ssr"
+)
+
+// signingMethods are the algorithms that tokens may be signed with. Symmetric algorithms and "none" are rejected.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// KeySet provides the public keys that tokens are signed with, see JWKS.
+type KeySet interface {
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// JWTAuthenticator verifies the tokens of an OIDC identity provider and maps their claims to users.
+type JWTAuthenticator struct {
+	// Issuer is the iss claim that tokens must have.
+	Issuer string
+	// Audience is the aud claim that tokens must have, if set.
+	Audience string
+	Keys     KeySet
+	// EmailClaim and GroupsClaim name the claims that hold the email address and the groups of the user.
+	EmailClaim  string
+	GroupsClaim string
+	// GroupScopes grants scopes to the members of groups of the identity provider.
+	GroupScopes map[string][]ssr.Scope
+	// OrganizationClaim names the claim that holds the name of the organization of the user, which Organizations
+	// resolves. Without it, every user belongs to ssr.DefaultOrganizationID.
+	OrganizationClaim string
+	Organizations     ssr.OrganizationService
+}
+
+func NewJWTAuthenticator(issuer, audience string, keys KeySet) *JWTAuthenticator {
+	return &JWTAuthenticator{
+		Issuer:      issuer,
+		Audience:    audience,
+		Keys:        keys,
+		EmailClaim:  "email",
+		GroupsClaim: "groups",
+		GroupScopes: make(map[string][]ssr.Scope),
+	}
+}
+
+// Authenticate verifies the signature and the claims of token and returns its user.
+// It returns ssr.ErrUnauthorized if the token is invalid.
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, token string) (*ssr.User, error) {
+	var keyErr error
+	keyFunc := func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := a.Keys.Key(ctx, kid)
+		keyErr = err
+		return key, err
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.NewParser(jwt.WithValidMethods(signingMethods)).ParseWithClaims(token, claims, keyFunc); err != nil {
+		if keyErr != nil && ssr.KindOf(keyErr) != ssr.KindUnauthorized {
+			return nil, keyErr
+		}
+		return nil, errors.Wrapf(ssr.ErrUnauthorized, "invalid token: %v", err)
+	}
+	// The parser only checks the time claims that are present, tokens must expire.
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, errors.Wrap(ssr.ErrUnauthorized, "token without exp claim")
+	}
+	if !claims.VerifyIssuer(a.Issuer, true) {
+		return nil, errors.Wrap(ssr.ErrUnauthorized, "token of another issuer")
+	}
+	if a.Audience != "" && !claims.VerifyAudience(a.Audience, true) {
+		return nil, errors.Wrap(ssr.ErrUnauthorized, "token for another audience")
+	}
+
+	var err error
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, errors.Wrap(ssr.ErrUnauthorized, "token without sub claim")
+	}
+	u := &ssr.User{
+		ID:     sub,
+		Groups: stringsClaim(claims[a.GroupsClaim]),
+		Scopes: ssr.ScopeList{},
+	}
+	u.Email, _ = claims[a.EmailClaim].(string)
+	u.EmailVerified, _ = claims["email_verified"].(bool)
+	if u.OrganizationID, err = a.organization(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	granted := make(map[ssr.Scope]bool)
+	for _, g := range u.Groups {
+		for _, scope := range a.GroupScopes[g] {
+			if !granted[scope] {
+				granted[scope] = true
+				u.Scopes = append(u.Scopes, scope)
+			}
+		}
+	}
+	return u, nil
+}
+
+// organization returns the organization named by the claims of a token.
+func (a *JWTAuthenticator) organization(ctx context.Context, claims jwt.MapClaims) (uint64, error) {
+	if a.OrganizationClaim == "" {
+		return ssr.DefaultOrganizationID, nil
+	}
+
+	name, _ := claims[a.OrganizationClaim].(string)
+	if name == "" {
+		return 0, errors.Wrapf(ssr.ErrUnauthorized, "token without %s claim", a.OrganizationClaim)
+	}
+	o, err := a.Organizations.GetByName(ctx, name)
+	if err != nil {
+		if ssr.KindOf(err) == ssr.KindNotFound {
+			return 0, errors.Wrapf(ssr.ErrUnauthorized, "token of unknown organization: %s", name)
+		}
+		return 0, err
+	}
+	return o.ID, nil
+}
+
+// stringsClaim reads a claim that is either a string or an array of strings.
+func stringsClaim(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		s := make([]string, 0, len(v))
+		for _, e := range v {
+			if e, ok := e.(string); ok {
+				s = append(s, e)
+			}
+		}
+		return s
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+	"github.com/quantonganh/ssr/http/oidctest"
+	"github.com/quantonganh/ssr/mocks"
+)
+
+func newTestAuthenticator(t *testing.T) (*oidctest.Issuer, *JWTAuthenticator) {
+	issuer, err := oidctest.NewIssuer()
+	require.NoError(t, err)
+	t.Cleanup(issuer.Close)
+
+	a := NewJWTAuthenticator(issuer.URL, "ssr", NewJWKS(issuer.JWKSURL()))
+	a.GroupScopes["security"] = ssr.Scopes
+	a.GroupScopes["auditors"] = []ssr.Scope{ssr.ScopeScansRead, ssr.ScopeRepositoriesRead}
+	return issuer, a
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	issuer, a := newTestAuthenticator(t)
+	ctx := context.Background()
+
+	t.Run("valid token", func(t *testing.T) {
+		token, err := issuer.Token("alice", map[string]interface{}{
+			"aud":            "ssr",
+			"email":          "alice@example.com",
+			"email_verified": true,
+			"groups":         []string{"auditors", "engineering"},
+		})
+		require.NoError(t, err)
+
+		user, err := a.Authenticate(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", user.ID)
+		assert.Equal(t, "alice@example.com", user.Name())
+		assert.Equal(t, []string{"auditors", "engineering"}, user.Groups)
+		assert.Equal(t, ssr.ScopeList{ssr.ScopeScansRead, ssr.ScopeRepositoriesRead}, user.Scopes)
+		assert.Equal(t, ssr.DefaultOrganizationID, user.OrganizationID)
+	})
+
+	t.Run("unverified email", func(t *testing.T) {
+		token, err := issuer.Token("alice", map[string]interface{}{"aud": "ssr", "email": "bob@example.com", "email_verified": false})
+		require.NoError(t, err)
+
+		user, err := a.Authenticate(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", user.Name())
+	})
+
+	for name, claims := range map[string]map[string]interface{}{
+		"expired":        {"aud": "ssr", "exp": time.Now().Add(-time.Minute).Unix()},
+		"without expiry": {"aud": "ssr", "exp": nil},
+		"other issuer":   {"aud": "ssr", "iss": "https://idp.example.com"},
+		"other audience": {"aud": "grafana"},
+		"without sub":    {"aud": "ssr", "sub": ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			token, err := issuer.Token("alice", claims)
+			require.NoError(t, err)
+
+			_, err = a.Authenticate(ctx, token)
+			assert.ErrorIs(t, err, ssr.ErrUnauthorized)
+		})
+	}
+
+	t.Run("signed by another issuer", func(t *testing.T) {
+		other, err := oidctest.NewIssuer()
+		require.NoError(t, err)
+		defer other.Close()
+		token, err := other.Token("alice", map[string]interface{}{"aud": "ssr", "iss": issuer.URL})
+		require.NoError(t, err)
+
+		_, err = a.Authenticate(ctx, token)
+		assert.ErrorIs(t, err, ssr.ErrUnauthorized)
+	})
+
+	t.Run("symmetric algorithm", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"iss": issuer.URL, "sub": "alice", "aud": "ssr", "exp": time.Now().Add(time.Hour).Unix(),
+		}).SignedString([]byte("secret"))
+		require.NoError(t, err)
+
+		_, err = a.Authenticate(ctx, token)
+		assert.ErrorIs(t, err, ssr.ErrUnauthorized)
+	})
+
+	t.Run("rotated key", func(t *testing.T) {
+		require.NoError(t, issuer.Rotate())
+		token, err := issuer.Token("alice", map[string]interface{}{"aud": "ssr"})
+		require.NoError(t, err)
+		requests := issuer.JWKSRequests()
+
+		_, err = a.Authenticate(ctx, token)
+		assert.ErrorIs(t, err, ssr.ErrUnauthorized, "the key set is not fetched again right away")
+		assert.Equal(t, requests, issuer.JWKSRequests())
+
+		a.Keys.(*JWKS).minRefresh = 0
+		_, err = a.Authenticate(ctx, token)
+		assert.NoError(t, err)
+		assert.Equal(t, requests+1, issuer.JWKSRequests())
+	})
+
+	t.Run("unavailable key set", func(t *testing.T) {
+		token, err := issuer.Token("alice", map[string]interface{}{"aud": "ssr"})
+		require.NoError(t, err)
+		require.NoError(t, issuer.Rotate())
+		rotated, err := issuer.Token("alice", map[string]interface{}{"aud": "ssr"})
+		require.NoError(t, err)
+		issuer.Close()
+
+		a.Keys.(*JWKS).maxAge = 0
+		_, err = a.Authenticate(ctx, token)
+		assert.NoError(t, err, "the keys already read are still used")
+
+		_, err = a.Authenticate(ctx, rotated)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, NewProblem(err).Status)
+	})
+}
+
+func TestJWTAuthenticatorOrganization(t *testing.T) {
+	issuer, a := newTestAuthenticator(t)
+	ctx := context.Background()
+
+	organizationService := new(mocks.OrganizationService)
+	organizationService.On("GetByName", mock.Anything, "payments").Return(&ssr.Organization{ID: 2, Name: "payments"}, nil)
+	organizationService.On("GetByName", mock.Anything, "unknown").Return(nil, ssr.ErrOrganizationNotFound)
+	a.OrganizationClaim = "org"
+	a.Organizations = organizationService
+
+	t.Run("organization of the token", func(t *testing.T) {
+		token, err := issuer.Token("alice", map[string]interface{}{"aud": "ssr", "org": "payments"})
+		require.NoError(t, err)
+
+		user, err := a.Authenticate(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), user.OrganizationID)
+	})
+
+	for name, claims := range map[string]map[string]interface{}{
+		"without organization": {"aud": "ssr"},
+		"unknown organization": {"aud": "ssr", "org": "unknown"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			token, err := issuer.Token("alice", claims)
+			require.NoError(t, err)
+
+			_, err = a.Authenticate(ctx, token)
+			assert.ErrorIs(t, err, ssr.ErrUnauthorized)
+		})
+	}
+}
+
+func TestJWTAuthorize(t *testing.T) {
+	issuer, a := newTestAuthenticator(t)
+
+	repositoryService := new(mocks.RepositoryService)
+	repositoryService.On("List", mock.Anything, mock.Anything).Return([]*ssr.Repository{}, nil)
+	findingService := new(mocks.FindingService)
+	findingService.On("TriageFinding", mock.Anything, uint64(1), "abc", mock.MatchedBy(func(triage ssr.Triage) bool {
+		return triage.Actor == "bob@example.com"
+	})).Return(&ssr.RepositoryFinding{}, nil)
+
+	s := NewServer(repositoryService, nil)
+	s.FindingService = findingService
+	s.JWTAuthenticator = a
+
+	request := func(method, target, subject, group string, body string) *httptest.ResponseRecorder {
+		token, err := issuer.Token(subject, map[string]interface{}{
+			"aud":            "ssr",
+			"email":          subject + "@example.com",
+			"email_verified": true,
+			"groups":         []string{group},
+		})
+		require.NoError(t, err)
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		return serve(s, req)
+	}
+
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/repositories", "alice", "auditors", "").Code)
+	assert.Equal(t, http.StatusForbidden, request(http.MethodDelete, "/repositories/1", "alice", "auditors", "").Code)
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/repositories", "carol", "engineering", "").Code)
+
+	rr := request(http.MethodPut, "/repositories/1/findings/abc/triage", "bob", "security", fmt.Sprintf(`{"status": %q}`, ssr.FindingFalsePositive))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	findingService.AssertExpectations(t)
+
+	rr = request(http.MethodPut, "/repositories/1/findings/abc/triage", "bob", "security", fmt.Sprintf(`{"status": %q, "actor": "alice@example.com"}`, ssr.FindingFalsePositive))
+	assert.Equal(t, http.StatusForbidden, rr.Code, "a user cannot triage on behalf of another")
+
+	req := httptest.NewRequest(http.MethodGet, "/repositories", nil)
+	req.Header.Set("Authorization", "Bearer not.a.token")
+	assert.Equal(t, http.StatusUnauthorized, serve(s, req).Code)
+}
+
+func TestJWKSFile(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	jwks := fmt.Sprintf(`{"keys": [{"kty": "EC", "crv": "P-256", "kid": "ec", "x": %q, "y": %q}, {"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"}]}`,
+		base64.RawURLEncoding.EncodeToString(key.X.Bytes()), base64.RawURLEncoding.EncodeToString(key.Y.Bytes()))
+	require.NoError(t, ioutil.WriteFile(path, []byte(jwks), 0600))
+
+	a := NewJWTAuthenticator("https://idp.example.com", "", NewJWKS(path))
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": "https://idp.example.com", "sub": "alice", "exp": time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = "ec"
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	user, err := a.Authenticate(context.Background(), signed)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.ID)
+	assert.Empty(t, user.Scopes)
+
+	_, err = NewJWKS(filepath.Join(os.TempDir(), "missing.json")).Key(context.Background(), "ec")
+	assert.Error(t, err)
+	assert.NotEqual(t, ssr.KindUnauthorized, ssr.KindOf(err))
+}
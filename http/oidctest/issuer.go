@@ -0,0 +1,123 @@
+// Package oidctest provides an in-process OIDC issuer, so that token authentication can be tested
+// without an identity provider.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// JWKSPath is the path that the issuer serves its key set at.
+const JWKSPath = "/.well-known/jwks.json"
+
+// Issuer signs tokens with RSA keys and serves the public keys as a JWKS. Its URL is the issuer of its tokens.
+type Issuer struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	keys         []*rsa.PrivateKey
+	jwksRequests int
+}
+
+// NewIssuer starts an issuer with one signing key. It must be closed once done.
+func NewIssuer() (*Issuer, error) {
+	i := &Issuer{}
+	if err := i.Rotate(); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(JWKSPath, i.serveJWKS)
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   i.URL,
+			"jwks_uri": i.JWKSURL(),
+		})
+	})
+	i.Server = httptest.NewServer(mux)
+	return i, nil
+}
+
+// JWKSURL is the URL of the key set of the issuer.
+func (i *Issuer) JWKSURL() string {
+	return i.URL + JWKSPath
+}
+
+// JWKSRequests counts how many times the key set was fetched.
+func (i *Issuer) JWKSRequests() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.jwksRequests
+}
+
+// Rotate adds a key that signs the tokens from then on. Previous keys are still served.
+func (i *Issuer) Rotate() error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.keys = append(i.keys, key)
+	return nil
+}
+
+// Token signs a token for subject with the current key. iss, sub, iat and exp default to the issuer,
+// subject, now and an hour from now, claims overrides them and adds others, e.g. "email" or "groups".
+// A nil claim is left out.
+func (i *Issuer) Token(subject string, claims map[string]interface{}) (string, error) {
+	now := time.Now()
+	c := jwt.MapClaims{
+		"iss": i.URL,
+		"sub": subject,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	for k, v := range claims {
+		if v == nil {
+			delete(c, k)
+			continue
+		}
+		c[k] = v
+	}
+
+	i.mu.Lock()
+	kid := strconv.Itoa(len(i.keys))
+	key := i.keys[len(i.keys)-1]
+	i.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, c)
+	token.Header["kid"] = kid
+	return token.SignedString(key)
+}
+
+func (i *Issuer) serveJWKS(w http.ResponseWriter, r *http.Request) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.jwksRequests++
+
+	keys := make([]map[string]string, 0, len(i.keys))
+	for n, key := range i.keys {
+		keys = append(keys, map[string]string{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": strconv.Itoa(n + 1),
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+}
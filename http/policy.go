@@ -0,0 +1,124 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/quantonganh/ssr"
+)
+
+// CreatePolicyHandler adds a policy. Under /repositories/{repoID} the policy applies to that repository,
+// under /policies it applies to every repository.
+// It is taken into account by the scans that succeed from then on.
+func (s *Server) CreatePolicyHandler(w http.ResponseWriter, r *http.Request) error {
+	var policy ssr.Policy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		return jsonError(err)
+	}
+	if policy.Name == "" {
+		return NewError(nil, http.StatusBadRequest, "Bad request: name is required")
+	}
+	if policy.MaxFindings < 0 {
+		return NewError(nil, http.StatusBadRequest, "Bad request: max_findings must not be negative")
+	}
+	policy.ID = 0
+	policy.RepositoryID = nil
+	if _, ok := mux.Vars(r)["repoID"]; ok {
+		repoID, err := parseRepoID(r)
+		if err != nil {
+			return err
+		}
+		policy.RepositoryID = &repoID
+	}
+
+	if err := s.PolicyService.Create(r.Context(), &policy); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	return writeJSON(w, policy)
+}
+
+// ListPoliciesHandler lists the policies that apply to a repository, global ones included,
+// or only the global policies under /policies.
+func (s *Server) ListPoliciesHandler(w http.ResponseWriter, r *http.Request) error {
+	var repoID uint64
+	if _, ok := mux.Vars(r)["repoID"]; ok {
+		var err error
+		if repoID, err = parseRepoID(r); err != nil {
+			return err
+		}
+	}
+
+	policies, err := s.PolicyService.List(r.Context(), repoID)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, policies)
+}
+
+func (s *Server) GetPolicyHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := parsePolicyID(r)
+	if err != nil {
+		return err
+	}
+
+	policy, err := s.PolicyService.Get(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, policy)
+}
+
+func (s *Server) DeletePolicyHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := parsePolicyID(r)
+	if err != nil {
+		return err
+	}
+
+	if err := s.PolicyService.Delete(r.Context(), id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetScanGateHandler returns the result of the policies of a scan.
+// The gate is pending until the scan completes, and fails without reasons if the scan failed.
+func (s *Server) GetScanGateHandler(w http.ResponseWriter, r *http.Request) error {
+	scanID, err := uuid.Parse(mux.Vars(r)["scanID"])
+	if err != nil {
+		return NewError(err, http.StatusBadRequest, "Bad request: invalid scan ID")
+	}
+
+	scan, err := s.ScanService.GetScan(r.Context(), scanID)
+	if err != nil {
+		return err
+	}
+
+	gate := scan.Gate
+	if gate == nil {
+		gate = &ssr.Gate{
+			Status: ssr.GatePending,
+		}
+		if scan.Status == ssr.Failure {
+			gate.Status = ssr.GateFailed
+		}
+	}
+
+	return writeJSON(w, gate)
+}
+
+func parsePolicyID(r *http.Request) (uint64, error) {
+	id, err := strconv.ParseUint(mux.Vars(r)["policyID"], 10, 64)
+	if err != nil {
+		return 0, NewError(err, http.StatusBadRequest, "Bad request: invalid policy ID")
+	}
+	return id, nil
+}
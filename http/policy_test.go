@@ -0,0 +1,128 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+	"github.com/quantonganh/ssr/mocks"
+)
+
+func TestPolicyHandler(t *testing.T) {
+	policy := &ssr.Policy{
+		ID:       2,
+		Name:     "no new high",
+		Severity: "HIGH",
+		NewOnly:  true,
+	}
+
+	policyService := new(mocks.PolicyService)
+	policyService.On("Create", mock.Anything, mock.MatchedBy(func(p *ssr.Policy) bool {
+		return p.RepositoryID != nil && *p.RepositoryID == 1 && p.ID == 0
+	})).Return(nil)
+	policyService.On("Create", mock.Anything, mock.MatchedBy(func(p *ssr.Policy) bool {
+		return p.RepositoryID == nil
+	})).Return(nil)
+	policyService.On("List", mock.Anything, uint64(1)).Return([]*ssr.Policy{policy}, nil)
+	policyService.On("List", mock.Anything, uint64(0)).Return([]*ssr.Policy{}, nil)
+	policyService.On("Get", mock.Anything, uint64(2)).Return(policy, nil)
+	policyService.On("Get", mock.Anything, uint64(3)).Return(nil, ssr.ErrPolicyNotFound)
+	policyService.On("Delete", mock.Anything, uint64(2)).Return(nil)
+
+	s := NewServer(nil, nil)
+	s.PolicyService = policyService
+
+	t.Run("create repository policy", func(t *testing.T) {
+		body, err := json.Marshal(policy)
+		require.NoError(t, err)
+
+		rr := serve(s, httptest.NewRequest(http.MethodPost, "/repositories/1/policies", bytes.NewBuffer(body)))
+		assert.Equal(t, http.StatusCreated, rr.Code)
+	})
+
+	t.Run("create global policy", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodPost, "/policies", bytes.NewBufferString(`{"name": "few medium", "severity": "MEDIUM", "max_findings": 10, "repository_id": 1}`)))
+		assert.Equal(t, http.StatusCreated, rr.Code)
+	})
+
+	for name, body := range map[string]string{
+		"create policy without name":          `{"severity": "HIGH"}`,
+		"create policy with negative maximum": `{"name": "no high", "max_findings": -1}`,
+		"create policy with invalid JSON":     `{"name": `,
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := serve(s, httptest.NewRequest(http.MethodPost, "/policies", bytes.NewBufferString(body)))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+
+	t.Run("list policies of repository", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/repositories/1/policies", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var policies []*ssr.Policy
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&policies))
+		assert.Len(t, policies, 1)
+	})
+
+	t.Run("list global policies", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/policies", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("get policy", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/policies/2", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("get unknown policy", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/policies/3", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("delete policy", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodDelete, "/policies/2", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	policyService.AssertExpectations(t)
+}
+
+func TestGetScanGateHandler(t *testing.T) {
+	passed := &ssr.Scan{ID: uuid.New(), Status: ssr.Success, Gate: &ssr.Gate{Status: ssr.GatePassed}}
+	running := &ssr.Scan{ID: uuid.New(), Status: ssr.InProgress}
+	failed := &ssr.Scan{ID: uuid.New(), Status: ssr.Failure}
+
+	scanService := new(mocks.ScanService)
+	for _, scan := range []*ssr.Scan{passed, running, failed} {
+		scanService.On("GetScan", mock.Anything, scan.ID).Return(scan, nil)
+	}
+
+	s := NewServer(nil, scanService)
+
+	for name, tc := range map[string]struct {
+		scan   *ssr.Scan
+		status string
+	}{
+		"successful scan": {passed, ssr.GatePassed},
+		"running scan":    {running, ssr.GatePending},
+		"failed scan":     {failed, ssr.GateFailed},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := serve(s, httptest.NewRequest(http.MethodGet, "/scans/"+tc.scan.ID.String()+"/gate", nil))
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var gate ssr.Gate
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&gate))
+			assert.Equal(t, tc.status, gate.Status)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/quantonganh/ssr"
+)
+
+func (s *Server) CreateRepositoryHandler(w http.ResponseWriter, r *http.Request) error {
+	var repo ssr.Repository
+	if err := json.NewDecoder(r.Body).Decode(&repo); err != nil {
+		return NewError(err, http.StatusBadRequest, "Bad request: invalid JSON")
+	}
+	if repo.FullName == "" {
+		return NewError(nil, http.StatusBadRequest, "Bad request: full_name is required")
+	}
+
+	if err := s.RepositoryService.Create(r.Context(), &repo); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	return writeJSON(w, repo)
+}
+
+func (s *Server) GetRepositoryHandler(w http.ResponseWriter, r *http.Request) error {
+	repoID, err := parseRepoID(r)
+	if err != nil {
+		return err
+	}
+
+	repo, err := s.RepositoryService.Get(r.Context(), repoID)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, repo)
+}
+
+func (s *Server) ListRepositoriesHandler(w http.ResponseWriter, r *http.Request) error {
+	filter := ssr.RepositoryFilter{
+		Provider: r.FormValue("provider"),
+		FullName: r.FormValue("full_name"),
+		Page:     1,
+		Limit:    defaultLimit,
+	}
+
+	var err error
+	if v := r.FormValue("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit <= 0 {
+			return NewError(err, http.StatusBadRequest, "Bad request: invalid limit parameter")
+		}
+	}
+	if v := r.FormValue("page"); v != "" {
+		if filter.Page, err = strconv.Atoi(v); err != nil || filter.Page <= 0 {
+			return NewError(err, http.StatusBadRequest, "Bad request: invalid page parameter")
+		}
+	}
+
+	repos, err := s.RepositoryService.List(r.Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, repos)
+}
+
+func (s *Server) UpdateRepositoryHandler(w http.ResponseWriter, r *http.Request) error {
+	repoID, err := parseRepoID(r)
+	if err != nil {
+		return err
+	}
+
+	var upd ssr.RepositoryUpdate
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		return NewError(err, http.StatusBadRequest, "Bad request: invalid JSON")
+	}
+
+	repo, err := s.RepositoryService.Update(r.Context(), repoID, upd)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, repo)
+}
+
+func (s *Server) DeleteRepositoryHandler(w http.ResponseWriter, r *http.Request) error {
+	repoID, err := parseRepoID(r)
+	if err != nil {
+		return err
+	}
+
+	if err := s.RepositoryService.Delete(r.Context(), repoID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func parseRepoID(r *http.Request) (uint64, error) {
+	repoID, err := strconv.ParseUint(mux.Vars(r)["repoID"], 10, 64)
+	if err != nil {
+		return 0, NewError(err, http.StatusBadRequest, "Bad request: invalid repository ID")
+	}
+	return repoID, nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) error {
+	response, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal response")
+	}
+
+	_, err = w.Write(response)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write response body")
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+	"github.com/quantonganh/ssr/mocks"
+)
+
+func TestRepositoryHandler(t *testing.T) {
+	repo := &ssr.Repository{
+		ID:          1,
+		Provider:    "GitHub",
+		FullName:    "quantonganh/ssr",
+		Description: "Security scan result",
+	}
+	description := "Security Scan Result"
+	upd := ssr.RepositoryUpdate{
+		Description: &description,
+	}
+
+	repoService := new(mocks.RepositoryService)
+	repoService.On("Create", mock.Anything, repo).Return(nil)
+	repoService.On("Get", mock.Anything, uint64(1)).Return(repo, nil)
+	repoService.On("Get", mock.Anything, uint64(2)).Return(nil, ssr.ErrRepositoryNotFound)
+	repoService.On("List", mock.Anything, ssr.RepositoryFilter{Provider: "GitHub", Page: 1, Limit: 5}).Return([]*ssr.Repository{repo}, nil)
+	repoService.On("Update", mock.Anything, uint64(1), upd).Return(repo, nil)
+	repoService.On("Delete", mock.Anything, uint64(1)).Return(nil)
+
+	s := NewServer(repoService, nil)
+
+	t.Run("create repository", func(t *testing.T) {
+		body, err := json.Marshal(repo)
+		require.NoError(t, err)
+
+		rr := serve(s, httptest.NewRequest(http.MethodPost, "/repositories", bytes.NewBuffer(body)))
+		assert.Equal(t, http.StatusCreated, rr.Code)
+	})
+
+	t.Run("get repository", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/repositories/1", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var resp ssr.Repository
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		assert.Equal(t, "quantonganh/ssr", resp.FullName)
+	})
+
+	t.Run("get unknown repository", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/repositories/2", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("list repositories", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/repositories?provider=GitHub&limit=5", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var repos []*ssr.Repository
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&repos))
+		assert.Equal(t, 1, len(repos))
+	})
+
+	t.Run("update repository", func(t *testing.T) {
+		body, err := json.Marshal(upd)
+		require.NoError(t, err)
+
+		rr := serve(s, httptest.NewRequest(http.MethodPut, "/repositories/1", bytes.NewBuffer(body)))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("delete repository", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodDelete, "/repositories/1", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	repoService.AssertExpectations(t)
+}
+
+func serve(s *Server, req *http.Request) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	s.router.ServeHTTP(rr, req)
+	return rr
+}
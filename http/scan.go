@@ -2,8 +2,11 @@ package http
 
 import (
 	"encoding/json"
+	"fmt"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -17,12 +20,31 @@ const (
 )
 
 func (s *Server) CreateScanHandler(w http.ResponseWriter, r *http.Request) error {
-	var scan ssr.Scan
-	if err := json.NewDecoder(r.Body).Decode(&scan); err != nil {
-		return NewError(err, http.StatusBadRequest, "Bad request: invalid JSON")
+	scan, err := decodeScan(r)
+	if err != nil {
+		return err
+	}
+	repoID, err := strconv.ParseUint(mux.Vars(r)["repoID"], 10, 64)
+	if err != nil {
+		return NewError(err, http.StatusBadRequest, "Bad request: invalid repository ID")
+	}
+	// The scan is created in the repository of the path, the body may only repeat it.
+	if scan.RepositoryID != 0 && scan.RepositoryID != repoID {
+		var v ssr.ValidationError
+		v.Add("repository_id", "does not match the repository of the path")
+		return &v
+	}
+	scan.RepositoryID = repoID
+	if err := scan.Validate(); err != nil {
+		return err
 	}
 
-	scanResult, err := s.ScanService.CreateScan(&scan)
+	scanResult, err := s.ScanService.CreateScan(r.Context(), scan)
+	if errors.Is(err, ssr.ErrRepositoryNotFound) {
+		var v ssr.ValidationError
+		v.Add("repository_id", "does not exist")
+		return &v
+	}
 	if err != nil {
 		return err
 	}
@@ -46,11 +68,15 @@ func (s *Server) GetScanHandler(w http.ResponseWriter, r *http.Request) error {
 		return NewError(err, http.StatusBadRequest, "Bad request: invalid scan ID")
 	}
 
-	scan, err := s.ScanService.GetScan(id)
+	scan, err := s.ScanService.GetScan(r.Context(), id)
 	if err != nil {
 		return err
 	}
 
+	if acceptsSARIF(r) {
+		return writeSARIF(w, scan)
+	}
+
 	response, err := json.Marshal(scan)
 	if err != nil {
 		return errors.Wrapf(err, "failed to marshal scan result")
@@ -70,12 +96,15 @@ func (s *Server) UpdateScanHandler(w http.ResponseWriter, r *http.Request) error
 		return NewError(err, http.StatusBadRequest, "Bad request: invalid scan ID")
 	}
 
-	var scan ssr.Scan
-	if err := json.NewDecoder(r.Body).Decode(&scan); err != nil {
-		return NewError(err, http.StatusBadRequest, "Bad request: invalid findings")
+	scan, err := decodeScan(r)
+	if err != nil {
+		return err
+	}
+	if err := scan.ValidateUpdate(); err != nil {
+		return err
 	}
 
-	scanResult, err := s.ScanService.UpdateScan(scanID, scan.Status, scan.Findings)
+	scanResult, err := s.ScanService.UpdateScan(r.Context(), scanID, scan.Status, scan.Findings)
 	if err != nil {
 		return err
 	}
@@ -93,13 +122,43 @@ func (s *Server) UpdateScanHandler(w http.ResponseWriter, r *http.Request) error
 	return nil
 }
 
+// GetScanSARIFHandler renders the findings of a scan as a SARIF log.
+func (s *Server) GetScanSARIFHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := uuid.Parse(mux.Vars(r)["scanID"])
+	if err != nil {
+		return NewError(err, http.StatusBadRequest, "Bad request: invalid scan ID")
+	}
+
+	scan, err := s.ScanService.GetScan(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return writeSARIF(w, scan)
+}
+
+// RetryScanHandler puts a failed scan back in the queue.
+func (s *Server) RetryScanHandler(w http.ResponseWriter, r *http.Request) error {
+	scanID, err := uuid.Parse(mux.Vars(r)["scanID"])
+	if err != nil {
+		return NewError(err, http.StatusBadRequest, "Bad request: invalid scan ID")
+	}
+
+	scan, err := s.ScanService.UpdateScan(r.Context(), scanID, ssr.Queued, nil)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, scan)
+}
+
 func (s *Server) DeleteScanHandler(w http.ResponseWriter, r *http.Request) error {
 	scanID, err := uuid.Parse(mux.Vars(r)["scanID"])
 	if err != nil {
 		return NewError(err, http.StatusBadRequest, "Bad request: invalid scan ID")
 	}
 
-	if err = s.ScanService.DeleteScan(scanID); err != nil {
+	if err = s.ScanService.DeleteScan(r.Context(), scanID); err != nil {
 		return err
 	}
 
@@ -119,7 +178,7 @@ func (s *Server) ListScansHandler(w http.ResponseWriter, r *http.Request) error
 		return NewError(err, http.StatusBadRequest, "Bad request: invalid page parameter")
 	}
 
-	scans, err := s.ScanService.ListScans(page, limit)
+	scans, err := s.ScanService.ListScans(r.Context(), page, limit)
 	if err != nil {
 		return err
 	}
@@ -136,3 +195,60 @@ func (s *Server) ListScansHandler(w http.ResponseWriter, r *http.Request) error
 
 	return nil
 }
+
+// decodeScan reads a scan from the request body.
+// The body is a scan in JSON unless the format query parameter names an importer,
+// in which case it is the report of that tool. A body sent as application/sarif+json is a SARIF log.
+func decodeScan(r *http.Request) (*ssr.Scan, error) {
+	format := r.URL.Query().Get("format")
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); format == "" && mediaType == ssr.SARIFContentType {
+		format = "sarif"
+	}
+
+	switch format {
+	case "":
+		var scan ssr.Scan
+		if err := json.NewDecoder(r.Body).Decode(&scan); err != nil {
+			return nil, jsonError(err)
+		}
+		return &scan, nil
+	case "sarif":
+		log, err := ssr.DecodeSARIF(r.Body)
+		if err != nil {
+			return nil, NewError(err, http.StatusBadRequest, "Bad request: invalid SARIF log")
+		}
+		return &ssr.Scan{
+			Status:   log.Status(),
+			Findings: log.Findings(),
+		}, nil
+	default:
+		importer, ok := ssr.LookupImporter(format)
+		if !ok {
+			return nil, NewError(nil, http.StatusBadRequest, fmt.Sprintf("Bad request: unsupported format %q, expected one of %s", format, strings.Join(ssr.ImportFormats(), ", ")))
+		}
+		findings, err := importer.Import(r.Body)
+		if err != nil {
+			return nil, NewError(err, http.StatusBadRequest, fmt.Sprintf("Bad request: invalid %s report", format))
+		}
+		return &ssr.Scan{
+			Status:   ssr.Success,
+			Findings: findings,
+		}, nil
+	}
+}
+
+// acceptsSARIF reports whether the client asked for a SARIF log rather than JSON.
+func acceptsSARIF(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && mediaType == ssr.SARIFContentType {
+			return true
+		}
+	}
+	return false
+}
+
+func writeSARIF(w http.ResponseWriter, scan *ssr.Scan) error {
+	w.Header().Set("Content-Type", ssr.SARIFContentType)
+	return writeJSON(w, ssr.NewSARIFLog(scan))
+}
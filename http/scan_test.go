@@ -2,14 +2,17 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/quantonganh/ssr"
@@ -42,11 +45,15 @@ func TestScanHandler(t *testing.T) {
 	}
 
 	scanService := new(mocks.ScanService)
-	scanService.On("CreateScan", scan).Return(scan, nil)
-	scanService.On("GetScan", scanID).Return(scan, nil)
-	scanService.On("UpdateScan", scanID, scan.Status, ssr.Findings{finding}).Return(scan, nil)
-	scanService.On("DeleteScan", scanID).Return(nil)
-	scanService.On("ListScans", 1, 1).Return([]*ssr.Scan{scan}, nil)
+	scanService.On("CreateScan", mock.Anything, scan).Return(scan, nil)
+	scanService.On("GetScan", mock.Anything, scanID).Return(scan, nil)
+	scanService.On("UpdateScan", mock.Anything, scanID, scan.Status, ssr.Findings{finding}).Return(scan, nil)
+	scanService.On("DeleteScan", mock.Anything, scanID).Return(nil)
+	scanService.On("ListScans", mock.Anything, 1, 1).Return([]*ssr.Scan{scan}, nil)
+
+	failedScanID := uuid.New()
+	scanService.On("UpdateScan", mock.Anything, failedScanID, ssr.Queued, ssr.Findings(nil)).Return(&ssr.Scan{ID: failedScanID, Status: ssr.Queued}, nil)
+	scanService.On("UpdateScan", mock.Anything, scanID, ssr.Queued, ssr.Findings(nil)).Return(nil, &ssr.TransitionError{From: ssr.Success, To: ssr.Queued})
 
 	t.Run("create scan", func(t *testing.T) {
 		testCreateScanHandler(t, scan, scanService)
@@ -67,6 +74,14 @@ func TestScanHandler(t *testing.T) {
 	t.Run("list scans", func(t *testing.T) {
 		testListScansHandler(t, scanService)
 	})
+
+	t.Run("retry failed scan", func(t *testing.T) {
+		testRetryScanHandler(t, failedScanID, scanService, http.StatusOK)
+	})
+
+	t.Run("retry successful scan", func(t *testing.T) {
+		testRetryScanHandler(t, scanID, scanService, http.StatusConflict)
+	})
 }
 
 func testCreateScanHandler(t *testing.T, scan *ssr.Scan, scanService ssr.ScanService) {
@@ -128,3 +143,218 @@ func testListScansHandler(t *testing.T, scanService ssr.ScanService) {
 	assert.Equal(t, uint64(1), scans[0].RepositoryID)
 	assert.Equal(t, ssr.Success, scans[0].Status)
 }
+
+func testRetryScanHandler(t *testing.T, scanID uuid.UUID, scanService ssr.ScanService, status int) {
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/scans/%s/retry", scanID), nil)
+	rr := httptest.NewRecorder()
+	s := NewServer(nil, scanService)
+	s.router.ServeHTTP(rr, req)
+
+	assert.Equal(t, status, rr.Code)
+}
+
+func TestCreateScanFromSARIF(t *testing.T) {
+	body, err := ioutil.ReadFile("../testdata/gosec.sarif")
+	require.NoError(t, err)
+
+	scanService := new(mocks.ScanService)
+	scanService.On("CreateScan", mock.Anything, mock.MatchedBy(func(s *ssr.Scan) bool {
+		return s.RepositoryID == 1 && s.Status == ssr.Success && len(s.Findings) == 2 && s.Findings[0].Tool == "gosec"
+	})).Return(func(_ context.Context, s *ssr.Scan) *ssr.Scan {
+		return s
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/scans/1", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", ssr.SARIFContentType)
+	rr := httptest.NewRecorder()
+	s := NewServer(nil, scanService)
+	s.router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp ssr.Scan
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Equal(t, "G402", resp.Findings[0].RuleID)
+	assert.Equal(t, int64(31), resp.Findings[0].Location.Positions.End.Column)
+
+	req = httptest.NewRequest(http.MethodPost, "/scans/1", bytes.NewBufferString(`{"version": "2.1.0"`))
+	req.Header.Set("Content-Type", ssr.SARIFContentType)
+	rr = httptest.NewRecorder()
+	s.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestCreateScanWithInvalidFinding(t *testing.T) {
+	s := NewServer(nil, new(mocks.ScanService))
+
+	for name, tc := range map[string]struct {
+		method  string
+		url     string
+		body    string
+		message string
+	}{
+		"severity":           {http.MethodPost, "/scans/1", `{"findings": [{"type": "sast", "metadata": {"severity": "urgent"}}]}`, `Bad request: invalid severity "urgent"`},
+		"type":               {http.MethodPost, "/scans/1", `{"findings": [{"type": "dast", "metadata": {"severity": "high"}}]}`, `Bad request: invalid finding type "dast"`},
+		"severity on update": {http.MethodPut, "/scans/" + uuid.New().String(), `{"status": 2, "findings": [{"type": "sast", "metadata": {"severity": "urgent"}}]}`, `Bad request: invalid severity "urgent"`},
+		"type on update":     {http.MethodPut, "/scans/" + uuid.New().String(), `{"status": 2, "findings": [{"type": "dast"}]}`, `Bad request: invalid finding type "dast"`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := serve(s, httptest.NewRequest(tc.method, tc.url, bytes.NewBufferString(tc.body)))
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+
+			var resp Problem
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, tc.message, resp.Detail)
+		})
+	}
+}
+
+func TestCreateScanValidation(t *testing.T) {
+	scanService := new(mocks.ScanService)
+	scanService.On("CreateScan", mock.Anything, mock.MatchedBy(func(s *ssr.Scan) bool {
+		return s.RepositoryID == 2
+	})).Return(nil, ssr.ErrRepositoryNotFound)
+	s := NewServer(nil, scanService)
+
+	for name, tc := range map[string]struct {
+		url    string
+		body   string
+		fields []ssr.FieldError
+	}{
+		"invalid fields": {
+			url:  "/scans/0",
+			body: `{"findings": [{"type": "sast", "location": {"positions": {"begin": {"line": -1}}}}]}`,
+			fields: []ssr.FieldError{
+				{Field: "repository_id", Message: "is required"},
+				{Field: "findings[0].rule_id", Message: "is required"},
+				{Field: "findings[0].location.positions.begin.line", Message: "must not be negative"},
+			},
+		},
+		"unknown repository": {
+			url:    "/scans/2",
+			body:   `{"status": 0}`,
+			fields: []ssr.FieldError{{Field: "repository_id", Message: "does not exist"}},
+		},
+		"repository other than the path": {
+			url:    "/scans/1",
+			body:   `{"repository_id": 2, "status": 0}`,
+			fields: []ssr.FieldError{{Field: "repository_id", Message: "does not match the repository of the path"}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := serve(s, httptest.NewRequest(http.MethodPost, tc.url, bytes.NewBufferString(tc.body)))
+			require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+			var resp Problem
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, tc.fields, resp.Errors)
+		})
+	}
+}
+
+func TestUpdateScanValidation(t *testing.T) {
+	// The scan service is not called: the mock fails the test if it is.
+	s := NewServer(nil, new(mocks.ScanService))
+
+	for name, tc := range map[string]struct {
+		body   string
+		fields []ssr.FieldError
+	}{
+		"invalid finding": {
+			body:   `{"status": 2, "findings": [{"type": "sast"}]}`,
+			fields: []ssr.FieldError{{Field: "findings[0].rule_id", Message: "is required"}},
+		},
+		"unknown status": {
+			body:   `{"status": 9}`,
+			fields: []ssr.FieldError{{Field: "status", Message: "must be one of 0 (Queued) to 3 (Failure)"}},
+		},
+		"negative status": {
+			body:   `{"status": -1}`,
+			fields: []ssr.FieldError{{Field: "status", Message: "must be one of 0 (Queued) to 3 (Failure)"}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := serve(s, httptest.NewRequest(http.MethodPut, "/scans/"+uuid.New().String(), bytes.NewBufferString(tc.body)))
+			require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+			var resp Problem
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, tc.fields, resp.Errors)
+		})
+	}
+}
+
+func TestGetScanAsSARIF(t *testing.T) {
+	scanID := uuid.New()
+	scan := &ssr.Scan{
+		ID:           scanID,
+		Status:       ssr.Success,
+		RepositoryID: 1,
+		Findings: ssr.Findings{
+			{
+				Type:   "sast",
+				RuleID: "G402",
+				Location: ssr.Location{
+					Path: "scan.go",
+					Positions: ssr.Positions{
+						Begin: ssr.Begin{Line: 60},
+					},
+				},
+				Metadata: ssr.Metadata{
+					Description: "TLS InsecureSkipVerify set true.",
+					Severity:    "HIGH",
+				},
+			},
+		},
+	}
+
+	scanService := new(mocks.ScanService)
+	scanService.On("GetScan", mock.Anything, scanID).Return(scan, nil)
+	s := NewServer(nil, scanService)
+
+	for name, req := range map[string]*http.Request{
+		"sarif route":         httptest.NewRequest(http.MethodGet, fmt.Sprintf("/scans/%s/sarif", scanID), nil),
+		"content negotiation": httptest.NewRequest(http.MethodGet, fmt.Sprintf("/scans/%s", scanID), nil),
+	} {
+		t.Run(name, func(t *testing.T) {
+			req.Header.Set("Accept", "application/json;q=0.5, application/sarif+json")
+			rr := httptest.NewRecorder()
+			s.router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, ssr.SARIFContentType, rr.Header().Get("Content-Type"))
+
+			log, err := ssr.DecodeSARIF(rr.Body)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(log.Runs))
+			assert.Equal(t, "G402", log.Runs[0].Tool.Driver.Rules[0].ID)
+			assert.Equal(t, "error", log.Runs[0].Results[0].Level)
+			assert.Equal(t, "scan.go", log.Runs[0].Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+		})
+	}
+}
+
+func TestCreateScanFromToolReport(t *testing.T) {
+	body, err := ioutil.ReadFile("../testdata/importers/gitleaks.json")
+	require.NoError(t, err)
+
+	scanService := new(mocks.ScanService)
+	scanService.On("CreateScan", mock.Anything, mock.MatchedBy(func(s *ssr.Scan) bool {
+		return s.RepositoryID == 1 && s.Status == ssr.Success && len(s.Findings) == 1 && s.Findings[0].Type == "secret"
+	})).Return(func(_ context.Context, s *ssr.Scan) *ssr.Scan {
+		return s
+	}, nil)
+	s := NewServer(nil, scanService)
+
+	rr := httptest.NewRecorder()
+	s.router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/scans/1?format=gitleaks", bytes.NewBuffer(body)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	s.router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/scans/1?format=bandit", bytes.NewBuffer(body)))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = httptest.NewRecorder()
+	s.router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/scans/1?format=trivy", bytes.NewBuffer(body)))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	scanService.AssertNumberOfCalls(t, "CreateScan", 1)
+}
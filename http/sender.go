@@ -0,0 +1,138 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/quantonganh/ssr"
+)
+
+// Headers of a delivery.
+const (
+	HeaderEvent     = "X-SSR-Event"
+	HeaderEventID   = "X-SSR-Event-ID"
+	HeaderDelivery  = "X-SSR-Delivery"
+	HeaderSignature = "X-SSR-Signature-256"
+)
+
+const defaultSendTimeout = 10 * time.Second
+
+// WebhookSender posts deliveries to their webhook, signed with its secret.
+type WebhookSender struct {
+	client *http.Client
+}
+
+// NewWebhookSender returns a sender whose requests are aborted after timeout. Unless allowPrivate is set, it refuses
+// to connect to loopback, private and link-local addresses, so that a webhook cannot make the server post to itself
+// or to the network it runs in. Redirects are never followed: a 3xx is the status of the delivery.
+func NewWebhookSender(timeout time.Duration, allowPrivate bool) *WebhookSender {
+	if timeout <= 0 {
+		timeout = defaultSendTimeout
+	}
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if !allowPrivate {
+		dialer.Control = dialPublic
+	}
+	return &WebhookSender{
+		client: &http.Client{
+			// No proxy, the addresses that are checked are those of the receivers.
+			Transport: &http.Transport{
+				DialContext:           dialer.DialContext,
+				ForceAttemptHTTP2:     true,
+				MaxIdleConns:          100,
+				IdleConnTimeout:       90 * time.Second,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ExpectContinueTimeout: time.Second,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+			Timeout: timeout,
+		},
+	}
+}
+
+// dialPublic is the Control of the dialer of webhooks. It runs once the host is resolved, on the address that is
+// actually connected to, so a name that resolves to a private address is refused too.
+func dialPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return errors.Wrapf(err, "invalid address: %s", address)
+	}
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return errors.Errorf("refusing to connect to non-public address: %s", host)
+	}
+	return nil
+}
+
+// nonPublicNetworks are the ranges that are neither loopback, link-local nor multicast but are not reachable from
+// the internet either.
+var nonPublicNetworks = parseCIDRs(
+	"0.0.0.0/8",      // this network
+	"10.0.0.0/8",     // private
+	"100.64.0.0/10",  // carrier-grade NAT
+	"172.16.0.0/12",  // private
+	"192.168.0.0/16", // private
+	"fc00::/7",       // unique local
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// publicIP reports whether ip may be the address of a webhook.
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, n := range nonPublicNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *WebhookSender) Send(ctx context.Context, w *ssr.Webhook, d *ssr.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid URL of webhook: %d", w.ID)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ssr-webhook")
+	req.Header.Set(HeaderEvent, string(d.Event))
+	req.Header.Set(HeaderEventID, d.EventID.String())
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(d.ID, 10))
+	req.Header.Set(HeaderSignature, ssr.Sign(w.Secret, d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to deliver to webhook: %d", w.ID)
+	}
+	defer resp.Body.Close()
+	// Drain the body, so that the connection can be reused.
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	return resp.StatusCode, nil
+}
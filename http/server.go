@@ -22,12 +22,24 @@ const (
 type Server struct {
 	ln net.Listener
 	Addr string
+	// RequestTimeout is the deadline of the context of each request, zero meaning no deadline.
+	RequestTimeout time.Duration
 
 	server *http.Server
 	router *mux.Router
 
 	RepositoryService ssr.RepositoryService
 	ScanService ssr.ScanService
+	FindingService ssr.FindingService
+	SuppressionService ssr.SuppressionService
+	PolicyService ssr.PolicyService
+	AccessService ssr.AccessService
+	WebhookService ssr.WebhookService
+	// APIKeyService and JWTAuthenticator authenticate requests, see authorize. Without them the API is open.
+	APIKeyService    ssr.APIKeyService
+	JWTAuthenticator *JWTAuthenticator
+	// AllowPrivateWebhooks lets webhooks be created for loopback, private and link-local addresses.
+	AllowPrivateWebhooks bool
 }
 
 func NewServer(repositoryService ssr.RepositoryService, scanService ssr.ScanService) *Server {
@@ -56,18 +68,81 @@ func NewServer(repositoryService ssr.RepositoryService, scanService ssr.ScanServ
 	s.router.Use(hlog.UserAgentHandler("user_agent"))
 	s.router.Use(hlog.RefererHandler("referer"))
 	s.router.Use(hlog.RequestIDHandler("req_id", "Request-Id"))
+	s.router.Use(s.timeoutHandler)
 
 	s.server.Handler = http.HandlerFunc(s.serveHTTP)
 
-	s.router.Handle("/scans/{repoID}", appHandler(s.CreateScanHandler)).Methods(http.MethodPost)
-	s.router.Handle("/scans/{scanID}", appHandler(s.GetScanHandler)).Methods(http.MethodGet)
-	s.router.Handle("/scans/{scanID}", appHandler(s.UpdateScanHandler)).Methods(http.MethodPut)
-	s.router.Handle("/scans/{scanID}", appHandler(s.DeleteScanHandler)).Methods(http.MethodDelete)
-	s.router.Handle("/scans", appHandler(s.ListScansHandler)).Methods(http.MethodGet)
+	s.router.Handle("/scans/{repoID}", s.authorize(ssr.ScopeScansWrite, s.CreateScanHandler)).Methods(http.MethodPost)
+	s.router.Handle("/scans/{scanID}", s.authorize(ssr.ScopeScansRead, s.GetScanHandler)).Methods(http.MethodGet)
+	s.router.Handle("/scans/{scanID}", s.authorize(ssr.ScopeScansWrite, s.UpdateScanHandler)).Methods(http.MethodPut)
+	s.router.Handle("/scans/{scanID}", s.authorize(ssr.ScopeScansWrite, s.DeleteScanHandler)).Methods(http.MethodDelete)
+	s.router.Handle("/scans/{scanID}/sarif", s.authorize(ssr.ScopeScansRead, s.GetScanSARIFHandler)).Methods(http.MethodGet)
+	s.router.Handle("/scans/{scanID}/diff", s.authorize(ssr.ScopeScansRead, s.GetScanDiffHandler)).Methods(http.MethodGet)
+	s.router.Handle("/scans/{scanID}/findings", s.authorize(ssr.ScopeScansRead, s.ListScanFindingsHandler)).Methods(http.MethodGet)
+	s.router.Handle("/scans/{scanID}/gate", s.authorize(ssr.ScopeScansRead, s.GetScanGateHandler)).Methods(http.MethodGet)
+	s.router.Handle("/scans/{scanID}/retry", s.authorize(ssr.ScopeScansWrite, s.RetryScanHandler)).Methods(http.MethodPost)
+	s.router.Handle("/scans", s.authorize(ssr.ScopeScansRead, s.ListScansHandler)).Methods(http.MethodGet)
+
+	s.router.Handle("/repositories", s.authorize(ssr.ScopeRepositoriesWrite, s.CreateRepositoryHandler)).Methods(http.MethodPost)
+	s.router.Handle("/repositories", s.authorize(ssr.ScopeRepositoriesRead, s.ListRepositoriesHandler)).Methods(http.MethodGet)
+	s.router.Handle("/repositories/{repoID}", s.authorize(ssr.ScopeRepositoriesRead, s.GetRepositoryHandler)).Methods(http.MethodGet)
+	s.router.Handle("/repositories/{repoID}", s.authorize(ssr.ScopeRepositoriesWrite, s.UpdateRepositoryHandler)).Methods(http.MethodPut)
+	s.router.Handle("/repositories/{repoID}", s.authorize(ssr.ScopeRepositoriesAdmin, s.DeleteRepositoryHandler)).Methods(http.MethodDelete)
+	s.router.Handle("/repositories/{repoID}/findings", s.authorize(ssr.ScopeRepositoriesRead, s.ListRepositoryFindingsHandler)).Methods(http.MethodGet)
+	s.router.Handle("/repositories/{repoID}/findings/{fingerprint}/triage", s.authorize(ssr.ScopeRepositoriesWrite, s.TriageFindingHandler)).Methods(http.MethodPut)
+	s.router.Handle("/repositories/{repoID}/suppressions", s.authorize(ssr.ScopeRepositoriesWrite, s.CreateSuppressionHandler)).Methods(http.MethodPost)
+	s.router.Handle("/repositories/{repoID}/suppressions", s.authorize(ssr.ScopeRepositoriesRead, s.ListSuppressionsHandler)).Methods(http.MethodGet)
+	s.router.Handle("/repositories/{repoID}/suppressions/{suppressionID}", s.authorize(ssr.ScopeRepositoriesRead, s.GetSuppressionHandler)).Methods(http.MethodGet)
+	s.router.Handle("/repositories/{repoID}/suppressions/{suppressionID}", s.authorize(ssr.ScopeRepositoriesWrite, s.DeleteSuppressionHandler)).Methods(http.MethodDelete)
+	s.router.Handle("/repositories/{repoID}/policies", s.authorize(ssr.ScopeRepositoriesWrite, s.CreatePolicyHandler)).Methods(http.MethodPost)
+	s.router.Handle("/repositories/{repoID}/policies", s.authorize(ssr.ScopeRepositoriesRead, s.ListPoliciesHandler)).Methods(http.MethodGet)
+
+	s.router.Handle("/policies", s.authorize(ssr.ScopeRepositoriesAdmin, s.CreatePolicyHandler)).Methods(http.MethodPost)
+	s.router.Handle("/policies", s.authorize(ssr.ScopeRepositoriesRead, s.ListPoliciesHandler)).Methods(http.MethodGet)
+	s.router.Handle("/policies/{policyID}", s.authorize(ssr.ScopeRepositoriesRead, s.GetPolicyHandler)).Methods(http.MethodGet)
+	s.router.Handle("/policies/{policyID}", s.authorize(ssr.ScopeRepositoriesAdmin, s.DeletePolicyHandler)).Methods(http.MethodDelete)
+
+	s.router.Handle("/apikeys", s.authorize(ssr.ScopeKeysAdmin, s.CreateAPIKeyHandler)).Methods(http.MethodPost)
+	s.router.Handle("/apikeys", s.authorize(ssr.ScopeKeysAdmin, s.ListAPIKeysHandler)).Methods(http.MethodGet)
+	s.router.Handle("/apikeys/{keyID}", s.authorize(ssr.ScopeKeysAdmin, s.RevokeAPIKeyHandler)).Methods(http.MethodDelete)
+
+	s.router.Handle("/users", s.authorize(ssr.ScopeUsersAdmin, s.ListUsersHandler)).Methods(http.MethodGet)
+	s.router.Handle("/users/{userID}", s.authorize(ssr.ScopeUsersAdmin, s.SaveUserHandler)).Methods(http.MethodPut)
+	s.router.Handle("/teams", s.authorize(ssr.ScopeUsersAdmin, s.CreateTeamHandler)).Methods(http.MethodPost)
+	s.router.Handle("/teams", s.authorize(ssr.ScopeUsersAdmin, s.ListTeamsHandler)).Methods(http.MethodGet)
+	s.router.Handle("/teams/{teamID}", s.authorize(ssr.ScopeUsersAdmin, s.DeleteTeamHandler)).Methods(http.MethodDelete)
+	s.router.Handle("/teams/{teamID}/members/{userID}", s.authorize(ssr.ScopeUsersAdmin, s.AddTeamMemberHandler)).Methods(http.MethodPut)
+	s.router.Handle("/teams/{teamID}/members/{userID}", s.authorize(ssr.ScopeUsersAdmin, s.RemoveTeamMemberHandler)).Methods(http.MethodDelete)
+	s.router.Handle("/repositories/{repoID}/members", s.authorize(ssr.ScopeRepositoriesRead, s.ListRepositoryMembersHandler)).Methods(http.MethodGet)
+	s.router.Handle("/repositories/{repoID}/members/{teamID}", s.authorize(ssr.ScopeRepositoriesAdmin, s.SetRepositoryMemberHandler)).Methods(http.MethodPut)
+	s.router.Handle("/repositories/{repoID}/members/{teamID}", s.authorize(ssr.ScopeRepositoriesAdmin, s.RemoveRepositoryMemberHandler)).Methods(http.MethodDelete)
+
+	s.router.Handle("/repositories/{repoID}/webhooks", s.authorize(ssr.ScopeWebhooksAdmin, s.CreateWebhookHandler)).Methods(http.MethodPost)
+	s.router.Handle("/repositories/{repoID}/webhooks", s.authorize(ssr.ScopeWebhooksAdmin, s.ListWebhooksHandler)).Methods(http.MethodGet)
+	s.router.Handle("/webhooks", s.authorize(ssr.ScopeWebhooksAdmin, s.CreateWebhookHandler)).Methods(http.MethodPost)
+	s.router.Handle("/webhooks", s.authorize(ssr.ScopeWebhooksAdmin, s.ListWebhooksHandler)).Methods(http.MethodGet)
+	s.router.Handle("/webhooks/{webhookID}", s.authorize(ssr.ScopeWebhooksAdmin, s.GetWebhookHandler)).Methods(http.MethodGet)
+	s.router.Handle("/webhooks/{webhookID}", s.authorize(ssr.ScopeWebhooksAdmin, s.DeleteWebhookHandler)).Methods(http.MethodDelete)
+	s.router.Handle("/webhooks/{webhookID}/deliveries", s.authorize(ssr.ScopeWebhooksAdmin, s.ListWebhookDeliveriesHandler)).Methods(http.MethodGet)
+	s.router.Handle("/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver", s.authorize(ssr.ScopeWebhooksAdmin, s.RedeliverWebhookHandler)).Methods(http.MethodPost)
 
 	return s
 }
 
+// timeoutHandler cancels the context of a request once RequestTimeout has elapsed,
+// so that the queries it runs are aborted.
+func (s *Server) timeoutHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.RequestTimeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), s.RequestTimeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
 	s.router.ServeHTTP(w, r)
 }
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/quantonganh/ssr"
+)
+
+// CreateSuppressionHandler adds a suppression to a repository.
+// It applies to the findings stored from then on, the findings already stored are left as they are.
+func (s *Server) CreateSuppressionHandler(w http.ResponseWriter, r *http.Request) error {
+	repoID, err := parseRepoID(r)
+	if err != nil {
+		return err
+	}
+
+	var suppression ssr.Suppression
+	if err := json.NewDecoder(r.Body).Decode(&suppression); err != nil {
+		return NewError(err, http.StatusBadRequest, "Bad request: invalid JSON")
+	}
+	if suppression.RuleID == "" && suppression.PathPrefix == "" {
+		return NewError(nil, http.StatusBadRequest, "Bad request: rule_id or path_prefix is required")
+	}
+	if suppression.CreatedBy, err = actor(r.Context(), "created_by", suppression.CreatedBy); err != nil {
+		return err
+	}
+	if suppression.ExpiresAt != nil && !suppression.ExpiresAt.After(time.Now()) {
+		return NewError(nil, http.StatusBadRequest, "Bad request: expires_at must be in the future")
+	}
+	suppression.ID = 0
+	suppression.RepositoryID = repoID
+
+	if err := s.SuppressionService.Create(r.Context(), &suppression); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	return writeJSON(w, suppression)
+}
+
+func (s *Server) ListSuppressionsHandler(w http.ResponseWriter, r *http.Request) error {
+	repoID, err := parseRepoID(r)
+	if err != nil {
+		return err
+	}
+
+	suppressions, err := s.SuppressionService.List(r.Context(), repoID)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, suppressions)
+}
+
+func (s *Server) GetSuppressionHandler(w http.ResponseWriter, r *http.Request) error {
+	suppression, err := s.repositorySuppression(r)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, suppression)
+}
+
+// DeleteSuppressionHandler removes a suppression, which re-opens the findings it suppressed.
+func (s *Server) DeleteSuppressionHandler(w http.ResponseWriter, r *http.Request) error {
+	suppression, err := s.repositorySuppression(r)
+	if err != nil {
+		return err
+	}
+
+	if err := s.SuppressionService.Delete(r.Context(), suppression.ID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// repositorySuppression returns the suppression of the request, provided that it belongs to the repository of the request.
+func (s *Server) repositorySuppression(r *http.Request) (*ssr.Suppression, error) {
+	repoID, err := parseRepoID(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["suppressionID"], 10, 64)
+	if err != nil {
+		return nil, NewError(err, http.StatusBadRequest, "Bad request: invalid suppression ID")
+	}
+
+	suppression, err := s.SuppressionService.Get(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	if suppression.RepositoryID != repoID {
+		return nil, ssr.ErrSuppressionNotFound
+	}
+	return suppression, nil
+}
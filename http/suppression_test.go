@@ -0,0 +1,93 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+	"github.com/quantonganh/ssr/mocks"
+)
+
+func TestSuppressionHandler(t *testing.T) {
+	expiresAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	suppression := &ssr.Suppression{
+		ID:           3,
+		RepositoryID: 1,
+		RuleID:       "G404",
+		PathPrefix:   "testdata/",
+		Reason:       "fixtures",
+		CreatedBy:    "alice@example.com",
+		ExpiresAt:    &expiresAt,
+	}
+
+	suppressionService := new(mocks.SuppressionService)
+	suppressionService.On("Create", mock.Anything, mock.MatchedBy(func(s *ssr.Suppression) bool {
+		return s.RepositoryID == 1 && s.RuleID == "G404" && s.ExpiresAt.Equal(expiresAt)
+	})).Return(nil)
+	suppressionService.On("List", mock.Anything, uint64(1)).Return([]*ssr.Suppression{suppression}, nil)
+	suppressionService.On("Get", mock.Anything, uint64(3)).Return(suppression, nil)
+	suppressionService.On("Get", mock.Anything, uint64(4)).Return(nil, ssr.ErrSuppressionNotFound)
+	suppressionService.On("Delete", mock.Anything, uint64(3)).Return(nil)
+
+	s := NewServer(nil, nil)
+	s.SuppressionService = suppressionService
+
+	t.Run("create suppression", func(t *testing.T) {
+		body, err := json.Marshal(suppression)
+		require.NoError(t, err)
+
+		rr := serve(s, httptest.NewRequest(http.MethodPost, "/repositories/1/suppressions", bytes.NewBuffer(body)))
+		assert.Equal(t, http.StatusCreated, rr.Code)
+	})
+
+	for name, body := range map[string]string{
+		"create suppression without scope":     `{"created_by": "alice@example.com"}`,
+		"create suppression without creator":   `{"rule_id": "G404"}`,
+		"create suppression that expired":      `{"rule_id": "G404", "created_by": "alice@example.com", "expires_at": "2020-01-01T00:00:00Z"}`,
+		"create suppression with invalid JSON": `{"rule_id": `,
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := serve(s, httptest.NewRequest(http.MethodPost, "/repositories/1/suppressions", bytes.NewBufferString(body)))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+
+	t.Run("list suppressions", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/repositories/1/suppressions", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var suppressions []*ssr.Suppression
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&suppressions))
+		assert.Len(t, suppressions, 1)
+	})
+
+	t.Run("get suppression", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/repositories/1/suppressions/3", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("get suppression of another repository", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/repositories/2/suppressions/3", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("get unknown suppression", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/repositories/1/suppressions/4", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("delete suppression", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodDelete, "/repositories/1/suppressions/3", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	suppressionService.AssertExpectations(t)
+}
@@ -0,0 +1,149 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/quantonganh/ssr"
+	"github.com/quantonganh/ssr/mocks"
+	"github.com/quantonganh/ssr/postgresql"
+)
+
+const (
+	sqlCountOrganizationRepository = `SELECT count(*) FROM "repository" WHERE id = $1 AND organization_id = $2`
+	sqlSelectScan                  = `SELECT * FROM "scan" WHERE id = $1 ORDER BY "scan"."id" LIMIT 1`
+	sqlPreloadFindings             = `SELECT * FROM "finding" WHERE "finding"."scan_id" = $1 ORDER BY id`
+	sqlListRepositories            = `SELECT * FROM "repository" WHERE organization_id = $1 ORDER BY id LIMIT 10`
+	sqlSelectPolicy                = `SELECT * FROM "policy" WHERE "policy"."id" = $1 ORDER BY "policy"."id" LIMIT 1`
+)
+
+// TestCrossTenant runs requests of organization 2 against the data of organization 1, through the services
+// of the postgresql package, to check that they are rejected before any data is read or written.
+func TestCrossTenant(t *testing.T) {
+	sqlDB, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	apiKeyService := new(mocks.APIKeyService)
+	apiKeyService.On("Authenticate", mock.Anything, "ssr_other").Return(&ssr.APIKey{ID: 4, OrganizationID: 2, Name: "other", Scopes: ssr.Scopes, Role: ssr.RoleAdmin}, nil)
+
+	s := NewServer(postgresql.NewRepositoryService(db), postgresql.NewScanService(db))
+	s.FindingService = postgresql.NewFindingService(db)
+	s.SuppressionService = postgresql.NewSuppressionService(db)
+	s.PolicyService = postgresql.NewPolicyService(db)
+	s.AccessService = postgresql.NewAccessService(db)
+	s.WebhookService = postgresql.NewWebhookService(db)
+	s.APIKeyService = apiKeyService
+
+	request := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer ssr_other")
+		return serve(s, req)
+	}
+	// Repository 1 belongs to organization 1.
+	expectForeignRepository := func() {
+		sqlMock.ExpectQuery(sqlCountOrganizationRepository).
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	}
+
+	for name, tc := range map[string]struct {
+		method string
+		target string
+		body   string
+	}{
+		"get repository":          {http.MethodGet, "/repositories/1", ""},
+		"update repository":       {http.MethodPut, "/repositories/1", `{"description": "mine"}`},
+		"delete repository":       {http.MethodDelete, "/repositories/1", ""},
+		"list findings":           {http.MethodGet, "/repositories/1/findings", ""},
+		"list suppressions":       {http.MethodGet, "/repositories/1/suppressions", ""},
+		"create suppression":      {http.MethodPost, "/repositories/1/suppressions", `{"rule_id": "G402", "reason": "mine"}`},
+		"list policies":           {http.MethodGet, "/repositories/1/policies", ""},
+		"create policy":           {http.MethodPost, "/repositories/1/policies", `{"name": "mine"}`},
+		"list repository members": {http.MethodGet, "/repositories/1/members", ""},
+		"set repository member":   {http.MethodPut, "/repositories/1/members/1", `{"role": "admin"}`},
+		"list webhooks":           {http.MethodGet, "/repositories/1/webhooks", ""},
+		"create webhook":          {http.MethodPost, "/repositories/1/webhooks", `{"url": "https://evil.example.com", "events": ["finding.new"]}`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			expectForeignRepository()
+
+			rr := request(tc.method, tc.target, tc.body)
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+			require.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("triage finding", func(t *testing.T) {
+		sqlMock.ExpectBegin()
+		expectForeignRepository()
+		sqlMock.ExpectRollback()
+
+		rr := request(http.MethodPut, "/repositories/1/findings/abc/triage", `{"status": "false_positive"}`)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		require.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("create scan", func(t *testing.T) {
+		sqlMock.ExpectBegin()
+		expectForeignRepository()
+		sqlMock.ExpectRollback()
+
+		rr := request(http.MethodPost, "/scans/1", `{"findings": []}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+		require.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("get scan", func(t *testing.T) {
+		id := uuid.New()
+		sqlMock.ExpectQuery(sqlSelectScan).
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "repository_id"}).AddRow(id, 1))
+		sqlMock.ExpectQuery(sqlPreloadFindings).
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		expectForeignRepository()
+
+		rr := request(http.MethodGet, "/scans/"+id.String(), "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		require.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("list repositories", func(t *testing.T) {
+		sqlMock.ExpectQuery(sqlListRepositories).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "organization_id"}))
+
+		rr := request(http.MethodGet, "/repositories?page=1&limit=10", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[]`, rr.Body.String())
+		require.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("get global policy", func(t *testing.T) {
+		sqlMock.ExpectQuery(sqlSelectPolicy).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "organization_id", "name"}).AddRow(3, 1, "no high"))
+
+		rr := request(http.MethodGet, "/policies/3", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		require.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,178 @@
+package http
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/quantonganh/ssr"
+)
+
+// createdWebhook is the response to the creation of a webhook, the only one that holds its secret.
+type createdWebhook struct {
+	*ssr.Webhook
+	Secret string `json:"secret"`
+}
+
+// CreateWebhookHandler subscribes a URL to events. Under /repositories/{repoID} the webhook receives the events of
+// that repository, under /webhooks those of every repository.
+// A secret is generated when the request has none. URLs to loopback, private and link-local addresses are refused
+// unless AllowPrivateWebhooks is set; the sender checks the addresses that names resolve to when it connects.
+func (s *Server) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) error {
+	var req struct {
+		URL    string          `json:"url"`
+		Secret string          `json:"secret"`
+		Events []ssr.EventType `json:"events"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return jsonError(err)
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return NewError(err, http.StatusBadRequest, "Bad request: url must be an absolute http or https URL")
+	}
+	if !s.AllowPrivateWebhooks && !publicHost(u.Hostname()) {
+		return NewError(nil, http.StatusBadRequest, "Bad request: url must not be a loopback, private or link-local address")
+	}
+	if len(req.Events) == 0 {
+		return NewError(nil, http.StatusBadRequest, "Bad request: events are required")
+	}
+	if req.Secret == "" {
+		if req.Secret, err = ssr.NewWebhookSecret(); err != nil {
+			return err
+		}
+	}
+
+	webhook := &ssr.Webhook{
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: req.Events,
+	}
+	if _, ok := mux.Vars(r)["repoID"]; ok {
+		repoID, err := parseRepoID(r)
+		if err != nil {
+			return err
+		}
+		webhook.RepositoryID = &repoID
+	}
+
+	if err := s.WebhookService.Create(r.Context(), webhook); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	return writeJSON(w, createdWebhook{Webhook: webhook, Secret: webhook.Secret})
+}
+
+// publicHost reports whether host may be the host of a webhook. Names are checked when the sender resolves them.
+func publicHost(host string) bool {
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return publicIP(ip)
+	}
+	return true
+}
+
+// ListWebhooksHandler lists the webhooks of a repository, or the global webhooks under /webhooks.
+func (s *Server) ListWebhooksHandler(w http.ResponseWriter, r *http.Request) error {
+	var repoID uint64
+	if _, ok := mux.Vars(r)["repoID"]; ok {
+		var err error
+		if repoID, err = parseRepoID(r); err != nil {
+			return err
+		}
+	}
+
+	webhooks, err := s.WebhookService.List(r.Context(), repoID)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, webhooks)
+}
+
+func (s *Server) GetWebhookHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseWebhookID(r)
+	if err != nil {
+		return err
+	}
+
+	webhook, err := s.WebhookService.Get(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, webhook)
+}
+
+func (s *Server) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseWebhookID(r)
+	if err != nil {
+		return err
+	}
+
+	return s.WebhookService.Delete(r.Context(), id)
+}
+
+// ListWebhookDeliveriesHandler returns the log of a webhook: its deliveries, newest first, along with the outcome
+// of their last attempt.
+func (s *Server) ListWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseWebhookID(r)
+	if err != nil {
+		return err
+	}
+	page, limit := 1, defaultLimit
+	if v := r.FormValue("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			return NewError(err, http.StatusBadRequest, "Bad request: invalid limit parameter")
+		}
+	}
+	if v := r.FormValue("page"); v != "" {
+		if page, err = strconv.Atoi(v); err != nil || page <= 0 {
+			return NewError(err, http.StatusBadRequest, "Bad request: invalid page parameter")
+		}
+	}
+
+	deliveries, err := s.WebhookService.ListDeliveries(r.Context(), id, page, limit)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, deliveries)
+}
+
+// RedeliverWebhookHandler queues the event of a delivery again, e.g. once the receiver is fixed.
+// It is sent by the dispatcher, the response only holds the new delivery.
+func (s *Server) RedeliverWebhookHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseWebhookID(r)
+	if err != nil {
+		return err
+	}
+	deliveryID, err := strconv.ParseUint(mux.Vars(r)["deliveryID"], 10, 64)
+	if err != nil {
+		return NewError(err, http.StatusBadRequest, "Bad request: invalid delivery ID")
+	}
+
+	d, err := s.WebhookService.Redeliver(r.Context(), id, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	return writeJSON(w, d)
+}
+
+func parseWebhookID(r *http.Request) (uint64, error) {
+	id, err := strconv.ParseUint(mux.Vars(r)["webhookID"], 10, 64)
+	if err != nil {
+		return 0, NewError(err, http.StatusBadRequest, "Bad request: invalid webhook ID")
+	}
+	return id, nil
+}
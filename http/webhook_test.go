@@ -0,0 +1,214 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quantonganh/ssr"
+	"github.com/quantonganh/ssr/mocks"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	webhook := &ssr.Webhook{
+		ID:     2,
+		URL:    "https://ci.example.com/hook",
+		Secret: "s3cr3t",
+		Events: ssr.EventTypeList{ssr.EventScanSucceeded},
+	}
+
+	webhookService := new(mocks.WebhookService)
+	webhookService.On("Create", mock.Anything, mock.MatchedBy(func(w *ssr.Webhook) bool {
+		return w.RepositoryID != nil && *w.RepositoryID == 1 && w.Secret == "s3cr3t"
+	})).Return(nil)
+	webhookService.On("Create", mock.Anything, mock.MatchedBy(func(w *ssr.Webhook) bool {
+		return w.RepositoryID == nil && len(w.Secret) == 64
+	})).Return(nil)
+	webhookService.On("List", mock.Anything, uint64(1)).Return([]*ssr.Webhook{webhook}, nil)
+	webhookService.On("Get", mock.Anything, uint64(2)).Return(webhook, nil)
+	webhookService.On("Get", mock.Anything, uint64(3)).Return(nil, ssr.ErrWebhookNotFound)
+	webhookService.On("Delete", mock.Anything, uint64(2)).Return(nil)
+	webhookService.On("ListDeliveries", mock.Anything, uint64(2), 2, 5).Return([]*ssr.WebhookDelivery{
+		{ID: 7, WebhookID: 2, Event: ssr.EventScanSucceeded, Payload: ssr.Payload(`{"type":"scan.succeeded"}`), Status: ssr.DeliveryFailed, Attempts: 8, ResponseStatus: 500},
+	}, nil)
+	webhookService.On("Redeliver", mock.Anything, uint64(2), uint64(7)).Return(&ssr.WebhookDelivery{ID: 8, WebhookID: 2, Status: ssr.DeliveryPending}, nil)
+	webhookService.On("Redeliver", mock.Anything, uint64(2), uint64(9)).Return(nil, ssr.ErrWebhookDeliveryNotFound)
+
+	s := NewServer(nil, nil)
+	s.WebhookService = webhookService
+
+	t.Run("create repository webhook", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodPost, "/repositories/1/webhooks", bytes.NewBufferString(`{"url": "https://ci.example.com/hook", "secret": "s3cr3t", "events": ["scan.succeeded"]}`)))
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		var created map[string]interface{}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&created))
+		assert.Equal(t, "s3cr3t", created["secret"])
+	})
+
+	t.Run("create global webhook without secret", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewBufferString(`{"url": "https://chat.example.com/hook", "events": ["scan.failed", "finding.new"]}`)))
+		assert.Equal(t, http.StatusCreated, rr.Code)
+	})
+
+	for name, body := range map[string]string{
+		"create webhook without URL":        `{"events": ["scan.failed"]}`,
+		"create webhook with relative URL":  `{"url": "/hook", "events": ["scan.failed"]}`,
+		"create webhook with ftp URL":       `{"url": "ftp://example.com/hook", "events": ["scan.failed"]}`,
+		"create webhook without events":     `{"url": "https://ci.example.com/hook"}`,
+		"create webhook with unknown event": `{"url": "https://ci.example.com/hook", "events": ["scan.deleted"]}`,
+		"create webhook with invalid JSON":  `{"url": `,
+		"create webhook for localhost":      `{"url": "http://localhost:8080/hook", "events": ["scan.failed"]}`,
+		"create webhook for loopback":       `{"url": "http://127.0.0.1/hook", "events": ["scan.failed"]}`,
+		"create webhook for link-local":     `{"url": "http://169.254.169.254/latest/meta-data", "events": ["scan.failed"]}`,
+		"create webhook for private":        `{"url": "http://10.0.0.1/hook", "events": ["scan.failed"]}`,
+		"create webhook for unique local":   `{"url": "http://[fd00::1]/hook", "events": ["scan.failed"]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := serve(s, httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewBufferString(body)))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+
+	t.Run("list webhooks of repository", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/repositories/1/webhooks", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "s3cr3t")
+	})
+
+	t.Run("get webhook", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/webhooks/2", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "s3cr3t")
+	})
+
+	t.Run("get unknown webhook", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/webhooks/3", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("delete webhook", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodDelete, "/webhooks/2", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("list deliveries", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/webhooks/2/deliveries?page=2&limit=5", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var deliveries []map[string]interface{}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&deliveries))
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, map[string]interface{}{"type": "scan.succeeded"}, deliveries[0]["payload"])
+		assert.Equal(t, float64(500), deliveries[0]["response_status"])
+	})
+
+	t.Run("list deliveries with invalid limit", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodGet, "/webhooks/2/deliveries?limit=-1", nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("redeliver", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodPost, "/webhooks/2/deliveries/7/redeliver", nil))
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+	})
+
+	t.Run("redeliver unknown delivery", func(t *testing.T) {
+		rr := serve(s, httptest.NewRequest(http.MethodPost, "/webhooks/2/deliveries/9/redeliver", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	webhookService.AssertExpectations(t)
+}
+
+func TestWebhookSender(t *testing.T) {
+	var (
+		body   []byte
+		header http.Header
+		status = http.StatusNoContent
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		header = r.Header
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+
+	webhook := &ssr.Webhook{ID: 2, URL: receiver.URL, Secret: "s3cr3t"}
+	e := &ssr.Event{ID: uuid.New(), Type: ssr.EventScanSucceeded, RepositoryID: 1, ScanID: uuid.New(), ScanStatus: ssr.Success}
+	d, err := ssr.NewWebhookDelivery(webhook.ID, e, time.Now())
+	require.NoError(t, err)
+	d.ID = 7
+
+	sender := NewWebhookSender(time.Second, true)
+
+	t.Run("signed delivery", func(t *testing.T) {
+		code, err := sender.Send(context.Background(), webhook, d)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, code)
+
+		assert.JSONEq(t, string(d.Payload), string(body))
+		assert.Equal(t, "application/json", header.Get("Content-Type"))
+		assert.Equal(t, "scan.succeeded", header.Get(HeaderEvent))
+		assert.Equal(t, e.ID.String(), header.Get(HeaderEventID))
+		assert.Equal(t, "7", header.Get(HeaderDelivery))
+		assert.True(t, ssr.VerifySignature("s3cr3t", body, header.Get(HeaderSignature)))
+	})
+
+	t.Run("receiver error", func(t *testing.T) {
+		status = http.StatusBadGateway
+		code, err := sender.Send(context.Background(), webhook, d)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadGateway, code)
+	})
+
+	t.Run("unreachable receiver", func(t *testing.T) {
+		_, err := sender.Send(context.Background(), &ssr.Webhook{ID: 3, URL: "http://127.0.0.1:1"}, d)
+		assert.Error(t, err)
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		status = http.StatusFound
+		code, err := sender.Send(context.Background(), webhook, d)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusFound, code, "redirects are not followed")
+	})
+
+	t.Run("private receiver", func(t *testing.T) {
+		_, err := NewWebhookSender(time.Second, false).Send(context.Background(), webhook, d)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "non-public address")
+	})
+}
+
+func TestPublicIP(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"::1":              false,
+		"::ffff:127.0.0.1": false,
+		"169.254.169.254":  false,
+		"fe80::1":          false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"100.64.0.1":       false,
+		"fd00::1":          false,
+		"0.0.0.0":          false,
+		"224.0.0.1":        false,
+	} {
+		assert.Equal(t, public, publicIP(net.ParseIP(addr)), addr)
+	}
+}
@@ -0,0 +1,68 @@
+package ssr
+
+import (
+	"io"
+	"sort"
+	"sync"
+)
+
+// Importer converts the report of a security tool into findings.
+type Importer interface {
+	Import(r io.Reader) (Findings, error)
+}
+
+// ImporterFunc adapts an ordinary function to the Importer interface.
+type ImporterFunc func(r io.Reader) (Findings, error)
+
+func (f ImporterFunc) Import(r io.Reader) (Findings, error) {
+	return f(r)
+}
+
+var (
+	importersMu sync.RWMutex
+	importers   = make(map[string]Importer)
+)
+
+// RegisterImporter makes an importer available under the given format name.
+// It panics if an importer is already registered for that format.
+func RegisterImporter(format string, importer Importer) {
+	importersMu.Lock()
+	defer importersMu.Unlock()
+
+	if _, dup := importers[format]; dup {
+		panic("ssr: RegisterImporter called twice for format " + format)
+	}
+	importers[format] = importer
+}
+
+// LookupImporter returns the importer registered for format.
+func LookupImporter(format string) (Importer, bool) {
+	importersMu.RLock()
+	defer importersMu.RUnlock()
+
+	importer, ok := importers[format]
+	return importer, ok
+}
+
+// ImportFormats returns the sorted names of the registered formats.
+func ImportFormats() []string {
+	importersMu.RLock()
+	defer importersMu.RUnlock()
+
+	formats := make([]string, 0, len(importers))
+	for format := range importers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+func init() {
+	RegisterImporter("sarif", ImporterFunc(func(r io.Reader) (Findings, error) {
+		log, err := DecodeSARIF(r)
+		if err != nil {
+			return nil, err
+		}
+		return log.Findings(), nil
+	}))
+}
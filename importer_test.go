@@ -0,0 +1,82 @@
+package ssr
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestImporters(t *testing.T) {
+	for _, format := range []string{"gosec", "semgrep", "gitleaks", "trivy"} {
+		t.Run(format, func(t *testing.T) {
+			importer, ok := LookupImporter(format)
+			require.True(t, ok)
+
+			f, err := os.Open(filepath.Join("testdata", "importers", format+".json"))
+			require.NoError(t, err)
+			defer f.Close()
+
+			findings, err := importer.Import(f)
+			require.NoError(t, err)
+
+			actual, err := json.MarshalIndent(findings, "", "  ")
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", "importers", format+".golden")
+			if *update {
+				require.NoError(t, ioutil.WriteFile(golden, append(actual, '\n'), 0644))
+			}
+			expected, err := ioutil.ReadFile(golden)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(expected), string(actual))
+		})
+	}
+}
+
+func TestImportFingerprintAcrossCommitsAndVersions(t *testing.T) {
+	for format, reports := range map[string][2]string{
+		"gitleaks": {
+			`[{"Description": "AWS Access Key", "RuleID": "aws-access-token", "File": "config/aws.go", "StartLine": 10, "Commit": "0d2ac5e4"}]`,
+			`[{"Description": "AWS Access Key", "RuleID": "aws-access-token", "File": "config/aws.go", "StartLine": 10, "Commit": "9f8e7d6c"}]`,
+		},
+		"trivy": {
+			`{"Results": [{"Target": "go.sum", "Vulnerabilities": [{"VulnerabilityID": "CVE-2021-38561", "PkgName": "golang.org/x/text", "InstalledVersion": "v0.3.5", "Title": "DoS"}]}]}`,
+			`{"Results": [{"Target": "go.sum", "Vulnerabilities": [{"VulnerabilityID": "CVE-2021-38561", "PkgName": "golang.org/x/text", "InstalledVersion": "v0.3.6", "Title": "DoS"}]}]}`,
+		},
+	} {
+		importer, _ := LookupImporter(format)
+		var fingerprints [2]string
+		for i, report := range reports {
+			findings, err := importer.Import(strings.NewReader(report))
+			require.NoError(t, err, format)
+			require.Len(t, findings, 1, format)
+			findings.Fingerprint()
+			fingerprints[i] = findings[0].Fingerprint
+		}
+		assert.Equal(t, fingerprints[0], fingerprints[1], format)
+	}
+}
+
+func TestImportInvalidReport(t *testing.T) {
+	for _, format := range ImportFormats() {
+		importer, _ := LookupImporter(format)
+		_, err := importer.Import(strings.NewReader("not json"))
+		assert.Error(t, err, format)
+	}
+}
+
+func TestImportFormats(t *testing.T) {
+	assert.Equal(t, []string{"gitleaks", "gosec", "sarif", "semgrep", "trivy"}, ImportFormats())
+
+	_, ok := LookupImporter("bandit")
+	assert.False(t, ok)
+}
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	ssr "github.com/quantonganh/ssr"
 	mock "github.com/stretchr/testify/mock"
 
@@ -14,13 +16,13 @@ type ScanService struct {
 	mock.Mock
 }
 
-// CreateScan provides a mock function with given fields: s
-func (_m *ScanService) CreateScan(s *ssr.Scan) (*ssr.Scan, error) {
-	ret := _m.Called(s)
+// CreateScan provides a mock function with given fields: ctx, s
+func (_m *ScanService) CreateScan(ctx context.Context, s *ssr.Scan) (*ssr.Scan, error) {
+	ret := _m.Called(ctx, s)
 
 	var r0 *ssr.Scan
-	if rf, ok := ret.Get(0).(func(*ssr.Scan) *ssr.Scan); ok {
-		r0 = rf(s)
+	if rf, ok := ret.Get(0).(func(context.Context, *ssr.Scan) *ssr.Scan); ok {
+		r0 = rf(ctx, s)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*ssr.Scan)
@@ -28,8 +30,8 @@ func (_m *ScanService) CreateScan(s *ssr.Scan) (*ssr.Scan, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*ssr.Scan) error); ok {
-		r1 = rf(s)
+	if rf, ok := ret.Get(1).(func(context.Context, *ssr.Scan) error); ok {
+		r1 = rf(ctx, s)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -37,13 +39,13 @@ func (_m *ScanService) CreateScan(s *ssr.Scan) (*ssr.Scan, error) {
 	return r0, r1
 }
 
-// DeleteScan provides a mock function with given fields: id
-func (_m *ScanService) DeleteScan(id uuid.UUID) error {
-	ret := _m.Called(id)
+// DeleteScan provides a mock function with given fields: ctx, id
+func (_m *ScanService) DeleteScan(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -51,13 +53,36 @@ func (_m *ScanService) DeleteScan(id uuid.UUID) error {
 	return r0
 }
 
-// GetScan provides a mock function with given fields: id
-func (_m *ScanService) GetScan(id uuid.UUID) (*ssr.Scan, error) {
-	ret := _m.Called(id)
+// GetLatestDefaultBranchScan provides a mock function with given fields: ctx, repositoryID
+func (_m *ScanService) GetLatestDefaultBranchScan(ctx context.Context, repositoryID uint64) (*ssr.Scan, error) {
+	ret := _m.Called(ctx, repositoryID)
+
+	var r0 *ssr.Scan
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) *ssr.Scan); ok {
+		r0 = rf(ctx, repositoryID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ssr.Scan)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, repositoryID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetScan provides a mock function with given fields: ctx, id
+func (_m *ScanService) GetScan(ctx context.Context, id uuid.UUID) (*ssr.Scan, error) {
+	ret := _m.Called(ctx, id)
 
 	var r0 *ssr.Scan
-	if rf, ok := ret.Get(0).(func(uuid.UUID) *ssr.Scan); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *ssr.Scan); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*ssr.Scan)
@@ -65,8 +90,8 @@ func (_m *ScanService) GetScan(id uuid.UUID) (*ssr.Scan, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -74,13 +99,13 @@ func (_m *ScanService) GetScan(id uuid.UUID) (*ssr.Scan, error) {
 	return r0, r1
 }
 
-// ListScans provides a mock function with given fields: page, limit
-func (_m *ScanService) ListScans(page int, limit int) ([]*ssr.Scan, error) {
-	ret := _m.Called(page, limit)
+// ListScans provides a mock function with given fields: ctx, page, limit
+func (_m *ScanService) ListScans(ctx context.Context, page int, limit int) ([]*ssr.Scan, error) {
+	ret := _m.Called(ctx, page, limit)
 
 	var r0 []*ssr.Scan
-	if rf, ok := ret.Get(0).(func(int, int) []*ssr.Scan); ok {
-		r0 = rf(page, limit)
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) []*ssr.Scan); ok {
+		r0 = rf(ctx, page, limit)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*ssr.Scan)
@@ -88,8 +113,8 @@ func (_m *ScanService) ListScans(page int, limit int) ([]*ssr.Scan, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int, int) error); ok {
-		r1 = rf(page, limit)
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, page, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -97,13 +122,13 @@ func (_m *ScanService) ListScans(page int, limit int) ([]*ssr.Scan, error) {
 	return r0, r1
 }
 
-// UpdateScan provides a mock function with given fields: id, status, findings
-func (_m *ScanService) UpdateScan(id uuid.UUID, status ssr.Status, findings ssr.Findings) (*ssr.Scan, error) {
-	ret := _m.Called(id, status, findings)
+// UpdateScan provides a mock function with given fields: ctx, id, status, findings
+func (_m *ScanService) UpdateScan(ctx context.Context, id uuid.UUID, status ssr.Status, findings ssr.Findings) (*ssr.Scan, error) {
+	ret := _m.Called(ctx, id, status, findings)
 
 	var r0 *ssr.Scan
-	if rf, ok := ret.Get(0).(func(uuid.UUID, ssr.Status, ssr.Findings) *ssr.Scan); ok {
-		r0 = rf(id, status, findings)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, ssr.Status, ssr.Findings) *ssr.Scan); ok {
+		r0 = rf(ctx, id, status, findings)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*ssr.Scan)
@@ -111,8 +136,8 @@ func (_m *ScanService) UpdateScan(id uuid.UUID, status ssr.Status, findings ssr.
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(uuid.UUID, ssr.Status, ssr.Findings) error); ok {
-		r1 = rf(id, status, findings)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, ssr.Status, ssr.Findings) error); ok {
+		r1 = rf(ctx, id, status, findings)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,35 @@
+package ssr
+
+import (
+	"context"
+	"time"
+)
+
+// ErrOrganizationNotFound is returned when an organization does not exist.
+var ErrOrganizationNotFound = NewError(KindNotFound, "organization not found")
+
+// DefaultOrganizationID is the organization of a single-tenant deployment. It owns the data that existed
+// before organizations.
+const DefaultOrganizationID uint64 = 1
+
+// Organization is a tenant, e.g. a business unit. It owns repositories, along with their scans, findings,
+// suppressions and policies, global policies, API keys, teams and users. Users only ever see the data of
+// their organization.
+type Organization struct {
+	ID        uint64    `json:"id"`
+	Name      string    `json:"name" gorm:"uniqueIndex;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (Organization) TableName() string {
+	return "organization"
+}
+
+// OrganizationService manages organizations. They are created from the command line, since no tenant
+// may manage the others.
+type OrganizationService interface {
+	Create(ctx context.Context, o *Organization) error
+	// GetByName returns the organization that the identity provider names in the tokens of its users.
+	GetByName(ctx context.Context, name string) (*Organization, error)
+	List(ctx context.Context) ([]*Organization, error)
+}
@@ -0,0 +1,132 @@
+package ssr
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChangeType is what changed, as published from the outbox.
+type ChangeType string
+
+const (
+	ChangeScanCreated ChangeType = "scan.created"
+	// ChangeScanUpdated is recorded whenever a scan moves to another status, along with its findings.
+	ChangeScanUpdated    ChangeType = "scan.updated"
+	ChangeScanDeleted    ChangeType = "scan.deleted"
+	ChangeFindingTriaged ChangeType = "finding.triaged"
+	// ChangeFindingStatusChanged is recorded when a fingerprint is first seen in a repository, when it is fixed and
+	// when it is seen again after it was fixed.
+	ChangeFindingStatusChanged ChangeType = "finding.status_changed"
+	// ChangeFindingUnsuppressed is recorded for each finding of a scan that is re-opened because its suppression was
+	// deleted or expired.
+	ChangeFindingUnsuppressed ChangeType = "finding.unsuppressed"
+)
+
+// Change is a change to a scan or a finding. Changes are recorded in the outbox within the transaction that made
+// them, then published to a Sink by the relay, at least once: a change keeps its ID when it is published again, so
+// that consumers can discard the copies they have already seen.
+type Change struct {
+	ID uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	// Sequence is assigned by the database in the order the changes are recorded. Changes may be published out of
+	// that order, see Outbox.Relay, so consumers that apply them should ignore a change of a key whose sequence is
+	// lower than the last one they applied.
+	Sequence uint64     `json:"sequence" gorm:"<-:false"`
+	Type     ChangeType `json:"type" gorm:"not null"`
+	// Key is the ID of the scan or the fingerprint of the finding that changed, e.g. to partition a topic.
+	Key          string `json:"key" gorm:"not null"`
+	RepositoryID uint64 `json:"repository_id" gorm:"not null"`
+	// Data is the scan or the repository finding after the change, only the ID of a deleted scan, or the finding of
+	// a scan that was unsuppressed.
+	Data        Payload    `json:"data" gorm:"type:jsonb;not null"`
+	CreatedAt   time.Time  `json:"created_at"`
+	PublishedAt *time.Time `json:"-"`
+}
+
+func (Change) TableName() string {
+	return "outbox"
+}
+
+// NewScanChange returns the change of type t to s.
+func NewScanChange(t ChangeType, s *Scan, now time.Time) (*Change, error) {
+	var v interface{} = struct {
+		*Scan
+		// Repository hides the relation of the scan, which is not loaded.
+		Repository *Repository `json:"Repository,omitempty"`
+	}{Scan: s}
+	if t == ChangeScanDeleted {
+		v = struct {
+			ID           uuid.UUID `json:"id"`
+			RepositoryID uint64    `json:"repository_id"`
+		}{s.ID, s.RepositoryID}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &Change{
+		ID:           uuid.New(),
+		Type:         t,
+		Key:          s.ID.String(),
+		RepositoryID: s.RepositoryID,
+		Data:         data,
+		CreatedAt:    now,
+	}, nil
+}
+
+// NewFindingChange returns the change of type t to rf, a triage decision or a new status.
+func NewFindingChange(t ChangeType, rf *RepositoryFinding, now time.Time) (*Change, error) {
+	data, err := json.Marshal(rf)
+	if err != nil {
+		return nil, err
+	}
+	return &Change{
+		ID:           uuid.New(),
+		Type:         t,
+		Key:          rf.Fingerprint,
+		RepositoryID: rf.RepositoryID,
+		Data:         data,
+		CreatedAt:    now,
+	}, nil
+}
+
+// NewUnsuppressedChange returns the change of f, a finding of a scan of the repository that is no longer suppressed
+// by the suppression suppressionID.
+func NewUnsuppressedChange(repositoryID uint64, f *Finding, suppressionID uint64, now time.Time) (*Change, error) {
+	data, err := json.Marshal(struct {
+		ScanID uuid.UUID `json:"scan_id"`
+		*Finding
+		SuppressionID uint64 `json:"suppression_id"`
+	}{f.ScanID, f, suppressionID})
+	if err != nil {
+		return nil, err
+	}
+	return &Change{
+		ID:           uuid.New(),
+		Type:         ChangeFindingUnsuppressed,
+		Key:          f.Fingerprint,
+		RepositoryID: repositoryID,
+		Data:         data,
+		CreatedAt:    now,
+	}, nil
+}
+
+// Outbox holds the changes until they are published.
+type Outbox interface {
+	// Relay publishes up to limit unpublished changes to sink, in the order they were recorded, and marks them as
+	// published once sink accepted them. The changes are leased meanwhile, so that concurrent relays skip them; a
+	// change whose lease expired before it was marked as published is published again, possibly after later
+	// changes of its key. It returns how many changes were published.
+	Relay(ctx context.Context, limit int, sink Sink) (int, error)
+	// Purge deletes the changes that were published before before.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Sink is where changes are published, e.g. a topic of a message broker.
+type Sink interface {
+	// Publish publishes changes in order. It returns nil only once every change was accepted.
+	Publish(ctx context.Context, changes []*Change) error
+	Close() error
+}
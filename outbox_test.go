@@ -0,0 +1,77 @@
+package ssr
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewScanChange(t *testing.T) {
+	now := time.Now()
+	scan := &Scan{
+		ID:           uuid.New(),
+		Status:       Success,
+		RepositoryID: 1,
+		Branch:       "main",
+		Findings:     Findings{{RuleID: "G402"}},
+		Gate:         &Gate{Status: GatePassed},
+	}
+
+	c, err := NewScanChange(ChangeScanUpdated, scan, now)
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, c.ID)
+	assert.Equal(t, scan.ID.String(), c.Key)
+	assert.Equal(t, uint64(1), c.RepositoryID)
+	assert.Equal(t, now, c.CreatedAt)
+	assert.Nil(t, c.PublishedAt)
+
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(c.Data, &data))
+	assert.Equal(t, "main", data["branch"])
+	assert.Len(t, data["findings"], 1)
+	assert.NotContains(t, data, "Repository")
+
+	other, err := NewScanChange(ChangeScanUpdated, scan, now)
+	require.NoError(t, err)
+	assert.NotEqual(t, c.ID, other.ID)
+
+	c, err = NewScanChange(ChangeScanDeleted, scan, now)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id": "`+scan.ID.String()+`", "repository_id": 1}`, string(c.Data))
+}
+
+func TestNewFindingChange(t *testing.T) {
+	rf := &RepositoryFinding{RepositoryID: 1, Fingerprint: "abc", Status: FindingFalsePositive, TriagedBy: "alice@example.com"}
+	c, err := NewFindingChange(ChangeFindingTriaged, rf, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, ChangeFindingTriaged, c.Type)
+	assert.Equal(t, "abc", c.Key)
+
+	b, err := json.Marshal(c)
+	require.NoError(t, err)
+	var published map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &published))
+	assert.Equal(t, "finding.triaged", published["type"])
+	assert.Equal(t, "alice@example.com", published["data"].(map[string]interface{})["triaged_by"])
+}
+
+func TestNewUnsuppressedChange(t *testing.T) {
+	scanID := uuid.New()
+	f := &Finding{ID: 7, ScanID: scanID, RuleID: "G404", Fingerprint: "abc"}
+	c, err := NewUnsuppressedChange(1, f, 3, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, ChangeFindingUnsuppressed, c.Type)
+	assert.Equal(t, "abc", c.Key)
+	assert.Equal(t, uint64(1), c.RepositoryID)
+
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(c.Data, &data))
+	assert.Equal(t, scanID.String(), data["scan_id"])
+	assert.Equal(t, "G404", data["rule_id"])
+	assert.Equal(t, float64(3), data["suppression_id"])
+	assert.NotContains(t, data, "suppressed")
+}
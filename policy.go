@@ -0,0 +1,144 @@
+package ssr
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrPolicyNotFound is returned when a policy does not exist.
+var ErrPolicyNotFound = NewError(KindNotFound, "policy not found")
+
+// Policy bounds the number of findings of a scan that match it, e.g. zero new HIGH findings,
+// at most 10 MEDIUM findings, or no finding of a given rule.
+// A policy without repository applies to every repository.
+type Policy struct {
+	ID uint64 `json:"id"`
+	// OrganizationID is the tenant that owns the policy. Global policies apply to the repositories of their organization.
+	OrganizationID uint64  `json:"organization_id" gorm:"index;not null"`
+	RepositoryID   *uint64 `json:"repository_id,omitempty" gorm:"index"`
+	Name           string  `json:"name"`
+	// Severity and RuleID select the findings that the policy counts, an empty one matches every finding.
+	Severity Severity `json:"severity,omitempty"`
+	RuleID   string   `json:"rule_id,omitempty"`
+	// NewOnly only counts the findings that the latest successful scan of the default branch did not report.
+	NewOnly bool `json:"new_only"`
+	// MaxFindings is how many matching findings the policy tolerates.
+	MaxFindings int       `json:"max_findings"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (Policy) TableName() string {
+	return "policy"
+}
+
+// Matches reports whether p counts f. Suppressed findings, false positives and accepted risks are never counted.
+func (p *Policy) Matches(f Finding, baseline map[string]bool) bool {
+	if f.Suppressed || f.Status == FindingFalsePositive || f.Status == FindingAcceptedRisk {
+		return false
+	}
+	if p.Severity != "" && p.Severity != f.Metadata.Severity {
+		return false
+	}
+	if p.RuleID != "" && p.RuleID != f.RuleID {
+		return false
+	}
+	return !p.NewOnly || !baseline[f.Fingerprint]
+}
+
+// Statuses of a gate.
+const (
+	GatePassed = "passed"
+	GateFailed = "failed"
+	// GatePending is the status of the gate of a scan that has not completed yet.
+	GatePending = "pending"
+)
+
+// Gate is the result of the evaluation of the policies of a repository against a successful scan.
+type Gate struct {
+	Status      string       `json:"status"`
+	Reasons     []GateReason `json:"reasons,omitempty"`
+	EvaluatedAt time.Time    `json:"evaluated_at"`
+}
+
+// GateReason explains why a policy failed.
+type GateReason struct {
+	PolicyID uint64 `json:"policy_id"`
+	Policy   string `json:"policy"`
+	Found    int    `json:"found"`
+	Allowed  int    `json:"allowed"`
+	Message  string `json:"message"`
+}
+
+func (g Gate) Value() (driver.Value, error) {
+	return json.Marshal(g)
+}
+
+func (g *Gate) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, &g)
+}
+
+// EvaluatePolicies checks findings against policies. baseline holds the fingerprints of the findings that
+// are not new. The gate fails if any policy is exceeded.
+func EvaluatePolicies(policies []*Policy, findings Findings, baseline map[string]bool, now time.Time) *Gate {
+	gate := &Gate{
+		Status:      GatePassed,
+		EvaluatedAt: now,
+	}
+	for _, p := range policies {
+		found := 0
+		for _, f := range findings {
+			if p.Matches(f, baseline) {
+				found++
+			}
+		}
+		if found > p.MaxFindings {
+			gate.Status = GateFailed
+			gate.Reasons = append(gate.Reasons, GateReason{
+				PolicyID: p.ID,
+				Policy:   p.Name,
+				Found:    found,
+				Allowed:  p.MaxFindings,
+				Message:  fmt.Sprintf("%s: %d %s, at most %d allowed", p.Name, found, p.describe(found), p.MaxFindings),
+			})
+		}
+	}
+	return gate
+}
+
+// describe names the findings that p counts, e.g. "new HIGH findings of rule G402".
+func (p *Policy) describe(n int) string {
+	var b strings.Builder
+	if p.NewOnly {
+		b.WriteString("new ")
+	}
+	if p.Severity != "" {
+		b.WriteString(string(p.Severity) + " ")
+	}
+	if n == 1 {
+		b.WriteString("finding")
+	} else {
+		b.WriteString("findings")
+	}
+	if p.RuleID != "" {
+		b.WriteString(" of rule " + p.RuleID)
+	}
+	return b.String()
+}
+
+type PolicyService interface {
+	Create(ctx context.Context, p *Policy) error
+	Get(ctx context.Context, id uint64) (*Policy, error)
+	// List returns the policies that apply to a repository, global ones included.
+	// A zero repositoryID only returns the global policies.
+	List(ctx context.Context, repositoryID uint64) ([]*Policy, error)
+	Delete(ctx context.Context, id uint64) error
+}
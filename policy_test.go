@@ -0,0 +1,59 @@
+package ssr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluatePolicies(t *testing.T) {
+	finding := func(ruleID string, severity Severity, fingerprint string) Finding {
+		return Finding{RuleID: ruleID, Metadata: Metadata{Severity: severity}, Fingerprint: fingerprint}
+	}
+	findings := Findings{
+		finding("G402", "HIGH", "a"),
+		finding("G404", "HIGH", "b"),
+		finding("G101", "MEDIUM", "c"),
+		finding("G101", "MEDIUM", "d"),
+		finding("G304", "MEDIUM", "e"),
+		finding("G104", "LOW", "f"),
+	}
+	findings[3].Suppressed = true
+	findings[4].Status = FindingFalsePositive
+	baseline := map[string]bool{"a": true}
+	now := time.Now()
+
+	t.Run("passed", func(t *testing.T) {
+		gate := EvaluatePolicies([]*Policy{
+			{ID: 1, Name: "few medium", Severity: "MEDIUM", MaxFindings: 1},
+			{ID: 2, Name: "no G401", RuleID: "G401"},
+		}, findings, baseline, now)
+		assert.Equal(t, GatePassed, gate.Status)
+		assert.Empty(t, gate.Reasons)
+		assert.Equal(t, now, gate.EvaluatedAt)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		gate := EvaluatePolicies([]*Policy{
+			{ID: 1, Name: "no new high", Severity: "HIGH", NewOnly: true},
+			{ID: 2, Name: "no G101", RuleID: "G101"},
+			{ID: 3, Name: "few findings", MaxFindings: 4},
+		}, findings, baseline, now)
+		assert.Equal(t, GateFailed, gate.Status)
+		require.Len(t, gate.Reasons, 2)
+		assert.Equal(t, GateReason{
+			PolicyID: 1,
+			Policy:   "no new high",
+			Found:    1,
+			Allowed:  0,
+			Message:  "no new high: 1 new HIGH finding, at most 0 allowed",
+		}, gate.Reasons[0])
+		assert.Equal(t, "no G101: 1 finding of rule G101, at most 0 allowed", gate.Reasons[1].Message)
+	})
+
+	t.Run("without policies", func(t *testing.T) {
+		assert.Equal(t, GatePassed, EvaluatePolicies(nil, findings, nil, now).Status)
+	})
+}
@@ -0,0 +1,293 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/quantonganh/ssr"
+)
+
+type accessService struct {
+	db *gorm.DB
+}
+
+func NewAccessService(db *gorm.DB) ssr.AccessService {
+	return &accessService{
+		db: db,
+	}
+}
+
+func (as *accessService) SaveUser(ctx context.Context, u *ssr.User) error {
+	db := as.db.WithContext(ctx)
+	if err := authorize(ctx, db, ssr.RoleAdmin); err != nil {
+		return err
+	}
+
+	u.OrganizationID = organizationOf(ctx, u.OrganizationID)
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}, {Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"email", "role"}),
+	}).Create(u).Error
+	if err != nil {
+		return errors.Wrapf(err, "failed to save user: %s", u.ID)
+	}
+	return nil
+}
+
+func (as *accessService) ListUsers(ctx context.Context) ([]*ssr.User, error) {
+	db := as.db.WithContext(ctx)
+	if err := authorize(ctx, db, ssr.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	users := []*ssr.User{}
+	if err := db.Scopes(inOrganization(ctx, "organization_id")).Order("id").Find(&users).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to list users")
+	}
+	return users, nil
+}
+
+func (as *accessService) CreateTeam(ctx context.Context, t *ssr.Team) error {
+	db := as.db.WithContext(ctx)
+	if err := authorize(ctx, db, ssr.RoleAdmin); err != nil {
+		return err
+	}
+
+	t.OrganizationID = organizationOf(ctx, t.OrganizationID)
+	if err := db.Create(t).Error; err != nil {
+		return errors.Wrapf(err, "failed to create team: %s", t.Name)
+	}
+	return nil
+}
+
+func (as *accessService) ListTeams(ctx context.Context) ([]*ssr.Team, error) {
+	db := as.db.WithContext(ctx)
+	if err := authorize(ctx, db, ssr.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	teams := []*ssr.Team{}
+	if err := db.Scopes(inOrganization(ctx, "organization_id")).Order("id").Find(&teams).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to list teams")
+	}
+	return teams, nil
+}
+
+// DeleteTeam removes a team along with its members and repository memberships.
+func (as *accessService) DeleteTeam(ctx context.Context, id uint64) error {
+	db := as.db.WithContext(ctx)
+	if err := authorize(ctx, db, ssr.RoleAdmin); err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := requireTeam(ctx, tx, id); err != nil {
+			return err
+		}
+		if err := tx.Where("team_id = ?", id).Delete(&ssr.TeamMember{}).Error; err != nil {
+			return errors.Wrapf(err, "failed to delete members of team: %d", id)
+		}
+		if err := tx.Where("team_id = ?", id).Delete(&ssr.RepositoryMember{}).Error; err != nil {
+			return errors.Wrapf(err, "failed to delete repository memberships of team: %d", id)
+		}
+		if err := tx.Delete(&ssr.Team{}, id).Error; err != nil {
+			return errors.Wrapf(err, "failed to delete team: %d", id)
+		}
+		return nil
+	})
+}
+
+func (as *accessService) AddTeamMember(ctx context.Context, m *ssr.TeamMember) error {
+	db := as.db.WithContext(ctx)
+	if err := authorize(ctx, db, ssr.RoleAdmin); err != nil {
+		return err
+	}
+
+	if err := requireTeam(ctx, db, m.TeamID); err != nil {
+		return err
+	}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(m).Error; err != nil {
+		return errors.Wrapf(err, "failed to add member to team: %d", m.TeamID)
+	}
+	return nil
+}
+
+func (as *accessService) RemoveTeamMember(ctx context.Context, m *ssr.TeamMember) error {
+	db := as.db.WithContext(ctx)
+	if err := authorize(ctx, db, ssr.RoleAdmin); err != nil {
+		return err
+	}
+
+	if err := requireTeam(ctx, db, m.TeamID); err != nil {
+		return err
+	}
+	if err := db.Where("team_id = ? AND user_id = ?", m.TeamID, m.UserID).Delete(&ssr.TeamMember{}).Error; err != nil {
+		return errors.Wrapf(err, "failed to remove member from team: %d", m.TeamID)
+	}
+	return nil
+}
+
+func (as *accessService) SetRepositoryMember(ctx context.Context, m *ssr.RepositoryMember) error {
+	db := as.db.WithContext(ctx)
+	if err := authorizeRepository(ctx, db, m.RepositoryID, ssr.RoleAdmin, ssr.ErrRepositoryNotFound); err != nil {
+		return err
+	}
+
+	if err := db.Select("id").First(&ssr.Repository{}, m.RepositoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ssr.ErrRepositoryNotFound
+		}
+		return errors.Wrapf(err, "failed to select repository: %d", m.RepositoryID)
+	}
+	if err := requireTeam(ctx, db, m.TeamID); err != nil {
+		return err
+	}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "repository_id"}, {Name: "team_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(m).Error
+	if err != nil {
+		return errors.Wrapf(err, "failed to set member of repository: %d", m.RepositoryID)
+	}
+	return nil
+}
+
+func (as *accessService) ListRepositoryMembers(ctx context.Context, repositoryID uint64) ([]*ssr.RepositoryMember, error) {
+	db := as.db.WithContext(ctx)
+	if err := authorizeRepository(ctx, db, repositoryID, ssr.RoleReader, ssr.ErrRepositoryNotFound); err != nil {
+		return nil, err
+	}
+
+	members := []*ssr.RepositoryMember{}
+	if err := db.Where("repository_id = ?", repositoryID).Order("team_id").Find(&members).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to list members of repository: %d", repositoryID)
+	}
+	return members, nil
+}
+
+func (as *accessService) RemoveRepositoryMember(ctx context.Context, repositoryID, teamID uint64) error {
+	db := as.db.WithContext(ctx)
+	if err := authorizeRepository(ctx, db, repositoryID, ssr.RoleAdmin, ssr.ErrRepositoryNotFound); err != nil {
+		return err
+	}
+
+	result := db.Where("repository_id = ? AND team_id = ?", repositoryID, teamID).Delete(&ssr.RepositoryMember{})
+	if result.Error != nil {
+		return errors.Wrapf(result.Error, "failed to remove member of repository: %d", repositoryID)
+	}
+	if result.RowsAffected == 0 {
+		return ssr.ErrRepositoryMemberNotFound
+	}
+	return nil
+}
+
+func (as *accessService) Access(ctx context.Context) (*ssr.Access, error) {
+	return resolveAccess(ctx, as.db.WithContext(ctx))
+}
+
+// requireTeam checks that a team exists in the organization of the user of ctx.
+func requireTeam(ctx context.Context, db *gorm.DB, id uint64) error {
+	if err := db.Scopes(inOrganization(ctx, "organization_id")).Select("id").First(&ssr.Team{}, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ssr.ErrTeamNotFound
+		}
+		return errors.Wrapf(err, "failed to select team: %d", id)
+	}
+	return nil
+}
+
+// resolveAccess returns what the user of ctx may do in their organization: their own role, the roles of their teams,
+// and the roles of their teams on repositories. It returns nil, i.e. unrestricted, when ctx has no user.
+func resolveAccess(ctx context.Context, db *gorm.DB) (*ssr.Access, error) {
+	u := ssr.UserFromContext(ctx)
+	if u == nil {
+		return nil, nil
+	}
+
+	if u.RepositoryID != nil {
+		// The user is restricted to the repository, e.g. an API key, whatever its teams allow.
+		a := &ssr.Access{}
+		a.GrantRepository(*u.RepositoryID, u.Role)
+		return a, nil
+	}
+	a := &ssr.Access{Role: u.Role}
+	if a.Role == ssr.RoleAdmin {
+		// Nothing allows more.
+		return a, nil
+	}
+	var stored ssr.User
+	if err := db.Where("organization_id = ? AND id = ?", u.OrganizationID, u.ID).Limit(1).Find(&stored).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to select user: %s", u.ID)
+	}
+	a.Grant(stored.Role)
+
+	var teams []*ssr.Team
+	member := db.Where("id IN (?)", db.Model(&ssr.TeamMember{}).Select("team_id").Where("user_id = ?", u.ID))
+	if len(u.Groups) > 0 {
+		member = member.Or("name IN ?", u.Groups)
+	}
+	if err := db.Where("organization_id = ?", u.OrganizationID).Where(member).Find(&teams).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to select teams of user: %s", u.ID)
+	}
+	if len(teams) == 0 {
+		return a, nil
+	}
+
+	teamIDs := make([]uint64, 0, len(teams))
+	for _, t := range teams {
+		a.Grant(t.Role)
+		teamIDs = append(teamIDs, t.ID)
+	}
+	var members []*ssr.RepositoryMember
+	if err := db.Where("team_id IN ?", teamIDs).Find(&members).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to select repository memberships of user: %s", u.ID)
+	}
+	for _, m := range members {
+		a.GrantRepository(m.RepositoryID, m.Role)
+	}
+	return a, nil
+}
+
+// authorize checks that the user of ctx has role on every repository.
+func authorize(ctx context.Context, db *gorm.DB, role ssr.Role) error {
+	a, err := resolveAccess(ctx, db)
+	if err != nil {
+		return err
+	}
+	if a != nil && !a.Role.Allows(role) {
+		return ssr.ErrForbidden
+	}
+	return nil
+}
+
+// authorizeRepository checks that the user of ctx has role on a repository of their organization. Users that
+// cannot even read the repository get notFound, so that they cannot tell which repositories exist.
+func authorizeRepository(ctx context.Context, db *gorm.DB, repositoryID uint64, role ssr.Role, notFound error) error {
+	if err := requireOrganizationRepository(ctx, db, repositoryID, notFound); err != nil {
+		return err
+	}
+	a, err := resolveAccess(ctx, db)
+	if err != nil {
+		return err
+	}
+	if a == nil || a.Can(repositoryID, role) {
+		return nil
+	}
+	if a.Can(repositoryID, ssr.RoleReader) {
+		return ssr.ErrForbidden
+	}
+	return notFound
+}
+
+// readableRepositories restricts a query to the rows whose column, a repository ID, is a repository that a can read.
+func readableRepositories(a *ssr.Access, column string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if a == nil || a.ReadsAll() {
+			return db
+		}
+		return db.Where(column+" IN ?", a.Readable())
+	}
+}
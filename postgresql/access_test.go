@@ -0,0 +1,179 @@
+// +build !integration
+
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/quantonganh/ssr"
+)
+
+const (
+	sqlSelectUser                  = `SELECT * FROM "user_account" WHERE organization_id = $1 AND id = $2 LIMIT 1`
+	sqlSelectUserTeams             = `SELECT * FROM "team" WHERE (organization_id = $1) AND (id IN (SELECT "team_id" FROM "team_member" WHERE user_id = $2) OR name IN ($3))`
+	sqlSelectRepositoryMembers     = `SELECT * FROM "repository_member" WHERE team_id IN ($1)`
+	sqlCountOrganizationRepository = `SELECT count(*) FROM "repository" WHERE id = $1 AND organization_id = $2`
+	sqlSaveUser                    = `INSERT INTO "user_account" ("id","organization_id","email","role") VALUES ($1,$2,$3,$4) ON CONFLICT ("organization_id","id") DO UPDATE SET "email"="excluded"."email","role"="excluded"."role"`
+	sqlListScansOfRepositories     = `SELECT * FROM "scan" WHERE (repository_id IN (SELECT "id" FROM "repository" WHERE organization_id = $1)) AND (repository_id IN ($2)) LIMIT 10`
+)
+
+func TestAccessService(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	accessService := NewAccessService(gormDB)
+	scanService := NewScanService(gormDB)
+
+	// alice is a maintainer of repository 1 of organization 2 through the payments group of the identity provider.
+	alice := ssr.NewContextWithUser(context.Background(), &ssr.User{ID: "alice", OrganizationID: 2, Groups: []string{"payments"}})
+	expectRepository := func(repositoryID uint64, found bool) {
+		rows := sqlmock.NewRows([]string{"count"}).AddRow(0)
+		if found {
+			rows = sqlmock.NewRows([]string{"count"}).AddRow(1)
+		}
+		mock.ExpectQuery(sqlCountOrganizationRepository).
+			WithArgs(repositoryID, 2).
+			WillReturnRows(rows)
+	}
+	expectAlice := func() {
+		mock.ExpectQuery(sqlSelectUser).
+			WithArgs(2, "alice").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).AddRow("alice", ""))
+		mock.ExpectQuery(sqlSelectUserTeams).
+			WithArgs(2, "alice", "payments").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "role"}).AddRow(7, "payments", ""))
+		mock.ExpectQuery(sqlSelectRepositoryMembers).
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"repository_id", "team_id", "role"}).AddRow(1, 7, ssr.RoleMaintainer))
+	}
+
+	t.Run("resolve access", func(t *testing.T) {
+		expectAlice()
+
+		a, err := accessService.Access(alice)
+		require.NoError(t, err)
+		assert.True(t, a.Can(1, ssr.RoleMaintainer))
+		assert.False(t, a.Can(1, ssr.RoleAdmin))
+		assert.False(t, a.Can(2, ssr.RoleReader))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unrestricted without user", func(t *testing.T) {
+		a, err := accessService.Access(context.Background())
+		require.NoError(t, err)
+		assert.Nil(t, a)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("admin without lookup", func(t *testing.T) {
+		a, err := accessService.Access(ssr.NewContextWithUser(context.Background(), (&ssr.APIKey{Name: "ci", Role: ssr.RoleAdmin}).User()))
+		require.NoError(t, err)
+		assert.True(t, a.Can(2, ssr.RoleAdmin))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("restricted to a repository without lookup", func(t *testing.T) {
+		repositoryID := uint64(1)
+		a, err := accessService.Access(ssr.NewContextWithUser(context.Background(), (&ssr.APIKey{Name: "ci", Role: ssr.RoleMaintainer, RepositoryID: &repositoryID}).User()))
+		require.NoError(t, err)
+		assert.True(t, a.Can(1, ssr.RoleMaintainer))
+		assert.False(t, a.Can(1, ssr.RoleAdmin))
+		assert.False(t, a.Can(2, ssr.RoleReader))
+		assert.False(t, a.ReadsAll())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("save user as admin", func(t *testing.T) {
+		mock.ExpectExec(sqlSaveUser).
+			WithArgs("bob", ssr.DefaultOrganizationID, "bob@example.com", ssr.RoleReader).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, accessService.SaveUser(context.Background(), &ssr.User{ID: "bob", Email: "bob@example.com", Role: ssr.RoleReader}))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("save user as maintainer", func(t *testing.T) {
+		expectAlice()
+
+		err := accessService.SaveUser(alice, &ssr.User{ID: "alice", Role: ssr.RoleAdmin})
+		assert.ErrorIs(t, err, ssr.ErrForbidden)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("remove member of unreadable repository", func(t *testing.T) {
+		expectRepository(2, true)
+		expectAlice()
+
+		err := accessService.RemoveRepositoryMember(alice, 2, 7)
+		assert.ErrorIs(t, err, ssr.ErrRepositoryNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list scans of readable repositories", func(t *testing.T) {
+		expectAlice()
+		mock.ExpectQuery(sqlListScansOfRepositories).
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "repository_id"}))
+
+		scans, err := scanService.ListScans(alice, 1, 10)
+		require.NoError(t, err)
+		assert.Empty(t, scans)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get scan of unreadable repository", func(t *testing.T) {
+		id := uuid.New()
+		mock.ExpectQuery(sqlSelectScan).
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "repository_id"}).AddRow(id, 2))
+		mock.ExpectQuery(sqlPreloadFindings).
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		expectRepository(2, true)
+		expectAlice()
+
+		_, err := scanService.GetScan(alice, id)
+		assert.ErrorIs(t, err, ssr.ErrScanNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get scan of another organization", func(t *testing.T) {
+		id := uuid.New()
+		mock.ExpectQuery(sqlSelectScan).
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "repository_id"}).AddRow(id, 3))
+		mock.ExpectQuery(sqlPreloadFindings).
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		expectRepository(3, false)
+
+		_, err := scanService.GetScan(alice, id)
+		assert.ErrorIs(t, err, ssr.ErrScanNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("delete repository as maintainer", func(t *testing.T) {
+		expectRepository(1, true)
+		expectAlice()
+
+		err := NewRepositoryService(gormDB).Delete(alice, 1)
+		assert.ErrorIs(t, err, ssr.ErrForbidden)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,99 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/quantonganh/ssr"
+)
+
+type apiKeyService struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyService(db *gorm.DB) ssr.APIKeyService {
+	return &apiKeyService{
+		db: db,
+	}
+}
+
+func (as *apiKeyService) Create(ctx context.Context, k *ssr.APIKey) error {
+	k.OrganizationID = organizationOf(ctx, k.OrganizationID)
+	db := as.db.WithContext(ctx)
+	if k.RepositoryID != nil {
+		var count int64
+		if err := db.Model(&ssr.Repository{}).Where("id = ? AND organization_id = ?", *k.RepositoryID, k.OrganizationID).Count(&count).Error; err != nil {
+			return errors.Wrapf(err, "failed to select repository: %d", *k.RepositoryID)
+		}
+		if count == 0 {
+			return ssr.ErrRepositoryNotFound
+		}
+	}
+	// A key cannot be given a role that the user issuing it does not have.
+	a, err := resolveAccess(ctx, db)
+	if err != nil {
+		return err
+	}
+	if a != nil {
+		allowed := a.Role.Allows(k.Role)
+		if k.RepositoryID != nil {
+			allowed = a.Can(*k.RepositoryID, k.Role)
+		}
+		if !allowed {
+			return ssr.ErrForbidden
+		}
+	}
+
+	if err := db.Create(k).Error; err != nil {
+		return errors.Wrap(err, "failed to create API key")
+	}
+	return nil
+}
+
+func (as *apiKeyService) Authenticate(ctx context.Context, key string) (*ssr.APIKey, error) {
+	var k ssr.APIKey
+	if err := as.db.WithContext(ctx).Where("hash = ?", ssr.HashAPIKey(key)).First(&k).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ssr.ErrUnauthorized
+		}
+		return nil, errors.Wrap(err, "failed to select API key")
+	}
+
+	now := time.Now()
+	if !k.Active(now) {
+		return nil, ssr.ErrUnauthorized
+	}
+
+	if err := as.db.WithContext(ctx).Model(&k).Update("last_used_at", now).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to update last use of API key: %d", k.ID)
+	}
+	return &k, nil
+}
+
+func (as *apiKeyService) List(ctx context.Context) ([]*ssr.APIKey, error) {
+	keys := []*ssr.APIKey{}
+	if err := as.db.WithContext(ctx).Scopes(inOrganization(ctx, "organization_id")).Order("id").Find(&keys).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to list API keys")
+	}
+	return keys, nil
+}
+
+func (as *apiKeyService) Revoke(ctx context.Context, id uint64) error {
+	result := as.db.WithContext(ctx).Model(&ssr.APIKey{}).Scopes(inOrganization(ctx, "organization_id")).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return errors.Wrapf(result.Error, "failed to revoke API key: %d", id)
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := as.db.WithContext(ctx).Model(&ssr.APIKey{}).Scopes(inOrganization(ctx, "organization_id")).Where("id = ?", id).Count(&count).Error; err != nil {
+			return errors.Wrapf(err, "failed to select API key: %d", id)
+		}
+		if count == 0 {
+			return ssr.ErrAPIKeyNotFound
+		}
+	}
+	return nil
+}
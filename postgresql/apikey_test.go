@@ -0,0 +1,157 @@
+// +build !integration
+
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/quantonganh/ssr"
+)
+
+const (
+	sqlInsertAPIKey       = `INSERT INTO "api_key" ("organization_id","name","prefix","hash","scopes","created_at","expires_at","last_used_at","revoked_at","role","repository_id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) RETURNING "id"`
+	sqlSelectAPIKeyByHash = `SELECT * FROM "api_key" WHERE hash = $1 ORDER BY "api_key"."id" LIMIT 1`
+	sqlUpdateAPIKeyUse    = `UPDATE "api_key" SET "last_used_at"=$1 WHERE "id" = $2`
+	sqlRevokeAPIKey       = `UPDATE "api_key" SET "revoked_at"=$1 WHERE id = $2 AND revoked_at IS NULL`
+	sqlCountAPIKey        = `SELECT count(*) FROM "api_key" WHERE id = $1`
+
+	sqlRevokeOrganizationAPIKey = `UPDATE "api_key" SET "revoked_at"=$1 WHERE (id = $2 AND revoked_at IS NULL) AND (organization_id = $3)`
+	sqlCountOrganizationAPIKey  = `SELECT count(*) FROM "api_key" WHERE id = $1 AND (organization_id = $2)`
+	sqlCountRepositoryOfKey     = `SELECT count(*) FROM "repository" WHERE id = $1 AND organization_id = $2`
+	sqlSelectTeamsOfUser        = `SELECT * FROM "team" WHERE (organization_id = $1) AND id IN (SELECT "team_id" FROM "team_member" WHERE user_id = $2)`
+)
+
+func TestAPIKeyService(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	apiKeyService := NewAPIKeyService(gormDB)
+	columns := []string{"id", "name", "hash", "scopes", "expires_at", "revoked_at"}
+
+	t.Run("create", func(t *testing.T) {
+		k, _, err := ssr.NewAPIKey("ci", []ssr.Scope{ssr.ScopeScansWrite}, nil)
+		require.NoError(t, err)
+		mock.ExpectQuery(sqlInsertAPIKey).
+			WithArgs(ssr.DefaultOrganizationID, "ci", k.Prefix, k.Hash, []byte(`["scans:write"]`), sqlmock.AnyArg(), nil, nil, nil, ssr.RoleReader, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+		require.NoError(t, apiKeyService.Create(context.Background(), k))
+		assert.Equal(t, uint64(1), k.ID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("create with a role that the issuer does not have", func(t *testing.T) {
+		ctx := ssr.NewContextWithUser(context.Background(), &ssr.User{ID: "bob", OrganizationID: ssr.DefaultOrganizationID})
+		k, _, err := ssr.NewAPIKey("ci", []ssr.Scope{ssr.ScopeScansWrite}, nil)
+		require.NoError(t, err)
+		k.Role = ssr.RoleAdmin
+		mock.ExpectQuery(sqlSelectUser).
+			WithArgs(ssr.DefaultOrganizationID, "bob").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "organization_id", "role"}).AddRow("bob", ssr.DefaultOrganizationID, ssr.RoleMaintainer))
+		mock.ExpectQuery(sqlSelectTeamsOfUser).
+			WithArgs(ssr.DefaultOrganizationID, "bob").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		assert.ErrorIs(t, apiKeyService.Create(ctx, k), ssr.ErrForbidden)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("create for a repository of another organization", func(t *testing.T) {
+		k, _, err := ssr.NewAPIKey("ci", []ssr.Scope{ssr.ScopeScansWrite}, nil)
+		require.NoError(t, err)
+		repositoryID := uint64(5)
+		k.OrganizationID = 2
+		k.RepositoryID = &repositoryID
+		mock.ExpectQuery(sqlCountRepositoryOfKey).
+			WithArgs(5, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		assert.ErrorIs(t, apiKeyService.Create(context.Background(), k), ssr.ErrRepositoryNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("authenticate", func(t *testing.T) {
+		mock.ExpectQuery(sqlSelectAPIKeyByHash).
+			WithArgs(ssr.HashAPIKey("ssr_key")).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "ci", ssr.HashAPIKey("ssr_key"), []byte(`["scans:write"]`), nil, nil))
+		mock.ExpectExec(sqlUpdateAPIKeyUse).
+			WithArgs(sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		k, err := apiKeyService.Authenticate(context.Background(), "ssr_key")
+		require.NoError(t, err)
+		assert.True(t, k.HasScope(ssr.ScopeScansWrite))
+		assert.NotNil(t, k.LastUsedAt)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("authenticate unknown key", func(t *testing.T) {
+		mock.ExpectQuery(sqlSelectAPIKeyByHash).
+			WithArgs(ssr.HashAPIKey("ssr_unknown")).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := apiKeyService.Authenticate(context.Background(), "ssr_unknown")
+		assert.ErrorIs(t, err, ssr.ErrUnauthorized)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("authenticate revoked key", func(t *testing.T) {
+		mock.ExpectQuery(sqlSelectAPIKeyByHash).
+			WithArgs(ssr.HashAPIKey("ssr_revoked")).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(2, "old", ssr.HashAPIKey("ssr_revoked"), []byte(`[]`), nil, time.Now().Add(-time.Hour)))
+
+		_, err := apiKeyService.Authenticate(context.Background(), "ssr_revoked")
+		assert.ErrorIs(t, err, ssr.ErrUnauthorized)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		mock.ExpectExec(sqlRevokeAPIKey).
+			WithArgs(sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, apiKeyService.Revoke(context.Background(), 1))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("revoke unknown key", func(t *testing.T) {
+		mock.ExpectExec(sqlRevokeAPIKey).
+			WithArgs(sqlmock.AnyArg(), 3).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(sqlCountAPIKey).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		assert.ErrorIs(t, apiKeyService.Revoke(context.Background(), 3), ssr.ErrAPIKeyNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("revoke key of another organization", func(t *testing.T) {
+		ctx := ssr.NewContextWithUser(context.Background(), &ssr.User{ID: "apikey:other", OrganizationID: 2, Role: ssr.RoleAdmin})
+		mock.ExpectExec(sqlRevokeOrganizationAPIKey).
+			WithArgs(sqlmock.AnyArg(), 1, 2).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(sqlCountOrganizationAPIKey).
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		assert.ErrorIs(t, apiKeyService.Revoke(ctx, 1), ssr.ErrAPIKeyNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,17 @@
+package postgresql
+
+import (
+	"github.com/pkg/errors"
+)
+
+// foreignKeyViolation is the SQLSTATE of a write that breaks a foreign key constraint.
+const foreignKeyViolation = "23503"
+
+// sqlState returns the SQLSTATE code of a PostgreSQL error, or an empty string if err is not one.
+func sqlState(err error) string {
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		return pgErr.SQLState()
+	}
+	return ""
+}
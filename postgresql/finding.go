@@ -0,0 +1,319 @@
+package postgresql
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/quantonganh/ssr"
+)
+
+// replaceFindings stores findings as the findings of a scan, in place of the ones it already has.
+func replaceFindings(tx *gorm.DB, repositoryID uint64, scanID uuid.UUID, findings ssr.Findings) error {
+	if err := tx.Where("scan_id = ?", scanID).Delete(&ssr.Finding{}).Error; err != nil {
+		return errors.Wrapf(err, "failed to delete findings of scan: %s", scanID)
+	}
+	return createFindings(tx, repositoryID, scanID, findings)
+}
+
+// createFindings stores findings as the findings of a scan, marking the ones that the active suppressions
+// of the repository match as suppressed.
+func createFindings(tx *gorm.DB, repositoryID uint64, scanID uuid.UUID, findings ssr.Findings) error {
+	if len(findings) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	var suppressions []*ssr.Suppression
+	if err := tx.Scopes(activeSuppressions(repositoryID, now)).Find(&suppressions).Error; err != nil {
+		return errors.Wrapf(err, "failed to select suppressions of repository: %d", repositoryID)
+	}
+	findings.Suppress(suppressions, now)
+
+	for i := range findings {
+		findings[i].ID = 0
+		findings[i].ScanID = scanID
+	}
+	if err := tx.Create(&findings).Error; err != nil {
+		return errors.Wrapf(err, "failed to create findings of scan: %s", scanID)
+	}
+	return nil
+}
+
+// preloadFindings loads the findings of scans in the order they were stored.
+func preloadFindings(db *gorm.DB) *gorm.DB {
+	return db.Preload("Findings", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	})
+}
+
+// trackFindings records that the findings of a scan were seen in the repository at the given time.
+// A fingerprint keeps the time and scan it was first seen in, the last ones are moved forward.
+// The fingerprints that are new to the repository, or open again, are recorded in the outbox.
+func trackFindings(tx *gorm.DB, repositoryID uint64, scanID uuid.UUID, findings ssr.Findings, seenAt time.Time) error {
+	if len(findings) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(findings))
+	fingerprints := make([]string, 0, len(findings))
+	records := make([]ssr.RepositoryFinding, 0, len(findings))
+	for _, f := range findings {
+		if seen[f.Fingerprint] {
+			continue
+		}
+		seen[f.Fingerprint] = true
+		fingerprints = append(fingerprints, f.Fingerprint)
+		records = append(records, ssr.RepositoryFinding{
+			RepositoryID: repositoryID,
+			Fingerprint:  f.Fingerprint,
+			RuleID:       f.RuleID,
+			Path:         f.Location.Path,
+			FirstSeenAt:  seenAt,
+			LastSeenAt:   seenAt,
+			FirstScanID:  scanID,
+			LastScanID:   scanID,
+			Status:       ssr.FindingOpen,
+		})
+	}
+
+	// The fingerprints the repository already has are locked until the end of tx, so that their status cannot change
+	// between the time it is read here and the time it is updated below.
+	var existing []ssr.RepositoryFinding
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("repository_id = ? AND fingerprint IN ?", repositoryID, fingerprints).
+		Find(&existing).Error
+	if err != nil {
+		return errors.Wrapf(err, "failed to select findings of repository: %d", repositoryID)
+	}
+	byFingerprint := make(map[string]*ssr.RepositoryFinding, len(existing))
+	for i := range existing {
+		byFingerprint[existing[i].Fingerprint] = &existing[i]
+	}
+
+	// A fingerprint seen again keeps its triage decision, unless it was fixed: then it is open again.
+	updates := append(clause.AssignmentColumns([]string{"rule_id", "path", "last_seen_at", "last_scan_id"}), clause.Assignment{
+		Column: clause.Column{Name: "status"},
+		Value:  gorm.Expr("CASE WHEN repository_finding.status = ? THEN ? ELSE repository_finding.status END", ssr.FindingFixed, ssr.FindingOpen),
+	})
+	err = tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "repository_id"}, {Name: "fingerprint"}},
+		DoUpdates: updates,
+	}).Create(&records).Error
+	if err != nil {
+		return errors.Wrapf(err, "failed to track findings of scan: %s", scanID)
+	}
+
+	for i := range records {
+		rf := &records[i]
+		if prev, ok := byFingerprint[rf.Fingerprint]; ok {
+			if prev.Status != ssr.FindingFixed {
+				continue
+			}
+			// The fingerprint keeps the first time it was seen, and its last triage decision.
+			reopened := *prev
+			reopened.RuleID, reopened.Path = rf.RuleID, rf.Path
+			reopened.LastSeenAt, reopened.LastScanID = rf.LastSeenAt, rf.LastScanID
+			reopened.Status = ssr.FindingOpen
+			rf = &reopened
+		}
+		if err := recordFinding(tx, ssr.ChangeFindingStatusChanged, rf, seenAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveFindings marks the findings of a repository that a successful scan no longer reports as fixed, and records
+// them in the outbox. False positives and accepted risks are left alone, since there was nothing to fix.
+func resolveFindings(tx *gorm.DB, repositoryID uint64, scanID uuid.UUID, now time.Time) error {
+	var fixed []*ssr.RepositoryFinding
+	err := tx.Model(&fixed).
+		Clauses(clause.Returning{}).
+		Where("repository_id = ? AND last_scan_id <> ? AND status IN ?", repositoryID, scanID, []string{ssr.FindingOpen, ssr.FindingConfirmed}).
+		Update("status", ssr.FindingFixed).Error
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve findings of scan: %s", scanID)
+	}
+	for _, rf := range fixed {
+		if err := recordFinding(tx, ssr.ChangeFindingStatusChanged, rf, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// severityRank orders findings by severity in SQL, the same way as ssr.Severity.Rank.
+const severityRank = `CASE finding.severity WHEN 'CRITICAL' THEN 4 WHEN 'HIGH' THEN 3 WHEN 'MEDIUM' THEN 2 WHEN 'LOW' THEN 1 ELSE 0 END`
+
+// findingSortColumns maps the fields findings can be sorted on to SQL expressions.
+var findingSortColumns = map[string]string{
+	"id":       "finding.id",
+	"severity": severityRank,
+	"path":     "finding.path",
+	"rule_id":  "finding.rule_id",
+}
+
+type findingService struct {
+	db *gorm.DB
+}
+
+func NewFindingService(db *gorm.DB) ssr.FindingService {
+	return &findingService{
+		db: db,
+	}
+}
+
+func (fs *findingService) ListScanFindings(ctx context.Context, scanID uuid.UUID, filter ssr.FindingFilter) (*ssr.FindingPage, error) {
+	var scan ssr.Scan
+	if err := fs.db.WithContext(ctx).Select("id", "repository_id").First(&scan, "id = ?", scanID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ssr.ErrScanNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to select scan: %s", scanID)
+	}
+	if err := authorizeRepository(ctx, fs.db.WithContext(ctx), scan.RepositoryID, ssr.RoleReader, ssr.ErrScanNotFound); err != nil {
+		return nil, err
+	}
+
+	db := fs.db.WithContext(ctx).Table("finding").
+		Select("finding.*, repository_finding.status").
+		Joins("LEFT JOIN repository_finding ON repository_finding.repository_id = ? AND repository_finding.fingerprint = finding.fingerprint", scan.RepositoryID).
+		Where("finding.scan_id = ?", scanID)
+	page, err := listFindings(db, filter)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list findings of scan: %s", scanID)
+	}
+	return page, nil
+}
+
+func (fs *findingService) ListRepositoryFindings(ctx context.Context, repositoryID uint64, filter ssr.FindingFilter) (*ssr.FindingPage, error) {
+	if err := authorizeRepository(ctx, fs.db.WithContext(ctx), repositoryID, ssr.RoleReader, ssr.ErrRepositoryNotFound); err != nil {
+		return nil, err
+	}
+	if err := fs.db.WithContext(ctx).Select("id").First(&ssr.Repository{}, repositoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ssr.ErrRepositoryNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to select repository: %d", repositoryID)
+	}
+
+	// Each fingerprint is listed as the finding of the last scan that reported it.
+	db := fs.db.WithContext(ctx).Table("repository_finding").
+		Select("finding.*, repository_finding.status").
+		Joins("JOIN finding ON finding.scan_id = repository_finding.last_scan_id AND finding.fingerprint = repository_finding.fingerprint").
+		Where("repository_finding.repository_id = ?", repositoryID)
+	page, err := listFindings(db, filter)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list findings of repository: %d", repositoryID)
+	}
+	return page, nil
+}
+
+func (fs *findingService) TriageFinding(ctx context.Context, repositoryID uint64, fingerprint string, triage ssr.Triage) (*ssr.RepositoryFinding, error) {
+	var rf ssr.RepositoryFinding
+	err := fs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := authorizeRepository(ctx, tx, repositoryID, ssr.RoleMaintainer, ssr.ErrFindingNotFound); err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&rf, "repository_id = ? AND fingerprint = ?", repositoryID, fingerprint).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ssr.ErrFindingNotFound
+			}
+			return errors.Wrapf(err, "failed to select finding: %s", fingerprint)
+		}
+
+		rf.Status = triage.Status
+		rf.TriageReason = triage.Reason
+		rf.TriagedBy = triage.Actor
+		rf.TriagedAt = time.Now()
+		if err := tx.Model(&rf).Select("status", "triage_reason", "triaged_by", "triaged_at").Updates(&rf).Error; err != nil {
+			return errors.Wrapf(err, "failed to triage finding: %s", fingerprint)
+		}
+		return recordFinding(tx, ssr.ChangeFindingTriaged, &rf, rf.TriagedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rf, nil
+}
+
+// listFindings applies filter to a query of findings and returns the page it selects.
+// Pages are cursor based: the sort column and the id of the last finding of a page are where the next page starts.
+func listFindings(db *gorm.DB, filter ssr.FindingFilter) (*ssr.FindingPage, error) {
+	field, desc, err := ssr.ParseFindingSort(filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Type != "" {
+		db = db.Where("finding.type = ?", filter.Type)
+	}
+	if filter.RuleID != "" {
+		db = db.Where("finding.rule_id = ?", filter.RuleID)
+	}
+	if filter.Severity != "" {
+		db = db.Where("finding.severity = ?", filter.Severity)
+	}
+	if filter.PathPrefix != "" {
+		db = db.Where(`finding.path LIKE ? ESCAPE '\'`, likePrefix(filter.PathPrefix))
+	}
+	if filter.Status != "" {
+		db = db.Where("repository_finding.status = ?", filter.Status)
+	}
+	if filter.Suppressed != nil {
+		db = db.Where("finding.suppressed = ?", *filter.Suppressed)
+	}
+
+	column := findingSortColumns[field]
+	direction, op := "ASC", ">"
+	if desc {
+		direction, op = "DESC", "<"
+	}
+	if after := filter.After; after != nil {
+		if field == "id" {
+			db = db.Where("finding.id "+op+" ?", after.ID)
+		} else {
+			var value interface{} = after.Value
+			if field == "severity" {
+				value = ssr.Severity(after.Value).Rank()
+			}
+			db = db.Where("("+column+", finding.id) "+op+" (?, ?)", value, after.ID)
+		}
+	}
+	if field != "id" {
+		db = db.Order(column + " " + direction)
+	}
+	db = db.Order("finding.id " + direction)
+	if filter.Limit > 0 {
+		// One more finding tells whether there is a next page.
+		db = db.Limit(filter.Limit + 1)
+	}
+
+	findings := ssr.Findings{}
+	if err := db.Find(&findings).Error; err != nil {
+		return nil, err
+	}
+
+	page := &ssr.FindingPage{Findings: findings}
+	if filter.Limit > 0 && len(findings) > filter.Limit {
+		page.Findings = findings[:filter.Limit]
+		last := page.Findings[filter.Limit-1]
+		page.NextCursor = ssr.FindingCursor{
+			Sort:  filter.Sort,
+			Value: last.SortValue(field),
+			ID:    last.ID,
+		}.Encode()
+	}
+	return page, nil
+}
+
+// likePrefix escapes the wildcards of prefix and turns it into a LIKE pattern.
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix) + "%"
+}
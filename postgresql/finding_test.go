@@ -0,0 +1,169 @@
+// +build !integration
+
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/quantonganh/ssr"
+)
+
+const (
+	sqlSelectScanRepository   = `SELECT "id","repository_id" FROM "scan" WHERE id = $1 ORDER BY "scan"."id" LIMIT 1`
+	sqlListScanFindings       = `SELECT finding.*, repository_finding.status FROM "finding" LEFT JOIN repository_finding ON repository_finding.repository_id = $1 AND repository_finding.fingerprint = finding.fingerprint WHERE finding.scan_id = $2 AND finding.rule_id = $3 AND finding.path LIKE $4 ESCAPE '\' AND (CASE finding.severity WHEN 'CRITICAL' THEN 4 WHEN 'HIGH' THEN 3 WHEN 'MEDIUM' THEN 2 WHEN 'LOW' THEN 1 ELSE 0 END, finding.id) < ($5, $6) ORDER BY CASE finding.severity WHEN 'CRITICAL' THEN 4 WHEN 'HIGH' THEN 3 WHEN 'MEDIUM' THEN 2 WHEN 'LOW' THEN 1 ELSE 0 END DESC,finding.id DESC LIMIT 2`
+	sqlSelectRepositoryID     = `SELECT "id" FROM "repository" WHERE "repository"."id" = $1 ORDER BY "repository"."id" LIMIT 1`
+	sqlSelectFindingForUpdate = `SELECT * FROM "repository_finding" WHERE repository_id = $1 AND fingerprint = $2 ORDER BY "repository_finding"."repository_id" LIMIT 1 FOR UPDATE`
+	sqlTriageFinding          = `UPDATE "repository_finding" SET "status"=$1,"triage_reason"=$2,"triaged_by"=$3,"triaged_at"=$4 WHERE "repository_id" = $5 AND "fingerprint" = $6`
+	sqlListRepoFindings       = `SELECT finding.*, repository_finding.status FROM "repository_finding" JOIN finding ON finding.scan_id = repository_finding.last_scan_id AND finding.fingerprint = repository_finding.fingerprint WHERE (repository_finding.repository_id = $1) AND (repository_finding.status = $2) ORDER BY finding.id ASC LIMIT 11`
+)
+
+func TestFindingService(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	findingService := NewFindingService(gormDB)
+	columns := []string{"id", "scan_id", "type", "rule_id", "path", "severity", "fingerprint", "status"}
+
+	t.Run("list scan findings", func(t *testing.T) {
+		scanID := uuid.New()
+		mock.ExpectQuery(sqlSelectScanRepository).
+			WithArgs(scanID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "repository_id"}).AddRow(scanID, 1))
+		mock.ExpectQuery(sqlListScanFindings).
+			WithArgs(1, scanID, "G402", "connectors/%", ssr.SeverityCritical.Rank(), 7).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(6, scanID, "sast", "G402", "connectors/tls.go", "HIGH", "a", ssr.FindingOpen).
+				AddRow(4, scanID, "sast", "G402", "connectors/http.go", "HIGH", "b", ssr.FindingOpen))
+
+		page, err := findingService.ListScanFindings(context.Background(), scanID, ssr.FindingFilter{
+			RuleID:     "G402",
+			PathPrefix: "connectors/",
+			Sort:       "-severity",
+			After:      &ssr.FindingCursor{Sort: "-severity", Value: "CRITICAL", ID: 7},
+			Limit:      1,
+		})
+		require.NoError(t, err)
+		require.Len(t, page.Findings, 1)
+		assert.Equal(t, uint64(6), page.Findings[0].ID)
+		assert.Equal(t, ssr.FindingOpen, page.Findings[0].Status)
+
+		cursor, err := ssr.DecodeFindingCursor(page.NextCursor)
+		require.NoError(t, err)
+		assert.Equal(t, ssr.FindingCursor{Sort: "-severity", Value: "HIGH", ID: 6}, *cursor)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list scan findings of unknown scan", func(t *testing.T) {
+		scanID := uuid.New()
+		mock.ExpectQuery(sqlSelectScanRepository).
+			WithArgs(scanID).
+			WillReturnError(gorm.ErrRecordNotFound)
+
+		_, err := findingService.ListScanFindings(context.Background(), scanID, ssr.FindingFilter{Limit: 10})
+		assert.ErrorIs(t, err, ssr.ErrScanNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list repository findings", func(t *testing.T) {
+		scanID := uuid.New()
+		mock.ExpectQuery(sqlSelectRepositoryID).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(sqlListRepoFindings).
+			WithArgs(1, ssr.FindingFixed).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(3, scanID, "sast", "G404", "util/util.go", "MEDIUM", "c", ssr.FindingFixed))
+
+		page, err := findingService.ListRepositoryFindings(context.Background(), 1, ssr.FindingFilter{Status: ssr.FindingFixed, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, page.Findings, 1)
+		assert.Equal(t, ssr.FindingFixed, page.Findings[0].Status)
+		assert.Empty(t, page.NextCursor)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list findings of unknown repository", func(t *testing.T) {
+		mock.ExpectQuery(sqlSelectRepositoryID).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := findingService.ListRepositoryFindings(context.Background(), 2, ssr.FindingFilter{Limit: 10})
+		assert.ErrorIs(t, err, ssr.ErrRepositoryNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTriageFinding(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	findingService := NewFindingService(gormDB)
+	triage := ssr.Triage{
+		Status: ssr.FindingFalsePositive,
+		Reason: "test fixture",
+		Actor:  "alice@example.com",
+	}
+
+	t.Run("triage finding", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(sqlSelectFindingForUpdate).
+			WithArgs(1, "abc").
+			WillReturnRows(sqlmock.NewRows([]string{"repository_id", "fingerprint", "rule_id", "status"}).AddRow(1, "abc", "G404", ssr.FindingOpen))
+		mock.ExpectExec(sqlTriageFinding).
+			WithArgs(triage.Status, triage.Reason, triage.Actor, sqlmock.AnyArg(), 1, "abc").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(sqlInsertChange).
+			WithArgs(sqlmock.AnyArg(), ssr.ChangeFindingTriaged, "abc", 1, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		rf, err := findingService.TriageFinding(context.Background(), 1, "abc", triage)
+		require.NoError(t, err)
+		assert.Equal(t, ssr.FindingFalsePositive, rf.Status)
+		assert.Equal(t, "G404", rf.RuleID)
+		assert.Equal(t, "alice@example.com", rf.TriagedBy)
+		assert.False(t, rf.TriagedAt.IsZero())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("triage unknown finding", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(sqlSelectFindingForUpdate).
+			WithArgs(1, "def").
+			WillReturnRows(sqlmock.NewRows([]string{"repository_id", "fingerprint"}))
+		mock.ExpectRollback()
+
+		_, err := findingService.TriageFinding(context.Background(), 1, "def", triage)
+		assert.ErrorIs(t, err, ssr.ErrFindingNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestLikePrefix(t *testing.T) {
+	assert.Equal(t, `connectors/%`, likePrefix("connectors/"))
+	assert.Equal(t, `100\%\_done\\%`, likePrefix(`100%_done\`))
+}
@@ -0,0 +1,217 @@
+package postgresql
+
+import (
+	"context"
+	"embed"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// migrationFiles holds the versioned SQL migrations of the schema. Each version has an up and a down file,
+// named <version>_<name>.up.sql and <version>_<name>.down.sql.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migrationLock is the key of the advisory lock that serializes migrations across replicas.
+const migrationLock = 5238132
+
+const (
+	sqlCreateSchemaMigration = `CREATE TABLE IF NOT EXISTS "schema_migration" ("version" bigint PRIMARY KEY, "name" text NOT NULL, "applied_at" timestamptz NOT NULL DEFAULT now())`
+	sqlLockMigrations        = `SELECT pg_advisory_xact_lock(?)`
+)
+
+// Migration is a version of the schema.
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus tells whether a migration has been applied to the database.
+type MigrationStatus struct {
+	Migration
+	// AppliedAt is nil for a pending migration.
+	AppliedAt *time.Time
+}
+
+// schemaMigration records an applied migration.
+type schemaMigration struct {
+	Version   uint64
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migration"
+}
+
+// Migrator applies and reverts the embedded migrations, recording the applied ones in the schema_migration table.
+// It holds an advisory lock while it runs, so that replicas starting at once do not migrate concurrently.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// loadMigrations reads the migrations of fsys sorted by version.
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list migrations")
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, path := range names {
+		m := migrationFileName.FindStringSubmatch(path[len("migrations/"):])
+		if m == nil {
+			return nil, errors.Errorf("invalid migration file name: %s", path)
+		}
+		version, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid migration version: %s", path)
+		}
+		b, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read migration: %s", path)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != m[2] {
+			return nil, errors.Errorf("migrations %s and %s have the same version", migration.Name, m[2])
+		}
+		if m[3] == "up" {
+			migration.Up = string(b)
+		} else {
+			migration.Down = string(b)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, errors.Errorf("migration %d_%s must have an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Up applies the pending migrations in order and returns them. They are applied in a single transaction,
+// so that a failing migration leaves the schema as it was.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(tx *gorm.DB, done map[uint64]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if err := tx.Exec(migration.Up).Error; err != nil {
+				return errors.Wrapf(err, "failed to apply migration %d_%s", migration.Version, migration.Name)
+			}
+			if err := tx.Select("version", "name").Create(&schemaMigration{Version: migration.Version, Name: migration.Name}).Error; err != nil {
+				return errors.Wrapf(err, "failed to record migration %d_%s", migration.Version, migration.Name)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// Down reverts the last applied migration and returns it, or nil if no migration has been applied.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var reverted *Migration
+	err := m.locked(ctx, func(tx *gorm.DB, done map[uint64]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			if err := tx.Exec(migration.Down).Error; err != nil {
+				return errors.Wrapf(err, "failed to revert migration %d_%s", migration.Version, migration.Name)
+			}
+			if err := tx.Delete(&schemaMigration{}, "version = ?", migration.Version).Error; err != nil {
+				return errors.Wrapf(err, "failed to unrecord migration %d_%s", migration.Version, migration.Name)
+			}
+			reverted = &migration
+			return nil
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reverted, nil
+}
+
+// Status lists every migration along with when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.locked(ctx, func(tx *gorm.DB, done map[uint64]time.Time) error {
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Migration: migration}
+			if appliedAt, ok := done[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// locked runs fn in a transaction that holds the migration lock, passing it when each applied migration was applied.
+func (m *Migrator) locked(ctx context.Context, fn func(tx *gorm.DB, done map[uint64]time.Time) error) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(sqlLockMigrations, migrationLock).Error; err != nil {
+			return errors.Wrap(err, "failed to lock migrations")
+		}
+		if err := tx.Exec(sqlCreateSchemaMigration).Error; err != nil {
+			return errors.Wrap(err, "failed to create schema_migration table")
+		}
+
+		var rows []schemaMigration
+		if err := tx.Order("version").Find(&rows).Error; err != nil {
+			return errors.Wrap(err, "failed to select applied migrations")
+		}
+		done := make(map[uint64]time.Time, len(rows))
+		for _, row := range rows {
+			done[row.Version] = row.AppliedAt
+		}
+
+		return fn(tx, done)
+	})
+}
@@ -0,0 +1,162 @@
+// +build !integration
+
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const (
+	sqlLockMigrationsQuery   = `SELECT pg_advisory_xact_lock($1)`
+	sqlSelectSchemaMigration = `SELECT * FROM "schema_migration" ORDER BY version`
+	sqlInsertSchemaMigration = `INSERT INTO "schema_migration" ("version","name") VALUES ($1,$2)`
+	sqlDeleteSchemaMigration = `DELETE FROM "schema_migration" WHERE version = $1`
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	for i, m := range migrations {
+		assert.Equal(t, uint64(i+1), m.Version)
+		assert.NotEmpty(t, m.Up)
+		assert.NotEmpty(t, m.Down)
+	}
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"missing down": {
+			"migrations/0001_init.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"duplicate version": {
+			"migrations/0001_init.up.sql":    {Data: []byte("SELECT 1;")},
+			"migrations/0001_init.down.sql":  {Data: []byte("SELECT 1;")},
+			"migrations/0001_other.up.sql":   {Data: []byte("SELECT 1;")},
+			"migrations/0001_other.down.sql": {Data: []byte("SELECT 1;")},
+		},
+		"invalid name": {
+			"migrations/init.sql": {Data: []byte("SELECT 1;")},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadMigrations(fsys)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	m := &Migrator{
+		db: gormDB,
+		migrations: []Migration{
+			{Version: 1, Name: "init", Up: "CREATE TABLE a (id bigint);", Down: "DROP TABLE a;"},
+			{Version: 2, Name: "add_b", Up: "CREATE TABLE b (id bigint);", Down: "DROP TABLE b;"},
+		},
+	}
+	appliedAt := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	expectLock := func(applied ...uint64) {
+		mock.ExpectBegin()
+		mock.ExpectExec(sqlLockMigrationsQuery).
+			WithArgs(migrationLock).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(sqlCreateSchemaMigration).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		rows := sqlmock.NewRows([]string{"version", "name", "applied_at"})
+		for _, v := range applied {
+			rows.AddRow(v, m.migrations[v-1].Name, appliedAt)
+		}
+		mock.ExpectQuery(sqlSelectSchemaMigration).
+			WillReturnRows(rows)
+	}
+
+	t.Run("up", func(t *testing.T) {
+		expectLock(1)
+		mock.ExpectExec("CREATE TABLE b (id bigint);").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(sqlInsertSchemaMigration).
+			WithArgs(2, "add_b").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		applied, err := m.Up(context.Background())
+		require.NoError(t, err)
+		require.Len(t, applied, 1)
+		assert.Equal(t, uint64(2), applied[0].Version)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("up rolls back a failing migration", func(t *testing.T) {
+		expectLock()
+		mock.ExpectExec("CREATE TABLE a (id bigint);").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(sqlInsertSchemaMigration).
+			WithArgs(1, "init").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("CREATE TABLE b (id bigint);").
+			WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		_, err := m.Up(context.Background())
+		assert.ErrorIs(t, err, assert.AnError)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("down", func(t *testing.T) {
+		expectLock(1, 2)
+		mock.ExpectExec("DROP TABLE b;").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(sqlDeleteSchemaMigration).
+			WithArgs(2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		reverted, err := m.Down(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, reverted)
+		assert.Equal(t, uint64(2), reverted.Version)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("down without applied migration", func(t *testing.T) {
+		expectLock()
+		mock.ExpectCommit()
+
+		reverted, err := m.Down(context.Background())
+		require.NoError(t, err)
+		assert.Nil(t, reverted)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("status", func(t *testing.T) {
+		expectLock(1)
+		mock.ExpectCommit()
+
+		statuses, err := m.Status(context.Background())
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+		require.NotNil(t, statuses[0].AppliedAt)
+		assert.True(t, appliedAt.Equal(*statuses[0].AppliedAt))
+		assert.Nil(t, statuses[1].AppliedAt)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "scan";
+DROP TABLE IF EXISTS "repository";
@@ -0,0 +1,21 @@
+-- The schema as created by AutoMigrate in the first release, so that existing databases are adopted as they are.
+-- The later migrations add what came after it without failing on the tables and columns that are already there.
+CREATE TABLE IF NOT EXISTS "repository" (
+    "id" bigserial,
+    "provider" text,
+    "full_name" text,
+    "description" text,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "scan" (
+    "id" uuid,
+    "status" bigint,
+    "repository_id" bigint,
+    "findings" bytea,
+    "queued_at" timestamptz,
+    "scanning_at" timestamptz,
+    "finished_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_scan_repository" FOREIGN KEY ("repository_id") REFERENCES "repository"("id")
+);
@@ -0,0 +1,4 @@
+DROP INDEX "idx_scan_lease_expires_at";
+ALTER TABLE "scan" DROP COLUMN "lease_expires_at";
+ALTER TABLE "scan" DROP COLUMN "lease_owner";
+ALTER TABLE "scan" DROP COLUMN "attempts";
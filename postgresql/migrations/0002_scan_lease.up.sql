@@ -0,0 +1,4 @@
+ALTER TABLE "scan" ADD COLUMN IF NOT EXISTS "attempts" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "scan" ADD COLUMN IF NOT EXISTS "lease_owner" text;
+ALTER TABLE "scan" ADD COLUMN IF NOT EXISTS "lease_expires_at" timestamptz;
+CREATE INDEX IF NOT EXISTS "idx_scan_lease_expires_at" ON "scan" ("lease_expires_at");
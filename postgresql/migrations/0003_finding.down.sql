@@ -0,0 +1,2 @@
+DROP TABLE "repository_finding";
+DROP TABLE "finding";
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS "finding" (
+    "id" bigserial,
+    "scan_id" uuid NOT NULL,
+    "type" text,
+    "rule_id" text,
+    "tool" text,
+    "path" text,
+    "begin_line" bigint,
+    "begin_column" bigint,
+    "end_line" bigint,
+    "end_column" bigint,
+    "snippet" text,
+    "description" text,
+    "severity" text,
+    "rule_name" text,
+    "help_uri" text,
+    "fingerprint" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_scan_findings" FOREIGN KEY ("scan_id") REFERENCES "scan"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_finding_fingerprint" ON "finding" ("fingerprint");
+CREATE INDEX IF NOT EXISTS "idx_finding_scan_id" ON "finding" ("scan_id");
+
+CREATE TABLE IF NOT EXISTS "repository_finding" (
+    "repository_id" bigint,
+    "fingerprint" text,
+    "rule_id" text,
+    "path" text,
+    "first_seen_at" timestamptz,
+    "last_seen_at" timestamptz,
+    "first_scan_id" uuid,
+    "last_scan_id" uuid,
+    PRIMARY KEY ("repository_id", "fingerprint")
+);
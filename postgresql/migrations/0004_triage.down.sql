@@ -0,0 +1,5 @@
+DROP INDEX "idx_repository_finding_status";
+ALTER TABLE "repository_finding" DROP COLUMN "triaged_at";
+ALTER TABLE "repository_finding" DROP COLUMN "triaged_by";
+ALTER TABLE "repository_finding" DROP COLUMN "triage_reason";
+ALTER TABLE "repository_finding" DROP COLUMN "status";
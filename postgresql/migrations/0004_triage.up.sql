@@ -0,0 +1,5 @@
+ALTER TABLE "repository_finding" ADD COLUMN IF NOT EXISTS "status" text NOT NULL DEFAULT 'open';
+ALTER TABLE "repository_finding" ADD COLUMN IF NOT EXISTS "triage_reason" text;
+ALTER TABLE "repository_finding" ADD COLUMN IF NOT EXISTS "triaged_by" text;
+ALTER TABLE "repository_finding" ADD COLUMN IF NOT EXISTS "triaged_at" timestamptz;
+CREATE INDEX IF NOT EXISTS "idx_repository_finding_status" ON "repository_finding" ("status");
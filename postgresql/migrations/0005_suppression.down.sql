@@ -0,0 +1,4 @@
+DROP INDEX "idx_finding_suppression_id";
+ALTER TABLE "finding" DROP COLUMN "suppression_id";
+ALTER TABLE "finding" DROP COLUMN "suppressed";
+DROP TABLE "suppression";
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS "suppression" (
+    "id" bigserial,
+    "repository_id" bigint NOT NULL,
+    "rule_id" text,
+    "path_prefix" text,
+    "reason" text,
+    "created_by" text,
+    "created_at" timestamptz,
+    "expires_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_suppression_expires_at" ON "suppression" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_suppression_repository_id" ON "suppression" ("repository_id");
+
+ALTER TABLE "finding" ADD COLUMN IF NOT EXISTS "suppressed" boolean NOT NULL DEFAULT false;
+ALTER TABLE "finding" ADD COLUMN IF NOT EXISTS "suppression_id" bigint;
+CREATE INDEX IF NOT EXISTS "idx_finding_suppression_id" ON "finding" ("suppression_id");
@@ -0,0 +1,2 @@
+ALTER TABLE "scan" DROP COLUMN "branch";
+ALTER TABLE "repository" DROP COLUMN "default_branch";
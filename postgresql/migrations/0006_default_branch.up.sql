@@ -0,0 +1,2 @@
+ALTER TABLE "repository" ADD COLUMN IF NOT EXISTS "default_branch" text;
+ALTER TABLE "scan" ADD COLUMN IF NOT EXISTS "branch" text;
@@ -0,0 +1,2 @@
+ALTER TABLE "scan" DROP COLUMN "gate";
+DROP TABLE "policy";
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS "policy" (
+    "id" bigserial,
+    "repository_id" bigint,
+    "name" text,
+    "severity" text,
+    "rule_id" text,
+    "new_only" boolean,
+    "max_findings" bigint,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_policy_repository_id" ON "policy" ("repository_id");
+
+ALTER TABLE "scan" ADD COLUMN IF NOT EXISTS "gate" jsonb;
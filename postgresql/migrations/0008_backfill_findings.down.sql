@@ -0,0 +1,3 @@
+-- The findings are still in the findings column of scan, only their copies are deleted.
+DELETE FROM "repository_finding" WHERE "first_scan_id" IN (SELECT "id" FROM "scan" WHERE "findings" IS NOT NULL);
+DELETE FROM "finding" WHERE "scan_id" IN (SELECT "id" FROM "scan" WHERE "findings" IS NOT NULL);
@@ -0,0 +1,82 @@
+-- Copy the findings that the first release stored as JSON in the findings column of scan into the finding table,
+-- fingerprinted like ssr.Findings.Fingerprint does, and track their fingerprints like trackFindings does.
+-- Databases upgraded by a release that ran AutoMigrate have already moved them and dropped the column.
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'scan' AND column_name = 'findings'
+    ) THEN
+        RETURN;
+    END IF;
+
+    WITH "scan_findings" AS (
+        -- Scans that did not finish have a zero finished_at, their findings were seen when they were queued.
+        SELECT "id", "repository_id", greatest("queued_at", "finished_at") AS "seen_at",
+            convert_from("findings", 'UTF8')::jsonb AS "findings"
+        FROM "scan"
+        WHERE "findings" IS NOT NULL
+    ), "element" AS (
+        SELECT "s"."id" AS "scan_id", "e"."ordinality",
+            coalesce("e"."value"->>'type', '') AS "type",
+            coalesce("e"."value"->>'rule_id', '') AS "rule_id",
+            coalesce("e"."value"->>'tool', '') AS "tool",
+            coalesce("e"."value"->'location'->>'path', '') AS "path",
+            coalesce(("e"."value"->'location'->'Positions'->'begin'->>'line')::bigint, 0) AS "begin_line",
+            coalesce(("e"."value"->'location'->'Positions'->'begin'->>'column')::bigint, 0) AS "begin_column",
+            coalesce(("e"."value"->'location'->'Positions'->'end'->>'line')::bigint, 0) AS "end_line",
+            coalesce(("e"."value"->'location'->'Positions'->'end'->>'column')::bigint, 0) AS "end_column",
+            coalesce("e"."value"->'location'->>'snippet', '') AS "snippet",
+            coalesce("e"."value"->'metadata'->>'description', '') AS "description",
+            coalesce("e"."value"->'metadata'->>'severity', '') AS "severity",
+            coalesce("e"."value"->'metadata'->>'name', '') AS "rule_name",
+            coalesce("e"."value"->'metadata'->>'help_uri', '') AS "help_uri"
+        FROM "scan_findings" AS "s",
+            jsonb_array_elements(CASE jsonb_typeof("s"."findings") WHEN 'array' THEN "s"."findings" ELSE '[]' END) WITH ORDINALITY AS "e"
+    ), "context" AS (
+        -- The snippet without line number prefixes, indentation and repeated spaces, see ssr.NormalizeSnippet.
+        SELECT "element".*, coalesce((
+            SELECT string_agg("l"."line", E'\n' ORDER BY "l"."n")
+            FROM (
+                SELECT btrim(regexp_replace(regexp_replace(regexp_replace("line", '^\s+|\s+$', '', 'g'), '^\d+:\s?', ''), '\s+', ' ', 'g')) AS "line", "n"
+                FROM regexp_split_to_table("element"."snippet", E'\n') WITH ORDINALITY AS "s"("line", "n")
+            ) AS "l"
+            WHERE "l"."line" <> ''
+        ), '') AS "normalized_snippet"
+        FROM "element"
+    ), "base" AS (
+        SELECT "context".*,
+            convert_to("tool", 'UTF8') || '\x00'::bytea || convert_to("rule_id", 'UTF8') || '\x00'::bytea ||
+            convert_to("path", 'UTF8') || '\x00'::bytea ||
+            CASE WHEN "normalized_snippet" <> '' THEN convert_to("normalized_snippet", 'UTF8')
+                ELSE convert_to("rule_name", 'UTF8') || '\x00'::bytea || convert_to("description", 'UTF8')
+            END AS "base"
+        FROM "context"
+    ), "inserted" AS (
+        -- Findings sharing a base are told apart by their order of appearance in the file.
+        INSERT INTO "finding" ("scan_id", "type", "rule_id", "tool", "path", "begin_line", "begin_column", "end_line",
+            "end_column", "snippet", "description", "severity", "rule_name", "help_uri", "fingerprint")
+        SELECT "scan_id", "type", "rule_id", "tool", "path", "begin_line", "begin_column", "end_line",
+            "end_column", "snippet", "description", "severity", "rule_name", "help_uri",
+            encode(sha256("base" || '\x00'::bytea || convert_to((
+                row_number() OVER (PARTITION BY "scan_id", "base" ORDER BY "begin_line", "ordinality")
+            )::text, 'UTF8')), 'hex')
+        FROM "base"
+        ORDER BY "scan_id", "ordinality"
+        RETURNING "scan_id", "rule_id", "path", "fingerprint"
+    )
+    INSERT INTO "repository_finding" ("repository_id", "fingerprint", "rule_id", "path", "first_seen_at", "last_seen_at",
+        "first_scan_id", "last_scan_id", "status")
+    SELECT DISTINCT ON ("s"."repository_id", "i"."fingerprint") "s"."repository_id", "i"."fingerprint",
+        last_value("i"."rule_id") OVER "w", last_value("i"."path") OVER "w",
+        first_value("s"."seen_at") OVER "w", last_value("s"."seen_at") OVER "w",
+        first_value("s"."id") OVER "w", last_value("s"."id") OVER "w", 'open'
+    FROM "inserted" AS "i"
+    JOIN "scan_findings" AS "s" ON "s"."id" = "i"."scan_id"
+    WINDOW "w" AS (
+        PARTITION BY "s"."repository_id", "i"."fingerprint" ORDER BY "s"."seen_at", "s"."id"
+        ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING
+    )
+    ON CONFLICT ("repository_id", "fingerprint") DO NOTHING;
+END
+$$;
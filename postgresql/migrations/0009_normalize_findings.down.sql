@@ -0,0 +1,6 @@
+-- The original spelling of severities and types is lost, the migration cannot be reverted.
+DO $$
+BEGIN
+    RAISE EXCEPTION 'migration 0009_normalize_findings cannot be reverted';
+END
+$$;
@@ -0,0 +1,38 @@
+-- Rewrite the severities and types stored before they were typed to their canonical values, see ssr.ParseSeverity
+-- and ssr.ParseFindingType. Severities that cannot be mapped become INFO, like in the importers, types that cannot
+-- be mapped are left as they are.
+UPDATE "finding" SET "severity" = CASE lower(btrim("severity"))
+    WHEN 'low' THEN 'LOW'
+    WHEN 'note' THEN 'LOW'
+    WHEN 'minor' THEN 'LOW'
+    WHEN 'medium' THEN 'MEDIUM'
+    WHEN 'moderate' THEN 'MEDIUM'
+    WHEN 'warning' THEN 'MEDIUM'
+    WHEN 'warn' THEN 'MEDIUM'
+    WHEN 'high' THEN 'HIGH'
+    WHEN 'error' THEN 'HIGH'
+    WHEN 'major' THEN 'HIGH'
+    WHEN 'important' THEN 'HIGH'
+    WHEN 'critical' THEN 'CRITICAL'
+    WHEN 'blocker' THEN 'CRITICAL'
+    WHEN 'fatal' THEN 'CRITICAL'
+    ELSE 'INFO'
+END
+WHERE "severity" <> '' AND "severity" NOT IN ('INFO', 'LOW', 'MEDIUM', 'HIGH', 'CRITICAL');
+
+UPDATE "finding" SET "type" = CASE lower(btrim("type"))
+    WHEN 'sast' THEN 'sast'
+    WHEN 'code' THEN 'sast'
+    WHEN 'sca' THEN 'sca'
+    WHEN 'dependency' THEN 'sca'
+    WHEN 'vulnerability' THEN 'sca'
+    WHEN 'secret' THEN 'secret'
+    WHEN 'secrets' THEN 'secret'
+    WHEN 'iac' THEN 'iac'
+    WHEN 'misconfiguration' THEN 'iac'
+    WHEN 'misconfig' THEN 'iac'
+    WHEN 'license' THEN 'license'
+    WHEN 'licence' THEN 'license'
+    ELSE "type"
+END
+WHERE "type" NOT IN ('sast', 'sca', 'secret', 'iac', 'license');
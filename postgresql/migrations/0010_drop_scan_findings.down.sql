@@ -0,0 +1,2 @@
+-- The findings stay in the finding table, the column is restored empty.
+ALTER TABLE "scan" ADD COLUMN "findings" bytea;
@@ -0,0 +1,2 @@
+-- The findings were copied into the finding table by 0008_backfill_findings.
+ALTER TABLE "scan" DROP COLUMN IF EXISTS "findings";
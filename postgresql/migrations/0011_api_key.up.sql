@@ -0,0 +1,13 @@
+CREATE TABLE "api_key" (
+    "id" bigserial,
+    "name" text,
+    "prefix" text,
+    "hash" text NOT NULL,
+    "scopes" jsonb,
+    "created_at" timestamptz,
+    "expires_at" timestamptz,
+    "last_used_at" timestamptz,
+    "revoked_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_api_key_hash" ON "api_key" ("hash");
@@ -0,0 +1,4 @@
+DROP TABLE "repository_member";
+DROP TABLE "team_member";
+DROP TABLE "team";
+DROP TABLE "user_account";
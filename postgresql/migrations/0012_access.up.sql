@@ -0,0 +1,28 @@
+CREATE TABLE "user_account" (
+    "id" text,
+    "email" text,
+    "role" text,
+    PRIMARY KEY ("id")
+);
+CREATE TABLE "team" (
+    "id" bigserial,
+    "name" text NOT NULL,
+    "role" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_team_name" ON "team" ("name");
+CREATE TABLE "team_member" (
+    "team_id" bigint,
+    "user_id" text,
+    PRIMARY KEY ("team_id", "user_id"),
+    CONSTRAINT "fk_team_member_team" FOREIGN KEY ("team_id") REFERENCES "team"("id")
+);
+CREATE TABLE "repository_member" (
+    "repository_id" bigint,
+    "team_id" bigint,
+    "role" text NOT NULL,
+    PRIMARY KEY ("repository_id", "team_id"),
+    CONSTRAINT "fk_repository_member_repository" FOREIGN KEY ("repository_id") REFERENCES "repository"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_repository_member_team" FOREIGN KEY ("team_id") REFERENCES "team"("id")
+);
@@ -0,0 +1,20 @@
+-- Reverting merges every organization into a single tenant. The users, teams and API keys of the other
+-- organizations would be granted access to all of it, so they are dropped.
+DELETE FROM "user_account" WHERE "organization_id" <> 1;
+ALTER TABLE "user_account" DROP CONSTRAINT "user_account_pkey";
+ALTER TABLE "user_account" ADD PRIMARY KEY ("id");
+ALTER TABLE "user_account" DROP COLUMN "organization_id";
+
+DROP INDEX "idx_team_organization_name";
+DELETE FROM "team_member" WHERE "team_id" IN (SELECT "id" FROM "team" WHERE "organization_id" <> 1);
+DELETE FROM "repository_member" WHERE "team_id" IN (SELECT "id" FROM "team" WHERE "organization_id" <> 1);
+DELETE FROM "team" WHERE "organization_id" <> 1;
+CREATE UNIQUE INDEX "idx_team_name" ON "team" ("name");
+ALTER TABLE "team" DROP COLUMN "organization_id";
+
+DELETE FROM "api_key" WHERE "organization_id" <> 1;
+ALTER TABLE "api_key" DROP COLUMN "organization_id";
+ALTER TABLE "policy" DROP COLUMN "organization_id";
+ALTER TABLE "repository" DROP COLUMN "organization_id";
+
+DROP TABLE "organization";
@@ -0,0 +1,34 @@
+CREATE TABLE "organization" (
+    "id" bigserial,
+    "name" text NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_organization_name" ON "organization" ("name");
+
+-- The default organization owns everything that existed before organizations.
+INSERT INTO "organization" ("id", "name", "created_at") VALUES (1, 'default', now());
+SELECT setval(pg_get_serial_sequence('organization', 'id'), 1);
+
+ALTER TABLE "repository" ADD COLUMN "organization_id" bigint NOT NULL DEFAULT 1 CONSTRAINT "fk_repository_organization" REFERENCES "organization"("id");
+ALTER TABLE "repository" ALTER COLUMN "organization_id" DROP DEFAULT;
+CREATE INDEX "idx_repository_organization_id" ON "repository" ("organization_id");
+
+ALTER TABLE "policy" ADD COLUMN "organization_id" bigint NOT NULL DEFAULT 1 CONSTRAINT "fk_policy_organization" REFERENCES "organization"("id");
+ALTER TABLE "policy" ALTER COLUMN "organization_id" DROP DEFAULT;
+UPDATE "policy" SET "organization_id" = "repository"."organization_id" FROM "repository" WHERE "repository"."id" = "policy"."repository_id";
+CREATE INDEX "idx_policy_organization_id" ON "policy" ("organization_id");
+
+ALTER TABLE "api_key" ADD COLUMN "organization_id" bigint NOT NULL DEFAULT 1 CONSTRAINT "fk_api_key_organization" REFERENCES "organization"("id");
+ALTER TABLE "api_key" ALTER COLUMN "organization_id" DROP DEFAULT;
+CREATE INDEX "idx_api_key_organization_id" ON "api_key" ("organization_id");
+
+ALTER TABLE "team" ADD COLUMN "organization_id" bigint NOT NULL DEFAULT 1 CONSTRAINT "fk_team_organization" REFERENCES "organization"("id");
+ALTER TABLE "team" ALTER COLUMN "organization_id" DROP DEFAULT;
+DROP INDEX "idx_team_name";
+CREATE UNIQUE INDEX "idx_team_organization_name" ON "team" ("organization_id", "name");
+
+ALTER TABLE "user_account" ADD COLUMN "organization_id" bigint NOT NULL DEFAULT 1 CONSTRAINT "fk_user_account_organization" REFERENCES "organization"("id");
+ALTER TABLE "user_account" ALTER COLUMN "organization_id" DROP DEFAULT;
+ALTER TABLE "user_account" DROP CONSTRAINT "user_account_pkey";
+ALTER TABLE "user_account" ADD PRIMARY KEY ("organization_id", "id");
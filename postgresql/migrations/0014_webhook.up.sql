@@ -0,0 +1,33 @@
+CREATE TABLE "webhook" (
+    "id" bigserial,
+    "organization_id" bigint NOT NULL,
+    "repository_id" bigint,
+    "url" text NOT NULL,
+    "secret" text NOT NULL,
+    "events" jsonb NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_webhook_organization" FOREIGN KEY ("organization_id") REFERENCES "organization"("id"),
+    CONSTRAINT "fk_webhook_repository" FOREIGN KEY ("repository_id") REFERENCES "repository"("id") ON DELETE CASCADE
+);
+CREATE INDEX "idx_webhook_organization_id" ON "webhook" ("organization_id");
+CREATE INDEX "idx_webhook_repository_id" ON "webhook" ("repository_id");
+CREATE TABLE "webhook_delivery" (
+    "id" bigserial,
+    "webhook_id" bigint NOT NULL,
+    "event_id" uuid NOT NULL,
+    "event" text NOT NULL,
+    "payload" jsonb NOT NULL,
+    "status" text NOT NULL,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz,
+    "response_status" bigint,
+    "error" text,
+    "created_at" timestamptz,
+    "delivered_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_webhook_delivery_webhook" FOREIGN KEY ("webhook_id") REFERENCES "webhook"("id") ON DELETE CASCADE
+);
+CREATE INDEX "idx_webhook_delivery_webhook_id" ON "webhook_delivery" ("webhook_id");
+-- Only pending deliveries are claimed, the others are kept as the log of their webhook.
+CREATE INDEX "idx_webhook_delivery_next_attempt_at" ON "webhook_delivery" ("next_attempt_at") WHERE "status" = 'pending';
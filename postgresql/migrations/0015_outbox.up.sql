@@ -0,0 +1,15 @@
+CREATE TABLE "outbox" (
+    "id" uuid,
+    "sequence" bigserial,
+    "type" text NOT NULL,
+    "key" text NOT NULL,
+    "repository_id" bigint NOT NULL,
+    "data" jsonb NOT NULL,
+    "created_at" timestamptz,
+    "published_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+-- Changes outlive their scan and repository, so that deletions are published too.
+-- Only unpublished changes are relayed, the published ones are kept until they are purged.
+CREATE INDEX "idx_outbox_sequence" ON "outbox" ("sequence") WHERE "published_at" IS NULL;
+CREATE INDEX "idx_outbox_published_at" ON "outbox" ("published_at");
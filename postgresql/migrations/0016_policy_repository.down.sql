@@ -0,0 +1 @@
+ALTER TABLE "policy" DROP CONSTRAINT "fk_policy_repository";
@@ -0,0 +1,3 @@
+-- Policies of repositories deleted before the foreign key existed are dropped with them.
+DELETE FROM "policy" WHERE "repository_id" IS NOT NULL AND "repository_id" NOT IN (SELECT "id" FROM "repository");
+ALTER TABLE "policy" ADD CONSTRAINT "fk_policy_repository" FOREIGN KEY ("repository_id") REFERENCES "repository"("id") ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+ALTER TABLE "api_key" DROP COLUMN "repository_id";
+ALTER TABLE "api_key" DROP COLUMN "role";
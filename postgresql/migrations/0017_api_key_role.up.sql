@@ -0,0 +1,5 @@
+-- Keys issued before they had a role acted as admins of their organization, they keep doing so until revoked.
+ALTER TABLE "api_key" ADD COLUMN "role" text NOT NULL DEFAULT 'admin';
+ALTER TABLE "api_key" ALTER COLUMN "role" DROP DEFAULT;
+ALTER TABLE "api_key" ADD COLUMN "repository_id" bigint CONSTRAINT "fk_api_key_repository" REFERENCES "repository"("id") ON DELETE CASCADE;
+CREATE INDEX "idx_api_key_repository_id" ON "api_key" ("repository_id");
@@ -0,0 +1 @@
+ALTER TABLE "outbox" DROP COLUMN "lease_expires_at";
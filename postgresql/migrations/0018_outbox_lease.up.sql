@@ -0,0 +1,2 @@
+-- A relay leases the changes it publishes instead of keeping them locked, see outbox.Relay.
+ALTER TABLE "outbox" ADD COLUMN "lease_expires_at" timestamptz;
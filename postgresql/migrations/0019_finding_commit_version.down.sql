@@ -0,0 +1,2 @@
+ALTER TABLE "finding" DROP COLUMN "installed_version";
+ALTER TABLE "finding" DROP COLUMN "commit";
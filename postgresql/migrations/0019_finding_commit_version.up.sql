@@ -0,0 +1,3 @@
+-- The commit of a secret and the installed version of a package used to be part of the description.
+ALTER TABLE "finding" ADD COLUMN "commit" text;
+ALTER TABLE "finding" ADD COLUMN "installed_version" text;
@@ -0,0 +1,110 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/quantonganh/ssr"
+)
+
+type organizationService struct {
+	db *gorm.DB
+}
+
+func NewOrganizationService(db *gorm.DB) ssr.OrganizationService {
+	return &organizationService{
+		db: db,
+	}
+}
+
+func (os *organizationService) Create(ctx context.Context, o *ssr.Organization) error {
+	if err := os.db.WithContext(ctx).Create(o).Error; err != nil {
+		return errors.Wrapf(err, "failed to create organization: %s", o.Name)
+	}
+	return nil
+}
+
+func (os *organizationService) GetByName(ctx context.Context, name string) (*ssr.Organization, error) {
+	var o ssr.Organization
+	if err := os.db.WithContext(ctx).Where("name = ?", name).First(&o).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ssr.ErrOrganizationNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to select organization: %s", name)
+	}
+	return &o, nil
+}
+
+func (os *organizationService) List(ctx context.Context) ([]*ssr.Organization, error) {
+	organizations := []*ssr.Organization{}
+	if err := os.db.WithContext(ctx).Order("id").Find(&organizations).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to list organizations")
+	}
+	return organizations, nil
+}
+
+// tenant returns the organization of the user of ctx. A context without user, e.g. of the worker or of the
+// command line, is not restricted to an organization.
+func tenant(ctx context.Context) (uint64, bool) {
+	u := ssr.UserFromContext(ctx)
+	if u == nil {
+		return 0, false
+	}
+	return u.OrganizationID, true
+}
+
+// organizationOf returns the organization that a new row belongs to: the one of the user of ctx, otherwise id,
+// defaulting to ssr.DefaultOrganizationID.
+func organizationOf(ctx context.Context, id uint64) uint64 {
+	if organizationID, ok := tenant(ctx); ok {
+		return organizationID
+	}
+	if id == 0 {
+		return ssr.DefaultOrganizationID
+	}
+	return id
+}
+
+// inOrganization restricts a query to the rows of the organization of the user of ctx, column being their organization.
+func inOrganization(ctx context.Context, column string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		organizationID, ok := tenant(ctx)
+		if !ok {
+			return db
+		}
+		return db.Where(column+" = ?", organizationID)
+	}
+}
+
+// inOrganizationRepositories restricts a query to the rows whose column, a repository ID, is a repository
+// of the organization of the user of ctx.
+func inOrganizationRepositories(ctx context.Context, column string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		organizationID, ok := tenant(ctx)
+		if !ok {
+			return db
+		}
+		repositories := db.Session(&gorm.Session{NewDB: true}).Model(&ssr.Repository{}).Select("id").Where("organization_id = ?", organizationID)
+		return db.Where(column+" IN (?)", repositories)
+	}
+}
+
+// requireOrganizationRepository checks that a repository belongs to the organization of the user of ctx,
+// returning notFound otherwise so that other tenants cannot tell that it exists.
+func requireOrganizationRepository(ctx context.Context, db *gorm.DB, repositoryID uint64, notFound error) error {
+	organizationID, ok := tenant(ctx)
+	if !ok {
+		return nil
+	}
+
+	var count int64
+	if err := db.Model(&ssr.Repository{}).Where("id = ? AND organization_id = ?", repositoryID, organizationID).Count(&count).Error; err != nil {
+		return errors.Wrapf(err, "failed to select repository: %d", repositoryID)
+	}
+	if count == 0 {
+		return notFound
+	}
+	return nil
+}
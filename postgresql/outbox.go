@@ -0,0 +1,135 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/quantonganh/ssr"
+)
+
+type outbox struct {
+	db    *gorm.DB
+	lease time.Duration
+}
+
+// NewOutbox returns an outbox whose relays lease the changes they publish for lease.
+func NewOutbox(db *gorm.DB, lease time.Duration) ssr.Outbox {
+	return &outbox{
+		db:    db,
+		lease: lease,
+	}
+}
+
+// Relay claims the changes in a transaction of its own and publishes them outside of it, so that no transaction is
+// kept open while sink publishes. Publishing is bounded by the lease: if the relay stops or fails before the changes
+// are marked as published, they are published again once the lease expired.
+func (o *outbox) Relay(ctx context.Context, limit int, sink ssr.Sink) (int, error) {
+	changes, err := o.claim(ctx, limit)
+	if err != nil || len(changes) == 0 {
+		return 0, err
+	}
+
+	publishCtx, cancel := context.WithTimeout(ctx, o.lease)
+	defer cancel()
+	if err := sink.Publish(publishCtx, changes); err != nil {
+		return 0, errors.Wrap(err, "failed to publish changes")
+	}
+
+	ids := make([]uuid.UUID, 0, len(changes))
+	for _, c := range changes {
+		ids = append(ids, c.ID)
+	}
+	err = o.db.WithContext(ctx).Model(&ssr.Change{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"published_at":     time.Now(),
+		"lease_expires_at": nil,
+	}).Error
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to mark changes as published")
+	}
+	return len(changes), nil
+}
+
+// claim leases the first limit unpublished changes that no other relay holds a lease on.
+func (o *outbox) claim(ctx context.Context, limit int) ([]*ssr.Change, error) {
+	now := time.Now()
+	var changes []*ssr.Change
+	err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL AND (lease_expires_at IS NULL OR lease_expires_at <= ?)", now).
+			Order("sequence").
+			Limit(limit).
+			Find(&changes).Error
+		if err != nil {
+			return errors.Wrap(err, "failed to claim unpublished changes")
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(changes))
+		for _, c := range changes {
+			ids = append(ids, c.ID)
+		}
+		if err := tx.Model(&ssr.Change{}).Where("id IN ?", ids).Update("lease_expires_at", now.Add(o.lease)).Error; err != nil {
+			return errors.Wrap(err, "failed to lease changes")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (o *outbox) Purge(ctx context.Context, before time.Time) (int64, error) {
+	result := o.db.WithContext(ctx).Where("published_at < ?", before).Delete(&ssr.Change{})
+	if err := result.Error; err != nil {
+		return 0, errors.Wrap(err, "failed to purge published changes")
+	}
+	return result.RowsAffected, nil
+}
+
+// recordScan records a change to scan in the outbox, within tx, the transaction that made it, so that the change
+// is published if and only if it is committed.
+func recordScan(tx *gorm.DB, t ssr.ChangeType, scan *ssr.Scan, now time.Time) error {
+	c, err := ssr.NewScanChange(t, scan, now)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode change of scan: %s", scan.ID)
+	}
+	if err := tx.Create(c).Error; err != nil {
+		return errors.Wrapf(err, "failed to record change of scan: %s", scan.ID)
+	}
+	return nil
+}
+
+// recordFinding records a change of type t to rf in the outbox, within tx.
+func recordFinding(tx *gorm.DB, t ssr.ChangeType, rf *ssr.RepositoryFinding, now time.Time) error {
+	c, err := ssr.NewFindingChange(t, rf, now)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode change of finding: %s", rf.Fingerprint)
+	}
+	if err := tx.Create(c).Error; err != nil {
+		return errors.Wrapf(err, "failed to record change of finding: %s", rf.Fingerprint)
+	}
+	return nil
+}
+
+// recordUnsuppressed records in the outbox, within tx, that the findings of a scan of the repository are no longer
+// suppressed by suppressionID.
+func recordUnsuppressed(tx *gorm.DB, repositoryID uint64, findings ssr.Findings, suppressionID uint64, now time.Time) error {
+	for i := range findings {
+		c, err := ssr.NewUnsuppressedChange(repositoryID, &findings[i], suppressionID, now)
+		if err != nil {
+			return errors.Wrapf(err, "failed to encode change of finding: %s", findings[i].Fingerprint)
+		}
+		if err := tx.Create(c).Error; err != nil {
+			return errors.Wrapf(err, "failed to record change of finding: %s", findings[i].Fingerprint)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+// +build !integration
+
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/quantonganh/ssr"
+)
+
+const (
+	sqlInsertChange   = `INSERT INTO "outbox" ("id","type","key","repository_id","data","created_at","published_at") VALUES ($1,$2,$3,$4,$5,$6,$7)`
+	sqlClaimChanges   = `SELECT * FROM "outbox" WHERE published_at IS NULL AND (lease_expires_at IS NULL OR lease_expires_at <= $1) ORDER BY sequence LIMIT 10 FOR UPDATE SKIP LOCKED`
+	sqlLeaseChanges   = `UPDATE "outbox" SET "lease_expires_at"=$1 WHERE id IN ($2,$3)`
+	sqlPublishChanges = `UPDATE "outbox" SET "lease_expires_at"=$1,"published_at"=$2 WHERE id IN ($3,$4)`
+	sqlPurgeChanges   = `DELETE FROM "outbox" WHERE published_at < $1`
+)
+
+type fakeSink struct {
+	published []*ssr.Change
+	err       error
+}
+
+func (s *fakeSink) Publish(ctx context.Context, changes []*ssr.Change) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.published = append(s.published, changes...)
+	return nil
+}
+
+func (s *fakeSink) Close() error {
+	return nil
+}
+
+func TestOutbox(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	o := NewOutbox(gormDB, time.Minute)
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+	claimed := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "sequence", "type", "key", "repository_id", "data"}).
+			AddRow(ids[0], 1, ssr.ChangeScanCreated, "a", 1, []byte(`{"status":0}`)).
+			AddRow(ids[1], 2, ssr.ChangeScanUpdated, "a", 1, []byte(`{"status":1}`))
+	}
+
+	// Changes are leased in a transaction of their own.
+	expectClaim := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery(sqlClaimChanges).WithArgs(sqlmock.AnyArg()).WillReturnRows(claimed())
+		mock.ExpectExec(sqlLeaseChanges).
+			WithArgs(sqlmock.AnyArg(), ids[0], ids[1]).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+	}
+
+	t.Run("relay", func(t *testing.T) {
+		expectClaim()
+		mock.ExpectExec(sqlPublishChanges).
+			WithArgs(nil, sqlmock.AnyArg(), ids[0], ids[1]).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		sink := &fakeSink{}
+		n, err := o.Relay(context.Background(), 10, sink)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		require.Len(t, sink.published, 2)
+		assert.Equal(t, ids[0], sink.published[0].ID)
+		assert.Equal(t, uint64(2), sink.published[1].Sequence)
+		assert.Equal(t, ssr.Payload(`{"status":1}`), sink.published[1].Data)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("relay to a failing sink", func(t *testing.T) {
+		expectClaim()
+
+		n, err := o.Relay(context.Background(), 10, &fakeSink{err: errors.New("broker unavailable")})
+		assert.Error(t, err)
+		assert.Zero(t, n)
+		require.NoError(t, mock.ExpectationsWereMet(), "the changes are left unpublished until their lease expires")
+	})
+
+	t.Run("relay without unpublished change", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(sqlClaimChanges).WithArgs(sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectCommit()
+
+		n, err := o.Relay(context.Background(), 10, &fakeSink{})
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		before := time.Now().Add(-time.Hour)
+		mock.ExpectExec(sqlPurgeChanges).
+			WithArgs(before).
+			WillReturnResult(sqlmock.NewResult(0, 5))
+
+		n, err := o.Purge(context.Background(), before)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), n)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRecordScan(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	scan := &ssr.Scan{
+		ID:           uuid.New(),
+		Status:       ssr.Success,
+		RepositoryID: 1,
+		Findings:     ssr.Findings{{RuleID: "G404"}},
+	}
+	var payloads []string
+	mock.ExpectExec(sqlInsertChange).
+		WithArgs(sqlmock.AnyArg(), ssr.ChangeScanUpdated, scan.ID.String(), 1, payloadArg{&payloads}, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, recordScan(gormDB, ssr.ChangeScanUpdated, scan, time.Now()))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	require.Len(t, payloads, 1)
+	var recorded ssr.Scan
+	require.NoError(t, json.Unmarshal([]byte(payloads[0]), &recorded))
+	assert.Equal(t, scan.ID, recorded.ID)
+	assert.Equal(t, ssr.Success, recorded.Status)
+	require.Len(t, recorded.Findings, 1)
+	assert.Equal(t, "G404", recorded.Findings[0].RuleID)
+}
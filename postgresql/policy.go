@@ -0,0 +1,179 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/quantonganh/ssr"
+)
+
+type policyService struct {
+	db *gorm.DB
+}
+
+func NewPolicyService(db *gorm.DB) ssr.PolicyService {
+	return &policyService{
+		db: db,
+	}
+}
+
+func (ps *policyService) Create(ctx context.Context, p *ssr.Policy) error {
+	if err := authorizePolicy(ctx, ps.db.WithContext(ctx), p.RepositoryID, ssr.ErrRepositoryNotFound); err != nil {
+		return err
+	}
+	p.OrganizationID = organizationOf(ctx, p.OrganizationID)
+	if p.RepositoryID != nil {
+		var repo ssr.Repository
+		if err := ps.db.WithContext(ctx).Select("id", "organization_id").First(&repo, *p.RepositoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ssr.ErrRepositoryNotFound
+			}
+			return errors.Wrapf(err, "failed to select repository: %d", *p.RepositoryID)
+		}
+		p.OrganizationID = repo.OrganizationID
+	}
+
+	if err := ps.db.WithContext(ctx).Create(p).Error; err != nil {
+		return errors.Wrap(err, "failed to create policy")
+	}
+	return nil
+}
+
+func (ps *policyService) Get(ctx context.Context, id uint64) (*ssr.Policy, error) {
+	var p ssr.Policy
+	if err := ps.db.WithContext(ctx).First(&p, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ssr.ErrPolicyNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to select policy: %d", id)
+	}
+	if p.RepositoryID != nil {
+		if err := authorizeRepository(ctx, ps.db.WithContext(ctx), *p.RepositoryID, ssr.RoleReader, ssr.ErrPolicyNotFound); err != nil {
+			return nil, err
+		}
+	} else if organizationID, ok := tenant(ctx); ok && p.OrganizationID != organizationID {
+		return nil, ssr.ErrPolicyNotFound
+	}
+	return &p, nil
+}
+
+func (ps *policyService) List(ctx context.Context, repositoryID uint64) ([]*ssr.Policy, error) {
+	if repositoryID != 0 {
+		if err := authorizeRepository(ctx, ps.db.WithContext(ctx), repositoryID, ssr.RoleReader, ssr.ErrRepositoryNotFound); err != nil {
+			return nil, err
+		}
+	}
+	policies := []*ssr.Policy{}
+	if err := ps.db.WithContext(ctx).Scopes(inOrganization(ctx, "organization_id"), repositoryPolicies(repositoryID)).Find(&policies).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to list policies of repository: %d", repositoryID)
+	}
+	return policies, nil
+}
+
+func (ps *policyService) Delete(ctx context.Context, id uint64) error {
+	if ssr.UserFromContext(ctx) != nil {
+		p, err := ps.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := authorizePolicy(ctx, ps.db.WithContext(ctx), p.RepositoryID, ssr.ErrPolicyNotFound); err != nil {
+			return err
+		}
+	}
+
+	result := ps.db.WithContext(ctx).Delete(&ssr.Policy{}, id)
+	if result.Error != nil {
+		return errors.Wrapf(result.Error, "failed to delete policy: %d", id)
+	}
+	if result.RowsAffected == 0 {
+		return ssr.ErrPolicyNotFound
+	}
+	return nil
+}
+
+// authorizePolicy checks that the user of ctx may manage the policies of a repository, or the global policies
+// when repositoryID is nil, which applies to every repository.
+func authorizePolicy(ctx context.Context, db *gorm.DB, repositoryID *uint64, notFound error) error {
+	if repositoryID == nil {
+		return authorize(ctx, db, ssr.RoleAdmin)
+	}
+	return authorizeRepository(ctx, db, *repositoryID, ssr.RoleMaintainer, notFound)
+}
+
+// repositoryPolicies selects the policies of a repository along with the global ones of its organization.
+func repositoryPolicies(repositoryID uint64) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if repositoryID == 0 {
+			return db.Where("repository_id IS NULL").Order("id")
+		}
+		organization := db.Session(&gorm.Session{NewDB: true}).Model(&ssr.Repository{}).Select("organization_id").Where("id = ?", repositoryID)
+		return db.Where("repository_id = ? OR (repository_id IS NULL AND organization_id = (?))", repositoryID, organization).Order("id")
+	}
+}
+
+// evaluateGate evaluates the policies of a repository against the findings of a successful scan
+// and stores the result on the scan.
+// New findings are the ones missing from the latest other successful scan of the default branch.
+func evaluateGate(tx *gorm.DB, repositoryID uint64, scanID uuid.UUID, findings ssr.Findings, now time.Time) (*ssr.Gate, error) {
+	var policies []*ssr.Policy
+	if err := tx.Scopes(repositoryPolicies(repositoryID)).Find(&policies).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to list policies of repository: %d", repositoryID)
+	}
+
+	baseline := make(map[string]bool)
+	if len(policies) > 0 {
+		var dismissed []ssr.RepositoryFinding
+		err := tx.Select("fingerprint", "status").
+			Where("repository_id = ? AND status IN ?", repositoryID, []string{ssr.FindingFalsePositive, ssr.FindingAcceptedRisk}).
+			Find(&dismissed).Error
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to select dismissed findings of repository: %d", repositoryID)
+		}
+		statuses := make(map[string]string, len(dismissed))
+		for _, rf := range dismissed {
+			statuses[rf.Fingerprint] = rf.Status
+		}
+		// The statuses are set on a copy, the findings of the caller are published as they were reported.
+		findings = append(ssr.Findings(nil), findings...)
+		for i := range findings {
+			if status, ok := statuses[findings[i].Fingerprint]; ok {
+				findings[i].Status = status
+			}
+		}
+	}
+	if hasNewOnly(policies) {
+		base := tx.Model(&ssr.Scan{}).
+			Select("scan.id").
+			Joins("JOIN repository ON repository.id = scan.repository_id").
+			Where("scan.repository_id = ? AND scan.status = ? AND scan.id <> ?", repositoryID, ssr.Success, scanID).
+			Where("scan.branch = repository.default_branch OR scan.branch = ''").
+			Order("scan.finished_at DESC").
+			Limit(1)
+		var fingerprints []string
+		if err := tx.Model(&ssr.Finding{}).Where("scan_id = (?)", base).Pluck("fingerprint", &fingerprints).Error; err != nil {
+			return nil, errors.Wrapf(err, "failed to select baseline findings of scan: %s", scanID)
+		}
+		for _, fp := range fingerprints {
+			baseline[fp] = true
+		}
+	}
+
+	gate := ssr.EvaluatePolicies(policies, findings, baseline, now)
+	if err := tx.Model(&ssr.Scan{}).Where("id = ?", scanID).Update("gate", gate).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to store gate of scan: %s", scanID)
+	}
+	return gate, nil
+}
+
+func hasNewOnly(policies []*ssr.Policy) bool {
+	for _, p := range policies {
+		if p.NewOnly {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+// +build !integration
+
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/quantonganh/ssr"
+)
+
+const (
+	sqlInsertPolicy                 = `INSERT INTO "policy" ("organization_id","repository_id","name","severity","rule_id","new_only","max_findings","created_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING "id"`
+	sqlSelectRepositoryOrganization = `SELECT "id","organization_id" FROM "repository" WHERE "repository"."id" = $1 ORDER BY "repository"."id" LIMIT 1`
+	sqlSelectGlobalPolicies         = `SELECT * FROM "policy" WHERE repository_id IS NULL ORDER BY id`
+	sqlDeletePolicy                 = `DELETE FROM "policy" WHERE "policy"."id" = $1`
+	sqlSelectBaseline               = `SELECT "fingerprint" FROM "finding" WHERE scan_id = (SELECT scan.id FROM "scan" JOIN repository ON repository.id = scan.repository_id WHERE (scan.repository_id = $1 AND scan.status = $2 AND scan.id <> $3) AND (scan.branch = repository.default_branch OR scan.branch = '') ORDER BY scan.finished_at DESC LIMIT 1)`
+)
+
+func TestPolicyService(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	policyService := NewPolicyService(gormDB)
+
+	t.Run("create repository policy", func(t *testing.T) {
+		repositoryID := uint64(1)
+		p := &ssr.Policy{
+			RepositoryID: &repositoryID,
+			Name:         "no new high",
+			Severity:     "HIGH",
+			NewOnly:      true,
+		}
+		mock.ExpectQuery(sqlSelectRepositoryOrganization).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "organization_id"}).AddRow(1, 2))
+		mock.ExpectQuery(sqlInsertPolicy).
+			WithArgs(2, 1, "no new high", "HIGH", "", true, 0, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+		require.NoError(t, policyService.Create(context.Background(), p))
+		assert.Equal(t, uint64(2), p.ID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("create global policy", func(t *testing.T) {
+		mock.ExpectQuery(sqlInsertPolicy).
+			WithArgs(ssr.DefaultOrganizationID, nil, "few medium", "MEDIUM", "", false, 10, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+		require.NoError(t, policyService.Create(context.Background(), &ssr.Policy{Name: "few medium", Severity: "MEDIUM", MaxFindings: 10}))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("create policy in unknown repository", func(t *testing.T) {
+		repositoryID := uint64(4)
+		mock.ExpectQuery(sqlSelectRepositoryOrganization).
+			WithArgs(4).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		err := policyService.Create(context.Background(), &ssr.Policy{RepositoryID: &repositoryID, Name: "no high"})
+		assert.ErrorIs(t, err, ssr.ErrRepositoryNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list policies of repository", func(t *testing.T) {
+		mock.ExpectQuery(sqlSelectRepositoryPolicies).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "repository_id", "name"}).AddRow(2, 1, "no new high").AddRow(3, nil, "few medium"))
+
+		policies, err := policyService.List(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, policies, 2)
+		assert.Nil(t, policies[1].RepositoryID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list global policies", func(t *testing.T) {
+		mock.ExpectQuery(sqlSelectGlobalPolicies).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "few medium"))
+
+		policies, err := policyService.List(context.Background(), 0)
+		require.NoError(t, err)
+		assert.Len(t, policies, 1)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("delete unknown policy", func(t *testing.T) {
+		mock.ExpectExec(sqlDeletePolicy).
+			WithArgs(5).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.ErrorIs(t, policyService.Delete(context.Background(), 5), ssr.ErrPolicyNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("evaluate gate", func(t *testing.T) {
+		scanID := uuid.New()
+		findings := ssr.Findings{
+			{RuleID: "G402", Metadata: ssr.Metadata{Severity: "HIGH"}, Fingerprint: "a"},
+			{RuleID: "G404", Metadata: ssr.Metadata{Severity: "HIGH"}, Fingerprint: "b"},
+			{RuleID: "G101", Metadata: ssr.Metadata{Severity: "HIGH"}, Fingerprint: "c"},
+		}
+		mock.ExpectQuery(sqlSelectRepositoryPolicies).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "severity", "new_only", "max_findings"}).AddRow(2, "no new high", "HIGH", true, 0))
+		mock.ExpectQuery(sqlSelectDismissedFindings).
+			WithArgs(1, ssr.FindingFalsePositive, ssr.FindingAcceptedRisk).
+			WillReturnRows(sqlmock.NewRows([]string{"fingerprint", "status"}).AddRow("c", ssr.FindingAcceptedRisk))
+		mock.ExpectQuery(sqlSelectBaseline).
+			WithArgs(1, ssr.Success, scanID).
+			WillReturnRows(sqlmock.NewRows([]string{"fingerprint"}).AddRow("a"))
+		mock.ExpectExec(sqlUpdateGate).
+			WithArgs(sqlmock.AnyArg(), scanID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		gate, err := evaluateGate(gormDB, 1, scanID, findings, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, ssr.GateFailed, gate.Status)
+		require.Len(t, gate.Reasons, 1)
+		assert.Equal(t, 1, gate.Reasons[0].Found)
+		assert.Empty(t, findings[2].Status, "the findings of the caller are left as they are")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,157 @@
+package postgresql
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/quantonganh/ssr"
+)
+
+// sqlClaimScan locks the oldest queued scan, skipping the ones that are being claimed concurrently
+// by other replicas, so that every scan is handed out to a single worker.
+const sqlClaimScan = `UPDATE scan SET status = ?, scanning_at = ?, attempts = attempts + 1, lease_owner = ?, lease_expires_at = ?
+WHERE id = (
+	SELECT id FROM scan WHERE status = ? ORDER BY queued_at LIMIT 1 FOR UPDATE SKIP LOCKED
+)
+RETURNING *`
+
+// sqlRequeueScans returns the scans whose lease has expired to the queue,
+// or fails them once they have been attempted maxAttempts times, stamping when they finished.
+const sqlRequeueScans = `UPDATE scan SET status = CASE WHEN attempts >= ? THEN ? ELSE ? END,
+	finished_at = CASE WHEN attempts >= ? THEN ? ELSE finished_at END, lease_owner = NULL, lease_expires_at = NULL
+WHERE id IN (
+	SELECT id FROM scan WHERE status = ? AND lease_expires_at < ? FOR UPDATE SKIP LOCKED
+)
+RETURNING *`
+
+type scanQueue struct {
+	db *gorm.DB
+
+	owner       string
+	lease       time.Duration
+	maxAttempts int
+}
+
+// NewScanQueue returns a queue whose claims are leased to owner for the given duration.
+// A scan whose lease expired maxAttempts times is marked as failed instead of being requeued.
+func NewScanQueue(db *gorm.DB, owner string, lease time.Duration, maxAttempts int) ssr.ScanQueue {
+	return &scanQueue{
+		db:          db,
+		owner:       owner,
+		lease:       lease,
+		maxAttempts: maxAttempts,
+	}
+}
+
+func (q *scanQueue) Claim() (*ssr.Scan, error) {
+	now := time.Now()
+	var scans []*ssr.Scan
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(sqlClaimScan, ssr.InProgress, now, q.owner, now.Add(q.lease), ssr.Queued).Scan(&scans).Error; err != nil {
+			return errors.Wrap(err, "failed to claim scan")
+		}
+		if len(scans) == 0 {
+			return nil
+		}
+		if err := publishScan(tx, scans[0], nil, now); err != nil {
+			return err
+		}
+		return recordScan(tx, ssr.ChangeScanUpdated, scans[0], now)
+	})
+	if err != nil || len(scans) == 0 {
+		return nil, err
+	}
+	return scans[0], nil
+}
+
+func (q *scanQueue) Heartbeat(id uuid.UUID) error {
+	result := q.leased(q.db, id).Update("lease_expires_at", time.Now().Add(q.lease))
+	if err := result.Error; err != nil {
+		return errors.Wrapf(err, "failed to extend lease of scan: %s", id)
+	}
+	if result.RowsAffected == 0 {
+		return ssr.ErrLeaseLost
+	}
+	return nil
+}
+
+func (q *scanQueue) Finish(id uuid.UUID, status ssr.Status, findings ssr.Findings) error {
+	now := time.Now()
+	findings.Fingerprint()
+	return q.db.Transaction(func(tx *gorm.DB) error {
+		var scan ssr.Scan
+		if err := q.leased(tx, id).Clauses(clause.Locking{Strength: "UPDATE"}).Take(&scan).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ssr.ErrLeaseLost
+			}
+			return errors.Wrapf(err, "failed to select scan: %s", id)
+		}
+		// The worker moves scans through the same transitions as UpdateScan.
+		if err := scan.Transition(status, now); err != nil {
+			return err
+		}
+
+		err := q.leased(tx, id).Updates(map[string]interface{}{
+			"status":           scan.Status,
+			"finished_at":      scan.FinishedAt,
+			"lease_owner":      nil,
+			"lease_expires_at": nil,
+		}).Error
+		if err != nil {
+			return errors.Wrapf(err, "failed to finish scan: %s", id)
+		}
+		scan.Findings = findings
+		repositoryID := scan.RepositoryID
+		if err := replaceFindings(tx, repositoryID, id, findings); err != nil {
+			return err
+		}
+		if err := trackFindings(tx, repositoryID, id, findings, now); err != nil {
+			return err
+		}
+		if status == ssr.Success {
+			if err := resolveFindings(tx, repositoryID, id, now); err != nil {
+				return err
+			}
+			gate, err := evaluateGate(tx, repositoryID, id, findings, now)
+			if err != nil {
+				return err
+			}
+			scan.Gate = gate
+		}
+		if err := publishScan(tx, &scan, findings, now); err != nil {
+			return err
+		}
+		return recordScan(tx, ssr.ChangeScanUpdated, &scan, now)
+	})
+}
+
+func (q *scanQueue) Requeue() (int64, error) {
+	now := time.Now()
+	var scans []*ssr.Scan
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(sqlRequeueScans, q.maxAttempts, ssr.Failure, ssr.Queued, q.maxAttempts, now, ssr.InProgress, now).Scan(&scans).Error; err != nil {
+			return errors.Wrap(err, "failed to requeue expired scans")
+		}
+		for _, scan := range scans {
+			if err := publishScan(tx, scan, nil, now); err != nil {
+				return err
+			}
+			if err := recordScan(tx, ssr.ChangeScanUpdated, scan, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(scans)), nil
+}
+
+func (q *scanQueue) leased(db *gorm.DB, id uuid.UUID) *gorm.DB {
+	return db.Table(ssr.Scan{}.TableName()).Where("id = ? AND status = ? AND lease_owner = ?", id, ssr.InProgress, q.owner)
+}
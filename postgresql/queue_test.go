@@ -0,0 +1,197 @@
+// +build !integration
+
+package postgresql
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/quantonganh/ssr"
+)
+
+const (
+	sqlHeartbeatScan    = `UPDATE "scan" SET "lease_expires_at"=$1 WHERE id = $2 AND status = $3 AND lease_owner = $4`
+	sqlSelectLeasedScan = `SELECT * FROM "scan" WHERE id = $1 AND status = $2 AND lease_owner = $3 LIMIT 1 FOR UPDATE`
+	sqlFinishScan       = `UPDATE "scan" SET "finished_at"=$1,"lease_expires_at"=$2,"lease_owner"=$3,"status"=$4 WHERE id = $5 AND status = $6 AND lease_owner = $7`
+)
+
+func TestScanQueue(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	id := uuid.New()
+	now := time.Now()
+	q := NewScanQueue(gormDB, "worker-1", time.Minute, 3)
+
+	t.Run("claim", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "status", "repository_id", "queued_at", "scanning_at", "attempts", "lease_owner", "lease_expires_at"}).
+			AddRow(id, ssr.InProgress, 1, now, now, 1, "worker-1", now.Add(time.Minute))
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE SKIP LOCKED")).
+			WithArgs(ssr.InProgress, sqlmock.AnyArg(), "worker-1", sqlmock.AnyArg(), ssr.Queued).
+			WillReturnRows(rows)
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelectRepositoryWebhooks)).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec(regexp.QuoteMeta(sqlInsertChange)).
+			WithArgs(sqlmock.AnyArg(), ssr.ChangeScanUpdated, id.String(), 1, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		scan, err := q.Claim()
+		require.NoError(t, err)
+		require.NotNil(t, scan)
+		assert.Equal(t, id, scan.ID)
+		assert.Equal(t, ssr.InProgress, scan.Status)
+		assert.Equal(t, "worker-1", scan.LeaseOwner)
+		assert.Equal(t, 1, scan.Attempts)
+	})
+
+	t.Run("claim from empty queue", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE SKIP LOCKED")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectCommit()
+
+		scan, err := q.Claim()
+		require.NoError(t, err)
+		assert.Nil(t, scan)
+	})
+
+	t.Run("heartbeat", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(sqlHeartbeatScan)).
+			WithArgs(sqlmock.AnyArg(), id, ssr.InProgress, "worker-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, q.Heartbeat(id))
+	})
+
+	t.Run("heartbeat after the lease was lost", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(sqlHeartbeatScan)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, ssr.ErrLeaseLost, q.Heartbeat(id))
+	})
+
+	t.Run("finish", func(t *testing.T) {
+		findings := ssr.Findings{
+			{
+				Type:   "sast",
+				RuleID: "G404",
+				Location: ssr.Location{
+					Path: "util/util.go",
+				},
+			},
+		}
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelectLeasedScan)).
+			WithArgs(id, ssr.InProgress, "worker-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status", "repository_id", "branch", "lease_owner"}).AddRow(id, ssr.InProgress, 1, "main", "worker-1"))
+		mock.ExpectExec(regexp.QuoteMeta(sqlFinishScan)).
+			WithArgs(sqlmock.AnyArg(), nil, nil, ssr.Success, id, ssr.InProgress, "worker-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(sqlDeleteFindings)).
+			WithArgs(id).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelectActiveSuppressions)).
+			WithArgs(1, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "repository_id"}))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "finding"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		// The fingerprint is still open, its status does not change.
+		fingerprinted := append(ssr.Findings(nil), findings...)
+		fingerprinted.Fingerprint()
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelectTrackedFindings)).
+			WithArgs(1, fingerprinted[0].Fingerprint).
+			WillReturnRows(sqlmock.NewRows([]string{"repository_id", "fingerprint", "status"}).AddRow(1, fingerprinted[0].Fingerprint, ssr.FindingOpen))
+		mock.ExpectExec(regexp.QuoteMeta(sqlTrackFindings)).
+			WithArgs(1, sqlmock.AnyArg(), "G404", "util/util.go", sqlmock.AnyArg(), sqlmock.AnyArg(), id, id, ssr.FindingOpen, "", "", time.Time{}, ssr.FindingFixed, ssr.FindingOpen).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlResolveFindings)).
+			WithArgs(ssr.FindingFixed, 1, id, ssr.FindingOpen, ssr.FindingConfirmed).
+			WillReturnRows(sqlmock.NewRows([]string{"repository_id", "fingerprint"}))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelectRepositoryPolicies)).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec(regexp.QuoteMeta(sqlUpdateGate)).
+			WithArgs(sqlmock.AnyArg(), id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelectRepositoryWebhooks)).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec(regexp.QuoteMeta(sqlInsertChange)).
+			WithArgs(sqlmock.AnyArg(), ssr.ChangeScanUpdated, id.String(), 1, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, q.Finish(id, ssr.Success, findings))
+		assert.NotEmpty(t, findings[0].Fingerprint)
+	})
+
+	t.Run("finish after the lease was lost", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelectLeasedScan)).
+			WithArgs(id, ssr.InProgress, "worker-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		assert.Equal(t, ssr.ErrLeaseLost, q.Finish(id, ssr.Failure, nil))
+	})
+
+	t.Run("finish with a status that cannot follow In Progress", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelectLeasedScan)).
+			WithArgs(id, ssr.InProgress, "worker-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status", "repository_id"}).AddRow(id, ssr.InProgress, 1))
+		mock.ExpectRollback()
+
+		var transitionErr *ssr.TransitionError
+		require.True(t, errors.As(q.Finish(id, ssr.Queued, nil), &transitionErr))
+		assert.Equal(t, ssr.Queued, transitionErr.To)
+	})
+
+	t.Run("requeue", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM scan WHERE status = $6 AND lease_expires_at < $7 FOR UPDATE SKIP LOCKED")).
+			WithArgs(3, ssr.Failure, ssr.Queued, 3, sqlmock.AnyArg(), ssr.InProgress, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "repository_id", "branch", "status"}).
+				AddRow(uuid.New(), 1, "", ssr.Queued).
+				AddRow(uuid.New(), 2, "", ssr.Failure))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelectRepositoryWebhooks)).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec(regexp.QuoteMeta(sqlInsertChange)).
+			WithArgs(sqlmock.AnyArg(), ssr.ChangeScanUpdated, sqlmock.AnyArg(), 1, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(sqlSelectRepositoryWebhooks)).
+			WithArgs(2, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec(regexp.QuoteMeta(sqlInsertChange)).
+			WithArgs(sqlmock.AnyArg(), ssr.ChangeScanUpdated, sqlmock.AnyArg(), 2, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		n, err := q.Requeue()
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
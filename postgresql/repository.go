@@ -1,6 +1,7 @@
 package postgresql
 
 import (
+	"context"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
 
@@ -17,15 +18,99 @@ func NewRepositoryService(db *gorm.DB) ssr.RepositoryService {
 	}
 }
 
-func (s *repositoryService) Create(r *ssr.Repository) error {
-	if err := s.db.Create(&r).Error; err != nil {
+func (s *repositoryService) Create(ctx context.Context, r *ssr.Repository) error {
+	db := s.db.WithContext(ctx)
+	if err := authorize(ctx, db, ssr.RoleAdmin); err != nil {
+		return err
+	}
+
+	r.OrganizationID = organizationOf(ctx, r.OrganizationID)
+	if err := db.Create(&r).Error; err != nil {
 		return errors.Wrapf(err, "failed to create repository: %s", r.FullName)
 	}
 	return nil
 }
 
-func (s *repositoryService) Get(id uint64) (*ssr.Repository, error) {
+func (s *repositoryService) Get(ctx context.Context, id uint64) (*ssr.Repository, error) {
+	db := s.db.WithContext(ctx)
+	if err := authorizeRepository(ctx, db, id, ssr.RoleReader, ssr.ErrRepositoryNotFound); err != nil {
+		return nil, err
+	}
+
 	var repo ssr.Repository
-	s.db.First(&repo, "id = ?", id)
+	if err := db.First(&repo, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ssr.ErrRepositoryNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to select repository: %d", id)
+	}
 	return &repo, nil
 }
+
+// List only lists the repositories of their organization that the user of ctx can read.
+func (s *repositoryService) List(ctx context.Context, filter ssr.RepositoryFilter) (repos []*ssr.Repository, err error) {
+	db := s.db.WithContext(ctx)
+	a, err := resolveAccess(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	tx := db.Scopes(inOrganization(ctx, "organization_id"), readableRepositories(a, "id"), paginate(filter.Page, filter.Limit))
+	if filter.Provider != "" {
+		tx = tx.Where("provider = ?", filter.Provider)
+	}
+	if filter.FullName != "" {
+		tx = tx.Where("full_name = ?", filter.FullName)
+	}
+	if err = tx.Order("id").Find(&repos).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to list repositories")
+	}
+	return repos, nil
+}
+
+func (s *repositoryService) Update(ctx context.Context, id uint64, upd ssr.RepositoryUpdate) (*ssr.Repository, error) {
+	if err := authorizeRepository(ctx, s.db.WithContext(ctx), id, ssr.RoleMaintainer, ssr.ErrRepositoryNotFound); err != nil {
+		return nil, err
+	}
+
+	repo, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if upd.Provider != nil {
+		repo.Provider = *upd.Provider
+	}
+	if upd.FullName != nil {
+		repo.FullName = *upd.FullName
+	}
+	if upd.Description != nil {
+		repo.Description = *upd.Description
+	}
+	if upd.DefaultBranch != nil {
+		repo.DefaultBranch = *upd.DefaultBranch
+	}
+
+	if err := s.db.WithContext(ctx).Save(repo).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to update repository: %d", id)
+	}
+	return repo, nil
+}
+
+func (s *repositoryService) Delete(ctx context.Context, id uint64) error {
+	db := s.db.WithContext(ctx)
+	if err := authorizeRepository(ctx, db, id, ssr.RoleAdmin, ssr.ErrRepositoryNotFound); err != nil {
+		return err
+	}
+
+	result := db.Delete(&ssr.Repository{}, id)
+	if err := result.Error; err != nil {
+		if sqlState(err) == foreignKeyViolation {
+			return ssr.ErrRepositoryInUse
+		}
+		return errors.Wrapf(err, "failed to delete repository: %d", id)
+	}
+	if result.RowsAffected == 0 {
+		return ssr.ErrRepositoryNotFound
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
+// +build !integration
+
 package postgresql
 
 import (
+	"context"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -13,13 +16,21 @@ import (
 )
 
 const (
-	sqlInsertRepository = `INSERT INTO "repository" ("provider","full_name","description") VALUES ($1,$2,$3) RETURNING "id"`
+	sqlInsertRepository = `INSERT INTO "repository" ("organization_id","provider","full_name","description","default_branch") VALUES ($1,$2,$3,$4,$5) RETURNING "id"`
 	sqlSelectRepository = `SELECT * FROM "repository" WHERE id = $1 ORDER BY "repository"."id" LIMIT 1`
+	sqlListRepositories = `SELECT * FROM "repository" WHERE provider = $1 ORDER BY id LIMIT 10`
+	sqlUpdateRepository = `UPDATE "repository" SET "organization_id"=$1,"provider"=$2,"full_name"=$3,"description"=$4,"default_branch"=$5 WHERE "id" = $6`
+	sqlDeleteRepository = `DELETE FROM "repository" WHERE "repository"."id" = $1`
 )
 
 func TestRepositoryService(t *testing.T) {
 	t.Run("create repo", testCreateRepo)
+	t.Run("create repo as a maintainer", testCreateRepoAsMaintainer)
 	t.Run("get repo", testGetRepo)
+	t.Run("get unknown repo", testGetUnknownRepo)
+	t.Run("list repos", testListRepos)
+	t.Run("update repo", testUpdateRepo)
+	t.Run("delete repo", testDeleteRepo)
 }
 
 func testCreateRepo(t *testing.T) {
@@ -35,16 +46,43 @@ func testCreateRepo(t *testing.T) {
 	require.NoError(t, err)
 
 	repo := &ssr.Repository{
-		Provider:    "GitHub",
-		FullName:    "quantonganh/ssr",
-		Description: "Security scan result",
+		Provider:      "GitHub",
+		FullName:      "quantonganh/ssr",
+		Description:   "Security scan result",
+		DefaultBranch: "master",
 	}
 	mock.ExpectQuery(sqlInsertRepository).
-		WithArgs(repo.Provider, repo.FullName, repo.Description).
+		WithArgs(ssr.DefaultOrganizationID, repo.Provider, repo.FullName, repo.Description, repo.DefaultBranch).
 		WillReturnRows(sqlmock.NewRows([]string{"provider", "full_name", "description"}).AddRow(repo.Provider, repo.FullName, repo.Description))
 
 	repoService := NewRepositoryService(gormDB)
-	require.NoError(t, repoService.Create(repo))
+	require.NoError(t, repoService.Create(context.Background(), repo))
+}
+
+func testCreateRepoAsMaintainer(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	ctx := ssr.NewContextWithUser(context.Background(), &ssr.User{ID: "bob", OrganizationID: ssr.DefaultOrganizationID})
+	mock.ExpectQuery(sqlSelectUser).
+		WithArgs(ssr.DefaultOrganizationID, "bob").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "organization_id", "role"}).AddRow("bob", ssr.DefaultOrganizationID, ssr.RoleMaintainer))
+	mock.ExpectQuery(sqlSelectTeamsOfUser).
+		WithArgs(ssr.DefaultOrganizationID, "bob").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	repoService := NewRepositoryService(gormDB)
+	err = repoService.Create(ctx, &ssr.Repository{Provider: "GitHub", FullName: "quantonganh/ssr"})
+	assert.ErrorIs(t, err, ssr.ErrForbidden, "only admins create repositories")
+	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 func testGetRepo(t *testing.T) {
@@ -67,9 +105,108 @@ func testGetRepo(t *testing.T) {
 	mock.ExpectQuery(sqlSelectRepository).WithArgs(repo.ID).WillReturnRows(rows)
 
 	repoService := NewRepositoryService(gormDB)
-	r, err := repoService.Get(1)
+	r, err := repoService.Get(context.Background(), 1)
 	require.NoError(t, err)
 	assert.Equal(t, "GitHub", r.Provider)
 	assert.Equal(t, "quantonganh/ssr", r.FullName)
 	assert.Equal(t, "Security scan result", r.Description)
 }
+
+func testGetUnknownRepo(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	mock.ExpectQuery(sqlSelectRepository).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	repoService := NewRepositoryService(gormDB)
+	_, err = repoService.Get(context.Background(), 2)
+	assert.Equal(t, ssr.ErrRepositoryNotFound, err)
+}
+
+func testListRepos(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	rows := sqlmock.NewRows([]string{"id", "provider", "full_name", "description"}).
+		AddRow(1, "GitHub", "quantonganh/ssr", "Security scan result").
+		AddRow(2, "GitHub", "quantonganh/blog", "")
+	mock.ExpectQuery(sqlListRepositories).WithArgs("GitHub").WillReturnRows(rows)
+
+	repoService := NewRepositoryService(gormDB)
+	repos, err := repoService.List(context.Background(), ssr.RepositoryFilter{Provider: "GitHub", Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(repos))
+	assert.Equal(t, "quantonganh/blog", repos[1].FullName)
+}
+
+func testUpdateRepo(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	rows := sqlmock.NewRows([]string{"id", "organization_id", "provider", "full_name", "description", "default_branch"}).AddRow(1, 1, "GitHub", "quantonganh/ssr", "", "master")
+	mock.ExpectQuery(sqlSelectRepository).WithArgs(1).WillReturnRows(rows)
+	mock.ExpectExec(sqlUpdateRepository).
+		WithArgs(1, "GitHub", "quantonganh/ssr", "Security scan result", "main", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	description := "Security scan result"
+	defaultBranch := "main"
+	repoService := NewRepositoryService(gormDB)
+	r, err := repoService.Update(context.Background(), 1, ssr.RepositoryUpdate{Description: &description, DefaultBranch: &defaultBranch})
+	require.NoError(t, err)
+	assert.Equal(t, "Security scan result", r.Description)
+	assert.Equal(t, "main", r.DefaultBranch)
+}
+
+func testDeleteRepo(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	mock.ExpectExec(sqlDeleteRepository).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repoService := NewRepositoryService(gormDB)
+	assert.Equal(t, ssr.ErrRepositoryNotFound, repoService.Delete(context.Background(), 1))
+
+	mock.ExpectExec(sqlDeleteRepository).WithArgs(2).WillReturnError(pgError(foreignKeyViolation))
+	assert.Equal(t, ssr.ErrRepositoryInUse, repoService.Delete(context.Background(), 2))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// pgError is an error with a SQLSTATE, like the errors of the PostgreSQL driver.
+type pgError string
+
+func (e pgError) Error() string {
+	return "SQLSTATE " + string(e)
+}
+
+func (e pgError) SQLState() string {
+	return string(e)
+}
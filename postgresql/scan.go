@@ -1,9 +1,13 @@
 package postgresql
 
 import (
+	"context"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/quantonganh/ssr"
 )
@@ -18,25 +22,76 @@ func NewScanService(db *gorm.DB) ssr.ScanService {
 	}
 }
 
-func (ss *scanService) CreateScan(s *ssr.Scan) (*ssr.Scan, error) {
-	result := ss.db.Create(&s)
-	if err := result.Error; err != nil {
-		return nil, errors.Wrap(err, "failed to create scan")
+func (ss *scanService) CreateScan(ctx context.Context, s *ssr.Scan) (*ssr.Scan, error) {
+	now := time.Now()
+	s.Stamp(now)
+	s.Findings.Fingerprint()
+	// The gate is evaluated from the policies, never taken from the client.
+	s.Gate = nil
+	err := ss.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := authorizeRepository(ctx, tx, s.RepositoryID, ssr.RoleMaintainer, ssr.ErrRepositoryNotFound); err != nil {
+			return err
+		}
+		if err := tx.Select("id").First(&ssr.Repository{}, s.RepositoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ssr.ErrRepositoryNotFound
+			}
+			return errors.Wrapf(err, "failed to select repository: %d", s.RepositoryID)
+		}
+		if err := tx.Omit("Findings").Create(&s).Error; err != nil {
+			return errors.Wrap(err, "failed to create scan")
+		}
+		if err := createFindings(tx, s.RepositoryID, s.ID, s.Findings); err != nil {
+			return err
+		}
+		if err := trackFindings(tx, s.RepositoryID, s.ID, s.Findings, now); err != nil {
+			return err
+		}
+		if s.Status == ssr.Success {
+			if err := resolveFindings(tx, s.RepositoryID, s.ID, now); err != nil {
+				return err
+			}
+			gate, err := evaluateGate(tx, s.RepositoryID, s.ID, s.Findings, now)
+			if err != nil {
+				return err
+			}
+			s.Gate = gate
+		}
+		if err := publishScan(tx, s, s.Findings, now); err != nil {
+			return err
+		}
+		return recordScan(tx, ssr.ChangeScanCreated, s, now)
+	})
+	if err != nil {
+		return nil, err
 	}
 	return s, nil
 }
 
-func (ss *scanService) GetScan(id uuid.UUID) (*ssr.Scan, error) {
+func (ss *scanService) GetScan(ctx context.Context, id uuid.UUID) (*ssr.Scan, error) {
+	db := ss.db.WithContext(ctx)
 	var s ssr.Scan
-	if err := ss.db.First(&s, "id = ?", id).Error; err != nil {
+	if err := db.Scopes(preloadFindings).First(&s, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ssr.ErrScanNotFound
+		}
 		return nil, errors.Wrapf(err, "failed to select scan: %s", id)
 	}
+	if err := authorizeRepository(ctx, db, s.RepositoryID, ssr.RoleReader, ssr.ErrScanNotFound); err != nil {
+		return nil, err
+	}
 
 	return &s, nil
 }
 
-func (ss *scanService) ListScans(page, limit int) (scans []*ssr.Scan, err error) {
-	if err = ss.db.Scopes(paginate(page, limit)).Find(&scans).Error; err != nil {
+// ListScans only lists the scans of the repositories of their organization that the user of ctx can read.
+func (ss *scanService) ListScans(ctx context.Context, page, limit int) (scans []*ssr.Scan, err error) {
+	db := ss.db.WithContext(ctx)
+	a, err := resolveAccess(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Scopes(inOrganizationRepositories(ctx, "repository_id"), readableRepositories(a, "repository_id"), paginate(page, limit), preloadFindings).Find(&scans).Error; err != nil {
 		return
 	}
 
@@ -50,21 +105,107 @@ func paginate(page, limit int) func(db *gorm.DB) *gorm.DB {
 	}
 }
 
-func (ss *scanService) UpdateScan(id uuid.UUID, status ssr.Status, findings ssr.Findings) (*ssr.Scan, error) {
+func (ss *scanService) UpdateScan(ctx context.Context, id uuid.UUID, status ssr.Status, findings ssr.Findings) (*ssr.Scan, error) {
 	var scan ssr.Scan
-	if err := ss.db.Model(&scan).Where("id = ?", id).Updates(ssr.Scan{
-		Status: status,
-		Findings: findings,
-	}).Error; err != nil {
+	err := ss.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&scan, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ssr.ErrScanNotFound
+			}
+			return errors.Wrapf(err, "failed to select scan: %s", id)
+		}
+		if err := authorizeRepository(ctx, tx, scan.RepositoryID, ssr.RoleMaintainer, ssr.ErrScanNotFound); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := scan.Transition(status, now); err != nil {
+			return err
+		}
+		findings.Fingerprint()
+		scan.Findings = findings
+
+		if err := tx.Model(&scan).Select("status", "queued_at", "scanning_at", "finished_at").Updates(&scan).Error; err != nil {
+			return errors.Wrapf(err, "failed to update scan: %s", id)
+		}
+		if status == ssr.Queued {
+			// A retried scan is given as many attempts as a new one, rather than being failed by the next requeue.
+			if err := tx.Table(scan.TableName()).Where("id = ?", id).Update("attempts", 0).Error; err != nil {
+				return errors.Wrapf(err, "failed to reset attempts of scan: %s", id)
+			}
+			scan.Attempts = 0
+		}
+		if err := replaceFindings(tx, scan.RepositoryID, scan.ID, findings); err != nil {
+			return err
+		}
+		if err := trackFindings(tx, scan.RepositoryID, scan.ID, findings, now); err != nil {
+			return err
+		}
+		if status == ssr.Success {
+			if err := resolveFindings(tx, scan.RepositoryID, scan.ID, now); err != nil {
+				return err
+			}
+			gate, err := evaluateGate(tx, scan.RepositoryID, scan.ID, findings, now)
+			if err != nil {
+				return err
+			}
+			scan.Gate = gate
+		}
+		if err := publishScan(tx, &scan, findings, now); err != nil {
+			return err
+		}
+		return recordScan(tx, ssr.ChangeScanUpdated, &scan, now)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &scan, nil
 }
 
-func (ss *scanService) DeleteScan(id uuid.UUID) error {
-	var scan ssr.Scan
-	if err := ss.db.Delete(&scan, id).Error; err != nil {
-		return errors.Wrapf(err, "failed to delete scan: %s", id)
+func (ss *scanService) DeleteScan(ctx context.Context, id uuid.UUID) error {
+	return ss.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var scan ssr.Scan
+		if err := tx.Select("id", "repository_id").First(&scan, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ssr.ErrScanNotFound
+			}
+			return errors.Wrapf(err, "failed to select scan: %s", id)
+		}
+		if err := authorizeRepository(ctx, tx, scan.RepositoryID, ssr.RoleMaintainer, ssr.ErrScanNotFound); err != nil {
+			return err
+		}
+		if err := tx.Where("scan_id = ?", id).Delete(&ssr.Finding{}).Error; err != nil {
+			return errors.Wrapf(err, "failed to delete findings of scan: %s", id)
+		}
+		result := tx.Delete(&ssr.Scan{}, id)
+		if result.Error != nil {
+			return errors.Wrapf(result.Error, "failed to delete scan: %s", id)
+		}
+		if result.RowsAffected == 0 {
+			return ssr.ErrScanNotFound
+		}
+		return recordScan(tx, ssr.ChangeScanDeleted, &scan, time.Now())
+	})
+}
+
+func (ss *scanService) GetLatestDefaultBranchScan(ctx context.Context, repositoryID uint64) (*ssr.Scan, error) {
+	db := ss.db.WithContext(ctx)
+	if err := authorizeRepository(ctx, db, repositoryID, ssr.RoleReader, ssr.ErrScanNotFound); err != nil {
+		return nil, err
 	}
-	return nil
+
+	var s ssr.Scan
+	err := db.Scopes(preloadFindings).
+		Joins("JOIN repository ON repository.id = scan.repository_id").
+		Where("scan.repository_id = ? AND scan.status = ?", repositoryID, ssr.Success).
+		Where("scan.branch = repository.default_branch OR scan.branch = ''").
+		Order("scan.finished_at DESC").
+		Take(&s).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ssr.ErrScanNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to select latest scan of repository: %d", repositoryID)
+	}
+	return &s, nil
 }
@@ -3,12 +3,15 @@
 package postgresql
 
 import (
+	"context"
+	"encoding/json"
 	"regexp"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/postgres"
@@ -18,11 +21,24 @@ import (
 )
 
 const (
-	sqlInsertScan = `INSERT INTO "scan" ("id","status","repository_id","findings","queued_at","scanning_at","finished_at") VALUES ($1,$2,$3,$4,$5,$6,$7)`
+	sqlInsertScan = `INSERT INTO "scan" ("id","status","repository_id","branch","queued_at","scanning_at","finished_at","gate") VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`
+	sqlInsertFindings = `INSERT INTO "finding" ("scan_id","type","rule_id","tool","path","begin_line","begin_column","end_line","end_column","snippet","description","severity","rule_name","help_uri","commit","installed_version","fingerprint","suppressed","suppression_id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19) RETURNING "id"`
+	sqlSelectActiveSuppressions = `SELECT * FROM "suppression" WHERE repository_id = $1 AND (expires_at IS NULL OR expires_at > $2) ORDER BY id`
 	sqlSelectScan = `SELECT * FROM "scan" WHERE id = $1 ORDER BY "scan"."id" LIMIT 1`
-	sqlUpdateScan = `UPDATE "scan" SET "status"=$1,"findings"=$2 WHERE id = $3`
+	sqlSelectScanForUpdate = `SELECT * FROM "scan" WHERE id = $1 ORDER BY "scan"."id" LIMIT 1 FOR UPDATE`
+	sqlUpdateScan = `UPDATE "scan" SET "status"=$1,"queued_at"=$2,"scanning_at"=$3,"finished_at"=$4 WHERE "id" = $5`
 	sqlDeleteScan = `DELETE FROM "scan" WHERE "scan"."id" = $1`
 	sqlListScans = `SELECT * FROM "scan" LIMIT 1`
+	sqlPreloadFindings = `SELECT * FROM "finding" WHERE "finding"."scan_id" = $1 ORDER BY id`
+	sqlSelectLatestDefaultBranchScan = `SELECT "scan"."id","scan"."status","scan"."repository_id","scan"."branch","scan"."queued_at","scan"."scanning_at","scan"."finished_at","scan"."gate","scan"."attempts","scan"."lease_owner","scan"."lease_expires_at" FROM "scan" JOIN repository ON repository.id = scan.repository_id WHERE (scan.repository_id = $1 AND scan.status = $2) AND (scan.branch = repository.default_branch OR scan.branch = '') ORDER BY scan.finished_at DESC LIMIT 1`
+	sqlDeleteFindings = `DELETE FROM "finding" WHERE scan_id = $1`
+	sqlSelectTrackedFindings = `SELECT * FROM "repository_finding" WHERE repository_id = $1 AND fingerprint IN ($2) FOR UPDATE`
+	sqlTrackFindings = `INSERT INTO "repository_finding" ("repository_id","fingerprint","rule_id","path","first_seen_at","last_seen_at","first_scan_id","last_scan_id","status","triage_reason","triaged_by","triaged_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12) ON CONFLICT ("repository_id","fingerprint") DO UPDATE SET "rule_id"="excluded"."rule_id","path"="excluded"."path","last_seen_at"="excluded"."last_seen_at","last_scan_id"="excluded"."last_scan_id","status"=CASE WHEN repository_finding.status = $13 THEN $14 ELSE repository_finding.status END`
+	sqlResolveFindings = `UPDATE "repository_finding" SET "status"=$1 WHERE repository_id = $2 AND last_scan_id <> $3 AND status IN ($4,$5) RETURNING *`
+	sqlSelectRepositoryPolicies = `SELECT * FROM "policy" WHERE repository_id = $1 OR (repository_id IS NULL AND organization_id = (SELECT "organization_id" FROM "repository" WHERE id = $2)) ORDER BY id`
+	sqlSelectDismissedFindings = `SELECT "fingerprint","status" FROM "repository_finding" WHERE repository_id = $1 AND status IN ($2,$3)`
+	sqlUpdateGate = `UPDATE "scan" SET "gate"=$1 WHERE id = $2`
+	sqlResetScanAttempts = `UPDATE "scan" SET "attempts"=$1 WHERE id = $2`
 )
 
 var scanID uuid.UUID
@@ -41,6 +57,7 @@ func TestScanService(t *testing.T) {
 	t.Run("delete scan", func(t *testing.T) {
 		testDeleteScan(t, scanID)
 	})
+	t.Run("get latest scan of default branch", testGetLatestDefaultBranchScan)
 }
 
 func testCreateScan(t *testing.T) {
@@ -80,14 +97,66 @@ func testCreateScan(t *testing.T) {
 		ScanningAt:   now,
 		FinishedAt:   now,
 	}
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlSelectRepositoryID).
+		WithArgs(scan.RepositoryID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	mock.ExpectExec(sqlInsertScan).
-		WithArgs(sqlmock.AnyArg(), scan.Status, scan.RepositoryID, scan.Findings, scan.QueuedAt, scan.ScanningAt, scan.FinishedAt).
+		WithArgs(sqlmock.AnyArg(), scan.Status, scan.RepositoryID, "", sqlmock.AnyArg(), sqlmock.AnyArg(), time.Time{}, nil).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(sqlSelectActiveSuppressions).
+		WithArgs(scan.RepositoryID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "repository_id", "rule_id"}).AddRow(3, 1, "G402"))
+	mock.ExpectQuery(sqlInsertFindings).
+		WithArgs(sqlmock.AnyArg(), "sast", "G402", "", "", 60, 0, 0, 0, "", "TLS InsecureSkipVerify set true.", "HIGH", "", "", "", "", sqlmock.AnyArg(), true, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(sqlSelectTrackedFindings).
+		WithArgs(scan.RepositoryID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"repository_id", "fingerprint", "status"}))
+	mock.ExpectExec(sqlTrackFindings).
+		WithArgs(scan.RepositoryID, sqlmock.AnyArg(), "G402", "", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), ssr.FindingOpen, "", "", time.Time{}, ssr.FindingFixed, ssr.FindingOpen).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	var firstSeen []string
+	mock.ExpectExec(sqlInsertChange).
+		WithArgs(sqlmock.AnyArg(), ssr.ChangeFindingStatusChanged, sqlmock.AnyArg(), scan.RepositoryID, payloadArg{&firstSeen}, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(sqlSelectRepositoryWebhooks).
+		WithArgs(scan.RepositoryID, scan.RepositoryID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(sqlInsertChange).
+		WithArgs(sqlmock.AnyArg(), ssr.ChangeScanCreated, sqlmock.AnyArg(), scan.RepositoryID, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	scanService := NewScanService(gormDB)
-	scanResult, err := scanService.CreateScan(scan)
+	scanResult, err := scanService.CreateScan(context.Background(), scan)
 	require.NoError(t, err)
+	assert.True(t, scanResult.QueuedAt.After(now))
+	assert.Equal(t, scanResult.QueuedAt, scanResult.ScanningAt)
+	assert.True(t, scanResult.FinishedAt.IsZero())
+	assert.NotEmpty(t, scanResult.Findings[0].Fingerprint)
+	assert.Equal(t, uint64(1), scanResult.Findings[0].ID)
+	assert.Equal(t, scanResult.ID, scanResult.Findings[0].ScanID)
+
+	require.Len(t, firstSeen, 1)
+	var rf ssr.RepositoryFinding
+	require.NoError(t, json.Unmarshal([]byte(firstSeen[0]), &rf))
+	assert.Equal(t, scanResult.Findings[0].Fingerprint, rf.Fingerprint)
+	assert.Equal(t, ssr.FindingOpen, rf.Status)
+	assert.Equal(t, scanResult.ID, rf.FirstScanID)
+	assert.True(t, scanResult.Findings[0].Suppressed)
+	require.NoError(t, mock.ExpectationsWereMet())
 	scanID = scanResult.ID
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlSelectRepositoryID).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	_, err = scanService.CreateScan(context.Background(), &ssr.Scan{RepositoryID: 2})
+	assert.ErrorIs(t, err, ssr.ErrRepositoryNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 func testGetScan(t *testing.T, scanID uuid.UUID) {
@@ -126,11 +195,14 @@ func testGetScan(t *testing.T, scanID uuid.UUID) {
 		ScanningAt:   now,
 		FinishedAt:   now,
 	}
-	rows := sqlmock.NewRows([]string{"id", "status", "repository_id", "findings", "queued_at", "scanning_at", "finished_at"}).AddRow(scan.ID, scan.Status, scan.RepositoryID, scan.Findings, scan.QueuedAt, scan.ScanningAt, scan.FinishedAt)
+	rows := sqlmock.NewRows([]string{"id", "status", "repository_id", "queued_at", "scanning_at", "finished_at"}).AddRow(scan.ID, scan.Status, scan.RepositoryID, scan.QueuedAt, scan.ScanningAt, scan.FinishedAt)
+	findingRows := sqlmock.NewRows([]string{"id", "scan_id", "type", "rule_id", "path", "begin_line", "description", "severity"}).
+		AddRow(1, scan.ID, finding.Type, finding.RuleID, finding.Location.Path, finding.Location.Positions.Begin.Line, finding.Metadata.Description, finding.Metadata.Severity)
 	mock.ExpectQuery(sqlSelectScan).WithArgs(scanID).WillReturnRows(rows)
+	mock.ExpectQuery(sqlPreloadFindings).WithArgs(scanID).WillReturnRows(findingRows)
 
 	scanService := NewScanService(gormDB)
-	scanResult, err := scanService.GetScan(scanID)
+	scanResult, err := scanService.GetScan(context.Background(), scanID)
 	require.NoError(t, err)
 	assert.Equal(t, ssr.InProgress, scanResult.Status)
 	assert.Equal(t, "api.go", scanResult.Findings[0].Location.Path)
@@ -173,15 +245,19 @@ func testListScans(t *testing.T, scanID uuid.UUID) {
 		ScanningAt:   now,
 		FinishedAt:   now,
 	}
-	rows := sqlmock.NewRows([]string{"id", "status", "repository_id", "findings", "queued_at", "scanning_at", "finished_at"}).AddRow(scan.ID, scan.Status, scan.RepositoryID, scan.Findings, scan.QueuedAt, scan.ScanningAt, scan.FinishedAt)
+	rows := sqlmock.NewRows([]string{"id", "status", "repository_id", "queued_at", "scanning_at", "finished_at"}).AddRow(scan.ID, scan.Status, scan.RepositoryID, scan.QueuedAt, scan.ScanningAt, scan.FinishedAt)
+	findingRows := sqlmock.NewRows([]string{"id", "scan_id", "type", "rule_id", "path", "begin_line", "description", "severity"}).
+		AddRow(1, scan.ID, finding.Type, finding.RuleID, finding.Location.Path, finding.Location.Positions.Begin.Line, finding.Metadata.Description, finding.Metadata.Severity)
 	mock.ExpectQuery(regexp.QuoteMeta(sqlListScans)).WillReturnRows(rows)
+	mock.ExpectQuery(regexp.QuoteMeta(sqlPreloadFindings)).WithArgs(scanID).WillReturnRows(findingRows)
 
 	scanService := NewScanService(gormDB)
-	scans, err := scanService.ListScans(1, 1)
+	scans, err := scanService.ListScans(context.Background(), 1, 1)
 	require.NoError(t, err)
 	assert.Equal(t, 1, len(scans))
 	assert.Equal(t, scanID, scans[0].ID)
 	assert.Equal(t, ssr.InProgress, scans[0].Status)
+	assert.Equal(t, "G402", scans[0].Findings[0].RuleID)
 }
 
 func testUpdateScan(t *testing.T, scanID uuid.UUID) {
@@ -222,13 +298,111 @@ func testUpdateScan(t *testing.T, scanID uuid.UUID) {
 		ScanningAt:   now,
 		FinishedAt:   now,
 	}
-	mock.ExpectExec(sqlUpdateScan).WithArgs(scan.Status, ssr.Findings{finding}, scanID).
+	rows := sqlmock.NewRows([]string{"id", "status", "repository_id", "queued_at", "scanning_at"}).AddRow(scanID, ssr.InProgress, 1, now, now)
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlSelectScanForUpdate).WithArgs(scanID).WillReturnRows(rows)
+	mock.ExpectExec(sqlUpdateScan).WithArgs(scan.Status, now, now, sqlmock.AnyArg(), scanID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(sqlDeleteFindings).WithArgs(scanID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(sqlSelectActiveSuppressions).
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "repository_id"}))
+	mock.ExpectQuery(sqlInsertFindings).
+		WithArgs(scanID, "sast", "G402", "", "", 60, 0, 0, 0, "", "TLS InsecureSkipVerify set true.", "HIGH", "", "", "", "", sqlmock.AnyArg(), false, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	// The fingerprint was fixed before, it is open again; another one is no longer reported.
+	fingerprinted := ssr.Findings{finding}
+	fingerprinted.Fingerprint()
+	fingerprint := fingerprinted[0].Fingerprint
+	firstScanID := uuid.New()
+	mock.ExpectQuery(sqlSelectTrackedFindings).
+		WithArgs(1, fingerprint).
+		WillReturnRows(sqlmock.NewRows([]string{"repository_id", "fingerprint", "first_scan_id", "last_scan_id", "status", "triaged_by"}).
+			AddRow(1, fingerprint, firstScanID, firstScanID, ssr.FindingFixed, "alice@example.com"))
+	mock.ExpectExec(sqlTrackFindings).
+		WithArgs(1, sqlmock.AnyArg(), "G402", "", sqlmock.AnyArg(), sqlmock.AnyArg(), scanID, scanID, ssr.FindingOpen, "", "", time.Time{}, ssr.FindingFixed, ssr.FindingOpen).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	var changes []string
+	mock.ExpectExec(sqlInsertChange).
+		WithArgs(sqlmock.AnyArg(), ssr.ChangeFindingStatusChanged, fingerprint, 1, payloadArg{&changes}, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(sqlResolveFindings).
+		WithArgs(ssr.FindingFixed, 1, scanID, ssr.FindingOpen, ssr.FindingConfirmed).
+		WillReturnRows(sqlmock.NewRows([]string{"repository_id", "fingerprint", "status"}).AddRow(1, "def", ssr.FindingFixed))
+	mock.ExpectExec(sqlInsertChange).
+		WithArgs(sqlmock.AnyArg(), ssr.ChangeFindingStatusChanged, "def", 1, payloadArg{&changes}, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(sqlSelectRepositoryPolicies).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "severity", "max_findings"}).AddRow(1, "no high", "HIGH", 0))
+	mock.ExpectQuery(sqlSelectDismissedFindings).
+		WithArgs(1, ssr.FindingFalsePositive, ssr.FindingAcceptedRisk).
+		WillReturnRows(sqlmock.NewRows([]string{"fingerprint", "status"}))
+	mock.ExpectExec(sqlUpdateGate).
+		WithArgs(sqlmock.AnyArg(), scanID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(sqlSelectRepositoryWebhooks).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(sqlInsertChange).
+		WithArgs(sqlmock.AnyArg(), ssr.ChangeScanUpdated, scanID.String(), 1, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	scanService := NewScanService(gormDB)
-	scanResult, err := scanService.UpdateScan(scanID, scan.Status, []ssr.Finding{finding})
+	scanResult, err := scanService.UpdateScan(context.Background(), scanID, scan.Status, []ssr.Finding{finding})
 	require.NoError(t, err)
 	assert.Equal(t, ssr.Success, scanResult.Status)
+	assert.True(t, scanResult.FinishedAt.After(now))
+	require.NotNil(t, scanResult.Gate)
+	assert.Equal(t, ssr.GateFailed, scanResult.Gate.Status)
+	require.Len(t, scanResult.Gate.Reasons, 1)
+	assert.Equal(t, "no high: 1 HIGH finding, at most 0 allowed", scanResult.Gate.Reasons[0].Message)
+
+	require.Len(t, changes, 2)
+	var reopened, fixed ssr.RepositoryFinding
+	require.NoError(t, json.Unmarshal([]byte(changes[0]), &reopened))
+	assert.Equal(t, ssr.FindingOpen, reopened.Status)
+	assert.Equal(t, firstScanID, reopened.FirstScanID, "a fingerprint keeps the scan it was first seen in")
+	assert.Equal(t, scanID, reopened.LastScanID)
+	assert.Equal(t, "alice@example.com", reopened.TriagedBy)
+	require.NoError(t, json.Unmarshal([]byte(changes[1]), &fixed))
+	assert.Equal(t, "def", fixed.Fingerprint)
+	assert.Equal(t, ssr.FindingFixed, fixed.Status)
+
+	rows = sqlmock.NewRows([]string{"id", "status", "repository_id", "queued_at", "scanning_at", "finished_at"}).AddRow(scanID, ssr.Success, 1, now, now, now)
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlSelectScanForUpdate).WithArgs(scanID).WillReturnRows(rows)
+	mock.ExpectRollback()
+
+	_, err = scanService.UpdateScan(context.Background(), scanID, ssr.Queued, nil)
+	var transitionErr *ssr.TransitionError
+	require.True(t, errors.As(err, &transitionErr))
+	assert.Equal(t, ssr.Success, transitionErr.From)
+	assert.Equal(t, ssr.Queued, transitionErr.To)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	rows = sqlmock.NewRows([]string{"id", "status", "repository_id", "queued_at", "scanning_at", "finished_at", "attempts"}).AddRow(scanID, ssr.Failure, 1, now, now, now, 3)
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlSelectScanForUpdate).WithArgs(scanID).WillReturnRows(rows)
+	mock.ExpectExec(sqlUpdateScan).WithArgs(ssr.Queued, sqlmock.AnyArg(), time.Time{}, time.Time{}, scanID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(sqlResetScanAttempts).WithArgs(0, scanID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(sqlDeleteFindings).WithArgs(scanID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(sqlSelectRepositoryWebhooks).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(sqlInsertChange).
+		WithArgs(sqlmock.AnyArg(), ssr.ChangeScanUpdated, scanID.String(), 1, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	scanResult, err = scanService.UpdateScan(context.Background(), scanID, ssr.Queued, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ssr.Queued, scanResult.Status)
+	assert.Zero(t, scanResult.Attempts)
+	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 func testDeleteScan(t *testing.T, scanID uuid.UUID) {
@@ -243,9 +417,64 @@ func testDeleteScan(t *testing.T, scanID uuid.UUID) {
 	})
 	require.NoError(t, err)
 
+	var payloads []string
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(sqlSelectScanRepository)).WithArgs(scanID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "repository_id"}).AddRow(scanID, 1))
+	mock.ExpectExec(regexp.QuoteMeta(sqlDeleteFindings)).WithArgs(scanID).WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec(regexp.QuoteMeta(sqlDeleteScan)).WithArgs(scanID).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(sqlInsertChange)).
+		WithArgs(sqlmock.AnyArg(), ssr.ChangeScanDeleted, scanID.String(), 1, payloadArg{&payloads}, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	scanService := NewScanService(gormDB)
-	require.NoError(t, scanService.DeleteScan(scanID))
+	require.NoError(t, scanService.DeleteScan(context.Background(), scanID))
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.Len(t, payloads, 1)
+	assert.JSONEq(t, `{"id": "`+scanID.String()+`", "repository_id": 1}`, payloads[0])
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(sqlSelectScanRepository)).WithArgs(scanID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "repository_id"}))
+	mock.ExpectRollback()
+
+	assert.ErrorIs(t, scanService.DeleteScan(context.Background(), scanID), ssr.ErrScanNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func testGetLatestDefaultBranchScan(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	id := uuid.New()
+	mock.ExpectQuery(sqlSelectLatestDefaultBranchScan).
+		WithArgs(1, ssr.Success).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "repository_id", "branch"}).AddRow(id, ssr.Success, 1, "main"))
+	mock.ExpectQuery(sqlPreloadFindings).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "scan_id", "rule_id", "fingerprint"}).AddRow(1, id, "G402", "abc"))
+
+	scanService := NewScanService(gormDB)
+	scan, err := scanService.GetLatestDefaultBranchScan(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, id, scan.ID)
+	assert.Equal(t, "main", scan.Branch)
+	require.Len(t, scan.Findings, 1)
+
+	mock.ExpectQuery(sqlSelectLatestDefaultBranchScan).
+		WithArgs(2, ssr.Success).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err = scanService.GetLatestDefaultBranchScan(context.Background(), 2)
+	assert.True(t, errors.Is(err, ssr.ErrScanNotFound))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,150 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/quantonganh/ssr"
+)
+
+type suppressionService struct {
+	db *gorm.DB
+}
+
+func NewSuppressionService(db *gorm.DB) ssr.SuppressionService {
+	return &suppressionService{
+		db: db,
+	}
+}
+
+func (ss *suppressionService) Create(ctx context.Context, s *ssr.Suppression) error {
+	if err := authorizeRepository(ctx, ss.db.WithContext(ctx), s.RepositoryID, ssr.RoleMaintainer, ssr.ErrRepositoryNotFound); err != nil {
+		return err
+	}
+	if err := ss.db.WithContext(ctx).Select("id").First(&ssr.Repository{}, s.RepositoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ssr.ErrRepositoryNotFound
+		}
+		return errors.Wrapf(err, "failed to select repository: %d", s.RepositoryID)
+	}
+
+	if err := ss.db.WithContext(ctx).Create(s).Error; err != nil {
+		return errors.Wrap(err, "failed to create suppression")
+	}
+	return nil
+}
+
+func (ss *suppressionService) Get(ctx context.Context, id uint64) (*ssr.Suppression, error) {
+	var s ssr.Suppression
+	if err := ss.db.WithContext(ctx).First(&s, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ssr.ErrSuppressionNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to select suppression: %d", id)
+	}
+	if err := authorizeRepository(ctx, ss.db.WithContext(ctx), s.RepositoryID, ssr.RoleReader, ssr.ErrSuppressionNotFound); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (ss *suppressionService) List(ctx context.Context, repositoryID uint64) ([]*ssr.Suppression, error) {
+	if err := authorizeRepository(ctx, ss.db.WithContext(ctx), repositoryID, ssr.RoleReader, ssr.ErrRepositoryNotFound); err != nil {
+		return nil, err
+	}
+	suppressions := []*ssr.Suppression{}
+	if err := ss.db.WithContext(ctx).Where("repository_id = ?", repositoryID).Order("id").Find(&suppressions).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to list suppressions of repository: %d", repositoryID)
+	}
+	return suppressions, nil
+}
+
+func (ss *suppressionService) Delete(ctx context.Context, id uint64) error {
+	if ssr.UserFromContext(ctx) != nil {
+		s, err := ss.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := authorizeRepository(ctx, ss.db.WithContext(ctx), s.RepositoryID, ssr.RoleMaintainer, ssr.ErrSuppressionNotFound); err != nil {
+			return err
+		}
+	}
+
+	return ss.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var s ssr.Suppression
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&s, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ssr.ErrSuppressionNotFound
+			}
+			return errors.Wrapf(err, "failed to select suppression: %d", id)
+		}
+		if _, err := unsuppress(tx, &s, time.Now()); err != nil {
+			return err
+		}
+		if err := tx.Delete(&s).Error; err != nil {
+			return errors.Wrapf(err, "failed to delete suppression: %d", id)
+		}
+		return nil
+	})
+}
+
+// Expire re-opens the findings of the suppressions that expired at now, one transaction for all of them, so that
+// the findings are re-opened if and only if their changes are recorded.
+func (ss *suppressionService) Expire(ctx context.Context, now time.Time) (int64, error) {
+	var n int64
+	err := ss.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var suppressions []*ssr.Suppression
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("expires_at <= ? AND EXISTS (SELECT 1 FROM finding WHERE finding.suppression_id = suppression.id)", now).
+			Order("id").
+			Find(&suppressions).Error
+		if err != nil {
+			return errors.Wrap(err, "failed to select expired suppressions")
+		}
+		for _, s := range suppressions {
+			reopened, err := unsuppress(tx, s, now)
+			if err != nil {
+				return err
+			}
+			n += reopened
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// unsuppress re-opens the findings of s and records them in the outbox, within tx. It returns how many findings
+// were re-opened.
+func unsuppress(tx *gorm.DB, s *ssr.Suppression, now time.Time) (int64, error) {
+	var findings ssr.Findings
+	result := tx.Model(&findings).Clauses(clause.Returning{}).Where("suppression_id = ?", s.ID).Updates(unsuppressed())
+	if result.Error != nil {
+		return 0, errors.Wrapf(result.Error, "failed to re-open findings of suppression: %d", s.ID)
+	}
+	if err := recordUnsuppressed(tx, s.RepositoryID, findings, s.ID, now); err != nil {
+		return 0, err
+	}
+	return int64(len(findings)), nil
+}
+
+// activeSuppressions selects the suppressions of a repository that have not expired at the given time.
+func activeSuppressions(repositoryID uint64, now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("repository_id = ? AND (expires_at IS NULL OR expires_at > ?)", repositoryID, now).Order("id")
+	}
+}
+
+// unsuppressed are the updates that re-open a suppressed finding.
+func unsuppressed() map[string]interface{} {
+	return map[string]interface{}{
+		"suppressed":     false,
+		"suppression_id": nil,
+	}
+}
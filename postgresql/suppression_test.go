@@ -0,0 +1,171 @@
+// +build !integration
+
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/quantonganh/ssr"
+)
+
+const (
+	sqlInsertSuppression          = `INSERT INTO "suppression" ("repository_id","rule_id","path_prefix","reason","created_by","created_at","expires_at") VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING "id"`
+	sqlSelectSuppression          = `SELECT * FROM "suppression" WHERE "suppression"."id" = $1 ORDER BY "suppression"."id" LIMIT 1`
+	sqlListSuppressions           = `SELECT * FROM "suppression" WHERE repository_id = $1 ORDER BY id`
+	sqlSelectSuppressionForUpdate = `SELECT * FROM "suppression" WHERE "suppression"."id" = $1 ORDER BY "suppression"."id" LIMIT 1 FOR UPDATE`
+	sqlUnsuppressFindings         = `UPDATE "finding" SET "suppressed"=$1,"suppression_id"=$2 WHERE suppression_id = $3 RETURNING *`
+	sqlDeleteSuppression          = `DELETE FROM "suppression" WHERE "suppression"."id" = $1`
+	sqlSelectExpiredSuppressions  = `SELECT * FROM "suppression" WHERE expires_at <= $1 AND EXISTS (SELECT 1 FROM finding WHERE finding.suppression_id = suppression.id) ORDER BY id FOR UPDATE`
+)
+
+func TestSuppressionService(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	suppressionService := NewSuppressionService(gormDB)
+	expiresAt := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("create suppression", func(t *testing.T) {
+		s := &ssr.Suppression{
+			RepositoryID: 1,
+			RuleID:       "G404",
+			PathPrefix:   "testdata/",
+			Reason:       "fixtures",
+			CreatedBy:    "alice@example.com",
+			ExpiresAt:    &expiresAt,
+		}
+		mock.ExpectQuery(sqlSelectRepositoryID).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(sqlInsertSuppression).
+			WithArgs(1, "G404", "testdata/", "fixtures", "alice@example.com", sqlmock.AnyArg(), expiresAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+		require.NoError(t, suppressionService.Create(context.Background(), s))
+		assert.Equal(t, uint64(3), s.ID)
+		assert.False(t, s.CreatedAt.IsZero())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("create suppression in unknown repository", func(t *testing.T) {
+		mock.ExpectQuery(sqlSelectRepositoryID).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		err := suppressionService.Create(context.Background(), &ssr.Suppression{RepositoryID: 2, RuleID: "G404"})
+		assert.ErrorIs(t, err, ssr.ErrRepositoryNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get unknown suppression", func(t *testing.T) {
+		mock.ExpectQuery(sqlSelectSuppression).
+			WithArgs(4).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := suppressionService.Get(context.Background(), 4)
+		assert.ErrorIs(t, err, ssr.ErrSuppressionNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list suppressions", func(t *testing.T) {
+		mock.ExpectQuery(sqlListSuppressions).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "repository_id", "rule_id", "expires_at"}).AddRow(3, 1, "G404", expiresAt))
+
+		suppressions, err := suppressionService.List(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, suppressions, 1)
+		assert.Equal(t, expiresAt, *suppressions[0].ExpiresAt)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("delete suppression", func(t *testing.T) {
+		scanID := uuid.New()
+		var payloads []string
+		mock.ExpectBegin()
+		mock.ExpectQuery(sqlSelectSuppressionForUpdate).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "repository_id", "rule_id"}).AddRow(3, 1, "G404"))
+		mock.ExpectQuery(sqlUnsuppressFindings).
+			WithArgs(false, nil, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "scan_id", "rule_id", "fingerprint", "suppressed"}).
+				AddRow(5, scanID, "G404", "abc", false).
+				AddRow(6, scanID, "G404", "def", false))
+		mock.ExpectExec(sqlInsertChange).
+			WithArgs(sqlmock.AnyArg(), ssr.ChangeFindingUnsuppressed, "abc", 1, payloadArg{&payloads}, sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(sqlInsertChange).
+			WithArgs(sqlmock.AnyArg(), ssr.ChangeFindingUnsuppressed, "def", 1, payloadArg{&payloads}, sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(sqlDeleteSuppression).
+			WithArgs(3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, suppressionService.Delete(context.Background(), 3))
+		require.NoError(t, mock.ExpectationsWereMet())
+
+		require.Len(t, payloads, 2)
+		var data map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(payloads[0]), &data))
+		assert.Equal(t, scanID.String(), data["scan_id"])
+		assert.Equal(t, float64(3), data["suppression_id"])
+	})
+
+	t.Run("delete unknown suppression", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(sqlSelectSuppressionForUpdate).
+			WithArgs(4).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		assert.ErrorIs(t, suppressionService.Delete(context.Background(), 4), ssr.ErrSuppressionNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("expire suppressions", func(t *testing.T) {
+		now := expiresAt.Add(time.Hour)
+		mock.ExpectBegin()
+		mock.ExpectQuery(sqlSelectExpiredSuppressions).
+			WithArgs(now).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "repository_id", "expires_at"}).
+				AddRow(3, 1, expiresAt).
+				AddRow(5, 2, expiresAt))
+		mock.ExpectQuery(sqlUnsuppressFindings).
+			WithArgs(false, nil, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "scan_id", "fingerprint"}).AddRow(5, uuid.New(), "abc"))
+		mock.ExpectExec(sqlInsertChange).
+			WithArgs(sqlmock.AnyArg(), ssr.ChangeFindingUnsuppressed, "abc", 1, sqlmock.AnyArg(), now, nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(sqlUnsuppressFindings).
+			WithArgs(false, nil, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "scan_id", "fingerprint"}).AddRow(8, uuid.New(), "ghi"))
+		mock.ExpectExec(sqlInsertChange).
+			WithArgs(sqlmock.AnyArg(), ssr.ChangeFindingUnsuppressed, "ghi", 2, sqlmock.AnyArg(), now, nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		n, err := suppressionService.Expire(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
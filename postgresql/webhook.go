@@ -0,0 +1,294 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/quantonganh/ssr"
+)
+
+type webhookService struct {
+	db *gorm.DB
+}
+
+func NewWebhookService(db *gorm.DB) ssr.WebhookService {
+	return &webhookService{
+		db: db,
+	}
+}
+
+func (ws *webhookService) Create(ctx context.Context, w *ssr.Webhook) error {
+	db := ws.db.WithContext(ctx)
+	if err := authorizeWebhook(ctx, db, w.RepositoryID, ssr.ErrRepositoryNotFound); err != nil {
+		return err
+	}
+	w.OrganizationID = organizationOf(ctx, w.OrganizationID)
+	if w.RepositoryID != nil {
+		var repo ssr.Repository
+		if err := db.Select("id", "organization_id").First(&repo, *w.RepositoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ssr.ErrRepositoryNotFound
+			}
+			return errors.Wrapf(err, "failed to select repository: %d", *w.RepositoryID)
+		}
+		w.OrganizationID = repo.OrganizationID
+	}
+
+	if err := db.Create(w).Error; err != nil {
+		return errors.Wrap(err, "failed to create webhook")
+	}
+	return nil
+}
+
+// Get returns a webhook that the user of ctx may manage, the webhooks of other organizations being not found.
+func (ws *webhookService) Get(ctx context.Context, id uint64) (*ssr.Webhook, error) {
+	db := ws.db.WithContext(ctx)
+	var w ssr.Webhook
+	if err := db.Scopes(inOrganization(ctx, "organization_id")).First(&w, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ssr.ErrWebhookNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to select webhook: %d", id)
+	}
+	if err := authorizeWebhook(ctx, db, w.RepositoryID, ssr.ErrWebhookNotFound); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (ws *webhookService) List(ctx context.Context, repositoryID uint64) ([]*ssr.Webhook, error) {
+	db := ws.db.WithContext(ctx)
+	var repoID *uint64
+	if repositoryID != 0 {
+		repoID = &repositoryID
+	}
+	if err := authorizeWebhook(ctx, db, repoID, ssr.ErrRepositoryNotFound); err != nil {
+		return nil, err
+	}
+
+	tx := db.Scopes(inOrganization(ctx, "organization_id"))
+	if repositoryID == 0 {
+		tx = tx.Where("repository_id IS NULL")
+	} else {
+		tx = tx.Where("repository_id = ?", repositoryID)
+	}
+	webhooks := []*ssr.Webhook{}
+	if err := tx.Order("id").Find(&webhooks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to list webhooks of repository: %d", repositoryID)
+	}
+	return webhooks, nil
+}
+
+func (ws *webhookService) Delete(ctx context.Context, id uint64) error {
+	if _, err := ws.Get(ctx, id); err != nil {
+		return err
+	}
+
+	// Deliveries are deleted along with their webhook by the foreign key.
+	result := ws.db.WithContext(ctx).Delete(&ssr.Webhook{}, id)
+	if result.Error != nil {
+		return errors.Wrapf(result.Error, "failed to delete webhook: %d", id)
+	}
+	if result.RowsAffected == 0 {
+		return ssr.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (ws *webhookService) ListDeliveries(ctx context.Context, webhookID uint64, page, limit int) ([]*ssr.WebhookDelivery, error) {
+	if _, err := ws.Get(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	deliveries := []*ssr.WebhookDelivery{}
+	err := ws.db.WithContext(ctx).
+		Scopes(paginate(page, limit)).
+		Where("webhook_id = ?", webhookID).
+		Order("id DESC").
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list deliveries of webhook: %d", webhookID)
+	}
+	return deliveries, nil
+}
+
+func (ws *webhookService) Redeliver(ctx context.Context, webhookID, deliveryID uint64) (*ssr.WebhookDelivery, error) {
+	if _, err := ws.Get(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	db := ws.db.WithContext(ctx)
+	var d ssr.WebhookDelivery
+	if err := db.Where("webhook_id = ?", webhookID).First(&d, deliveryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ssr.ErrWebhookDeliveryNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to select delivery: %d", deliveryID)
+	}
+
+	now := time.Now()
+	redelivery := &ssr.WebhookDelivery{
+		WebhookID:     d.WebhookID,
+		EventID:       d.EventID,
+		Event:         d.Event,
+		Payload:       d.Payload,
+		Status:        ssr.DeliveryPending,
+		NextAttemptAt: &now,
+	}
+	if err := db.Create(redelivery).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to redeliver delivery: %d", deliveryID)
+	}
+	return redelivery, nil
+}
+
+// authorizeWebhook checks that the user of ctx may manage the webhooks of a repository, or the global webhooks
+// when repositoryID is nil. Webhooks send findings out, so managing them takes the admin role.
+func authorizeWebhook(ctx context.Context, db *gorm.DB, repositoryID *uint64, notFound error) error {
+	if repositoryID == nil {
+		return authorize(ctx, db, ssr.RoleAdmin)
+	}
+	return authorizeRepository(ctx, db, *repositoryID, ssr.RoleAdmin, notFound)
+}
+
+type webhookQueue struct {
+	db *gorm.DB
+}
+
+func NewWebhookQueue(db *gorm.DB) ssr.WebhookQueue {
+	return &webhookQueue{
+		db: db,
+	}
+}
+
+// Claim locks the due deliveries, skipping the ones that other replicas are claiming, and pushes their next
+// attempt back by lease, so that every delivery is attempted by a single dispatcher at a time.
+func (q *webhookQueue) Claim(ctx context.Context, limit int, lease time.Duration) ([]*ssr.WebhookDelivery, error) {
+	now := time.Now()
+	var deliveries []*ssr.WebhookDelivery
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", ssr.DeliveryPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).Error
+		if err != nil {
+			return errors.Wrap(err, "failed to claim deliveries")
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint64, 0, len(deliveries))
+		webhookIDs := make([]uint64, 0, len(deliveries))
+		for _, d := range deliveries {
+			ids = append(ids, d.ID)
+			webhookIDs = append(webhookIDs, d.WebhookID)
+		}
+		if err := tx.Model(&ssr.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error; err != nil {
+			return errors.Wrap(err, "failed to lease deliveries")
+		}
+
+		var webhooks []*ssr.Webhook
+		if err := tx.Where("id IN ?", webhookIDs).Find(&webhooks).Error; err != nil {
+			return errors.Wrap(err, "failed to select webhooks of deliveries")
+		}
+		byID := make(map[uint64]*ssr.Webhook, len(webhooks))
+		for _, w := range webhooks {
+			byID[w.ID] = w
+		}
+		for _, d := range deliveries {
+			d.Webhook = byID[d.WebhookID]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (q *webhookQueue) Save(ctx context.Context, d *ssr.WebhookDelivery) error {
+	err := q.db.WithContext(ctx).Model(d).
+		Select("status", "attempts", "next_attempt_at", "response_status", "error", "delivered_at").
+		Updates(d).Error
+	if err != nil {
+		return errors.Wrapf(err, "failed to save delivery: %d", d.ID)
+	}
+	return nil
+}
+
+// publishScan queues the events of a scan that entered its status for the webhooks of its repository, within the
+// transaction that moved it, so that an event is only sent for a change that was committed.
+// findings are the findings of the scan, those first seen by this scan are sent as finding.new.
+func publishScan(tx *gorm.DB, scan *ssr.Scan, findings ssr.Findings, now time.Time) error {
+	var webhooks []*ssr.Webhook
+	if err := tx.Scopes(repositoryWebhooks(scan.RepositoryID)).Find(&webhooks).Error; err != nil {
+		return errors.Wrapf(err, "failed to list webhooks of repository: %d", scan.RepositoryID)
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	var newFindings ssr.Findings
+	if scan.Status == ssr.Success && len(findings) > 0 && subscribed(webhooks, ssr.EventFindingNew) {
+		var fingerprints []string
+		err := tx.Model(&ssr.RepositoryFinding{}).
+			Where("repository_id = ? AND first_scan_id = ?", scan.RepositoryID, scan.ID).
+			Pluck("fingerprint", &fingerprints).Error
+		if err != nil {
+			return errors.Wrapf(err, "failed to select new findings of scan: %s", scan.ID)
+		}
+		isNew := make(map[string]bool, len(fingerprints))
+		for _, fp := range fingerprints {
+			isNew[fp] = true
+		}
+		for _, f := range findings {
+			if isNew[f.Fingerprint] {
+				newFindings = append(newFindings, f)
+				delete(isNew, f.Fingerprint)
+			}
+		}
+	}
+
+	var deliveries []*ssr.WebhookDelivery
+	for _, e := range ssr.ScanEvents(scan, newFindings, now) {
+		for _, w := range webhooks {
+			if !w.Subscribes(e.Type) {
+				continue
+			}
+			d, err := ssr.NewWebhookDelivery(w.ID, e, now)
+			if err != nil {
+				return errors.Wrapf(err, "failed to encode event: %s", e.ID)
+			}
+			deliveries = append(deliveries, d)
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := tx.Create(&deliveries).Error; err != nil {
+		return errors.Wrapf(err, "failed to queue deliveries of scan: %s", scan.ID)
+	}
+	return nil
+}
+
+// repositoryWebhooks selects the webhooks of a repository along with the global ones of its organization.
+func repositoryWebhooks(repositoryID uint64) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		organization := db.Session(&gorm.Session{NewDB: true}).Model(&ssr.Repository{}).Select("organization_id").Where("id = ?", repositoryID)
+		return db.Where("repository_id = ? OR (repository_id IS NULL AND organization_id = (?))", repositoryID, organization).Order("id")
+	}
+}
+
+func subscribed(webhooks []*ssr.Webhook, t ssr.EventType) bool {
+	for _, w := range webhooks {
+		if w.Subscribes(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,293 @@
+// +build !integration
+
+package postgresql
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/quantonganh/ssr"
+)
+
+const (
+	sqlInsertWebhook             = `INSERT INTO "webhook" ("organization_id","repository_id","url","secret","events","created_at") VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id"`
+	sqlSelectWebhook             = `SELECT * FROM "webhook" WHERE "webhook"."id" = $1 ORDER BY "webhook"."id" LIMIT 1`
+	sqlSelectOrganizationWebhook = `SELECT * FROM "webhook" WHERE "webhook"."id" = $1 AND (organization_id = $2) ORDER BY "webhook"."id" LIMIT 1`
+	sqlSelectGlobalWebhooks      = `SELECT * FROM "webhook" WHERE (repository_id IS NULL) AND (organization_id = $1) ORDER BY id`
+	sqlSelectRepositoryWebhooks  = `SELECT * FROM "webhook" WHERE repository_id = $1 OR (repository_id IS NULL AND organization_id = (SELECT "organization_id" FROM "repository" WHERE id = $2)) ORDER BY id`
+	sqlDeleteWebhook             = `DELETE FROM "webhook" WHERE "webhook"."id" = $1`
+	sqlListDeliveries            = `SELECT * FROM "webhook_delivery" WHERE webhook_id = $1 ORDER BY id DESC LIMIT 10`
+	sqlSelectDelivery            = `SELECT * FROM "webhook_delivery" WHERE webhook_id = $1 AND "webhook_delivery"."id" = $2 ORDER BY "webhook_delivery"."id" LIMIT 1`
+	sqlInsertDelivery            = `INSERT INTO "webhook_delivery" ("webhook_id","event_id","event","payload","status","attempts","next_attempt_at","response_status","error","created_at","delivered_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) RETURNING "id"`
+	sqlClaimDeliveries           = `SELECT * FROM "webhook_delivery" WHERE status = $1 AND next_attempt_at <= $2 ORDER BY next_attempt_at LIMIT 10 FOR UPDATE SKIP LOCKED`
+	sqlLeaseDeliveries           = `UPDATE "webhook_delivery" SET "next_attempt_at"=$1 WHERE id IN ($2,$3)`
+	sqlSelectDeliveryWebhooks    = `SELECT * FROM "webhook" WHERE id IN ($1,$2)`
+	sqlSaveDelivery              = `UPDATE "webhook_delivery" SET "status"=$1,"attempts"=$2,"next_attempt_at"=$3,"response_status"=$4,"error"=$5,"delivered_at"=$6 WHERE "id" = $7`
+	sqlSelectNewFingerprints     = `SELECT "fingerprint" FROM "repository_finding" WHERE repository_id = $1 AND first_scan_id = $2`
+)
+
+func TestWebhookService(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	webhookService := NewWebhookService(gormDB)
+	admin := ssr.NewContextWithUser(context.Background(), (&ssr.APIKey{Name: "ci", OrganizationID: 2, Role: ssr.RoleAdmin}).User())
+	webhookColumns := []string{"id", "organization_id", "repository_id", "url", "secret", "events"}
+	events := []byte(`["scan.succeeded","finding.new"]`)
+
+	t.Run("create repository webhook", func(t *testing.T) {
+		repositoryID := uint64(1)
+		w := &ssr.Webhook{
+			RepositoryID: &repositoryID,
+			URL:          "https://ci.example.com/hook",
+			Secret:       "s3cr3t",
+			Events:       ssr.EventTypeList{ssr.EventScanSucceeded},
+		}
+		mock.ExpectQuery(sqlCountOrganizationRepository).
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(sqlSelectRepositoryOrganization).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "organization_id"}).AddRow(1, 2))
+		mock.ExpectQuery(sqlInsertWebhook).
+			WithArgs(2, 1, "https://ci.example.com/hook", "s3cr3t", []byte(`["scan.succeeded"]`), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+		require.NoError(t, webhookService.Create(admin, w))
+		assert.Equal(t, uint64(1), w.ID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("create webhook in repository of another organization", func(t *testing.T) {
+		repositoryID := uint64(3)
+		mock.ExpectQuery(sqlCountOrganizationRepository).
+			WithArgs(3, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		err := webhookService.Create(admin, &ssr.Webhook{RepositoryID: &repositoryID, URL: "https://ci.example.com/hook"})
+		assert.ErrorIs(t, err, ssr.ErrRepositoryNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list global webhooks", func(t *testing.T) {
+		mock.ExpectQuery(sqlSelectGlobalWebhooks).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(webhookColumns).AddRow(2, 2, nil, "https://chat.example.com/hook", "s3cr3t", events))
+
+		webhooks, err := webhookService.List(admin, 0)
+		require.NoError(t, err)
+		require.Len(t, webhooks, 1)
+		assert.Equal(t, ssr.EventTypeList{ssr.EventScanSucceeded, ssr.EventFindingNew}, webhooks[0].Events)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get webhook of another organization", func(t *testing.T) {
+		mock.ExpectQuery(sqlSelectOrganizationWebhook).
+			WithArgs(5, 2).
+			WillReturnRows(sqlmock.NewRows(webhookColumns))
+
+		_, err := webhookService.Get(admin, 5)
+		assert.ErrorIs(t, err, ssr.ErrWebhookNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("delete webhook", func(t *testing.T) {
+		mock.ExpectQuery(sqlSelectWebhook).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(webhookColumns).AddRow(2, 1, nil, "https://chat.example.com/hook", "s3cr3t", events))
+		mock.ExpectExec(sqlDeleteWebhook).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, webhookService.Delete(context.Background(), 2))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list deliveries", func(t *testing.T) {
+		mock.ExpectQuery(sqlSelectWebhook).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(webhookColumns).AddRow(2, 1, nil, "https://chat.example.com/hook", "s3cr3t", events))
+		mock.ExpectQuery(sqlListDeliveries).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "webhook_id", "event", "payload", "status", "attempts", "response_status"}).
+				AddRow(7, 2, ssr.EventScanSucceeded, []byte(`{"type":"scan.succeeded"}`), ssr.DeliveryFailed, 8, 500))
+
+		deliveries, err := webhookService.ListDeliveries(context.Background(), 2, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, ssr.Payload(`{"type":"scan.succeeded"}`), deliveries[0].Payload)
+		assert.Equal(t, 500, deliveries[0].ResponseStatus)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("redeliver", func(t *testing.T) {
+		eventID := uuid.New()
+		mock.ExpectQuery(sqlSelectWebhook).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(webhookColumns).AddRow(2, 1, nil, "https://chat.example.com/hook", "s3cr3t", events))
+		mock.ExpectQuery(sqlSelectDelivery).
+			WithArgs(2, 7).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "webhook_id", "event_id", "event", "payload", "status", "attempts"}).
+				AddRow(7, 2, eventID, ssr.EventScanSucceeded, []byte(`{"type":"scan.succeeded"}`), ssr.DeliveryFailed, 8))
+		mock.ExpectQuery(sqlInsertDelivery).
+			WithArgs(2, eventID, ssr.EventScanSucceeded, `{"type":"scan.succeeded"}`, ssr.DeliveryPending, 0, sqlmock.AnyArg(), 0, "", sqlmock.AnyArg(), nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+
+		d, err := webhookService.Redeliver(context.Background(), 2, 7)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(8), d.ID)
+		assert.Equal(t, eventID, d.EventID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("redeliver delivery of another webhook", func(t *testing.T) {
+		mock.ExpectQuery(sqlSelectWebhook).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(webhookColumns).AddRow(2, 1, nil, "https://chat.example.com/hook", "s3cr3t", events))
+		mock.ExpectQuery(sqlSelectDelivery).
+			WithArgs(2, 9).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := webhookService.Redeliver(context.Background(), 2, 9)
+		assert.ErrorIs(t, err, ssr.ErrWebhookDeliveryNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestWebhookQueue(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	q := NewWebhookQueue(gormDB)
+
+	t.Run("claim", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(sqlClaimDeliveries).
+			WithArgs(ssr.DeliveryPending, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "webhook_id", "status"}).
+				AddRow(1, 2, ssr.DeliveryPending).
+				AddRow(3, 4, ssr.DeliveryPending))
+		mock.ExpectExec(sqlLeaseDeliveries).
+			WithArgs(sqlmock.AnyArg(), 1, 3).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery(sqlSelectDeliveryWebhooks).
+			WithArgs(2, 4).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "url", "secret", "events"}).
+				AddRow(2, "https://ci.example.com/hook", "s3cr3t", []byte(`["scan.succeeded"]`)).
+				AddRow(4, "https://chat.example.com/hook", "s3cr3t", []byte(`["scan.failed"]`)))
+		mock.ExpectCommit()
+
+		deliveries, err := q.Claim(context.Background(), 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		require.NotNil(t, deliveries[1].Webhook)
+		assert.Equal(t, "https://chat.example.com/hook", deliveries[1].Webhook.URL)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("claim without due delivery", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(sqlClaimDeliveries).
+			WithArgs(ssr.DeliveryPending, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectCommit()
+
+		deliveries, err := q.Claim(context.Background(), 10, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, deliveries)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("save", func(t *testing.T) {
+		d := &ssr.WebhookDelivery{ID: 1, WebhookID: 2, Status: ssr.DeliveryPending, Webhook: &ssr.Webhook{ID: 2}}
+		d.Record(time.Now(), 503, nil, 8, time.Second)
+		mock.ExpectExec(sqlSaveDelivery).
+			WithArgs(ssr.DeliveryPending, 1, sqlmock.AnyArg(), 503, "unexpected status: 503", nil, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, q.Save(context.Background(), d))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPublishScan(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	scan := &ssr.Scan{ID: uuid.New(), Status: ssr.Success, RepositoryID: 1, Branch: "main"}
+	findings := ssr.Findings{
+		{RuleID: "G402", Fingerprint: "abc"},
+		{RuleID: "G404", Fingerprint: "def"},
+	}
+	mock.ExpectQuery(sqlSelectRepositoryWebhooks).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "events"}).
+			AddRow(2, "https://ci.example.com/hook", []byte(`["scan.succeeded"]`)).
+			AddRow(3, "https://chat.example.com/hook", []byte(`["scan.failed","finding.new"]`)))
+	mock.ExpectQuery(sqlSelectNewFingerprints).
+		WithArgs(1, scan.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"fingerprint"}).AddRow("def"))
+	var payloads []string
+	mock.ExpectQuery(`INSERT INTO "webhook_delivery" ("webhook_id","event_id","event","payload","status","attempts","next_attempt_at","response_status","error","created_at","delivered_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11),($12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22) RETURNING "id"`).
+		WithArgs(
+			2, sqlmock.AnyArg(), ssr.EventScanSucceeded, payloadArg{&payloads}, ssr.DeliveryPending, 0, sqlmock.AnyArg(), 0, "", sqlmock.AnyArg(), nil,
+			3, sqlmock.AnyArg(), ssr.EventFindingNew, payloadArg{&payloads}, ssr.DeliveryPending, 0, sqlmock.AnyArg(), 0, "", sqlmock.AnyArg(), nil,
+		).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+
+	require.NoError(t, publishScan(gormDB, scan, findings, time.Now()))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	require.Len(t, payloads, 2)
+	var e ssr.Event
+	require.NoError(t, json.Unmarshal([]byte(payloads[1]), &e))
+	assert.Equal(t, ssr.EventFindingNew, e.Type)
+	assert.Equal(t, scan.ID, e.ScanID)
+	require.NotNil(t, e.Finding)
+	assert.Equal(t, "G404", e.Finding.RuleID)
+}
+
+// payloadArg matches any payload of a delivery and collects it.
+type payloadArg struct {
+	payloads *[]string
+}
+
+func (a payloadArg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	if ok {
+		*a.payloads = append(*a.payloads, s)
+	}
+	return ok
+}
@@ -1,17 +1,52 @@
 package ssr
 
+import (
+	"context"
+)
+
+var ErrRepositoryNotFound = NewError(KindNotFound, "repository not found")
+
+// ErrRepositoryInUse is returned when deleting a repository that still has scans.
+var ErrRepositoryInUse = NewError(KindConflict, "repository still has scans")
+
 type Repository struct {
 	ID uint64 `json:"id" gorm:"primaryKey"`
+	// OrganizationID is the tenant that owns the repository, see Organization.
+	OrganizationID uint64 `json:"organization_id" gorm:"index;not null"`
 	Provider string `json:"provider"`
 	FullName string `json:"full_name"`
 	Description string `json:"description"`
+	// DefaultBranch is the branch that other branches are compared against, see ScanService.GetLatestDefaultBranchScan.
+	DefaultBranch string `json:"default_branch"`
 }
 
 func (Repository) TableName() string {
 	return "repository"
 }
 
+// RepositoryFilter narrows down the repositories returned by List.
+// Empty fields are ignored.
+type RepositoryFilter struct {
+	Provider string
+	FullName string
+
+	Page  int
+	Limit int
+}
+
+// RepositoryUpdate holds the fields that can be changed on an existing repository.
+// Nil fields are left untouched.
+type RepositoryUpdate struct {
+	Provider    *string `json:"provider"`
+	FullName    *string `json:"full_name"`
+	Description *string `json:"description"`
+	DefaultBranch *string `json:"default_branch"`
+}
+
 type RepositoryService interface {
-	Create(r *Repository) error
-	Get(repoID uint64) (*Repository, error)
-}
\ No newline at end of file
+	Create(ctx context.Context, r *Repository) error
+	Get(ctx context.Context, repoID uint64) (*Repository, error)
+	List(ctx context.Context, filter RepositoryFilter) ([]*Repository, error)
+	Update(ctx context.Context, repoID uint64, upd RepositoryUpdate) (*Repository, error)
+	Delete(ctx context.Context, repoID uint64) error
+}
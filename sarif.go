@@ -0,0 +1,455 @@
+package ssr
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SARIFContentType is the media type of a SARIF log.
+const SARIFContentType = "application/sarif+json"
+
+// SARIFLog is the subset of a SARIF 2.1.0 log that ssr understands.
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type SARIFLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema,omitempty"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+type SARIFRun struct {
+	Tool        SARIFTool         `json:"tool"`
+	Invocations []SARIFInvocation `json:"invocations,omitempty"`
+	Results     []SARIFResult     `json:"results"`
+}
+
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+	// Extensions hold the rules of plugins, e.g. the query packs of CodeQL.
+	Extensions []SARIFDriver `json:"extensions,omitempty"`
+}
+
+// SARIFDriver is a tool component: the driver of a tool or one of its extensions.
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []SARIFRule `json:"rules,omitempty"`
+}
+
+type SARIFInvocation struct {
+	ExecutionSuccessful bool `json:"executionSuccessful"`
+}
+
+type SARIFRule struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name,omitempty"`
+	ShortDescription     *SARIFMessage          `json:"shortDescription,omitempty"`
+	FullDescription      *SARIFMessage          `json:"fullDescription,omitempty"`
+	HelpURI              string                 `json:"helpUri,omitempty"`
+	DefaultConfiguration *SARIFConfiguration    `json:"defaultConfiguration,omitempty"`
+	Properties           map[string]interface{} `json:"properties,omitempty"`
+}
+
+type SARIFConfiguration struct {
+	Level string `json:"level,omitempty"`
+}
+
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+type SARIFResult struct {
+	RuleID              string             `json:"ruleId,omitempty"`
+	RuleIndex           *int               `json:"ruleIndex,omitempty"`
+	Rule                *SARIFRuleRef      `json:"rule,omitempty"`
+	Level               string             `json:"level,omitempty"`
+	Message             SARIFMessage       `json:"message"`
+	Locations           []SARIFLocation    `json:"locations,omitempty"`
+	PartialFingerprints map[string]string  `json:"partialFingerprints,omitempty"`
+	Suppressions        []SARIFSuppression `json:"suppressions,omitempty"`
+}
+
+// SARIFRuleRef points at the rule of a result, in the driver or, with ToolComponent, in an extension.
+type SARIFRuleRef struct {
+	ID            string             `json:"id,omitempty"`
+	Index         *int               `json:"index,omitempty"`
+	ToolComponent *SARIFComponentRef `json:"toolComponent,omitempty"`
+}
+
+// SARIFComponentRef points at a tool component: Index is that of an extension, Name is that of the driver or of an
+// extension.
+type SARIFComponentRef struct {
+	Name  string `json:"name,omitempty"`
+	Index *int   `json:"index,omitempty"`
+}
+
+type SARIFSuppression struct {
+	Kind   string `json:"kind"`
+	Status string `json:"status,omitempty"`
+}
+
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type SARIFRegion struct {
+	StartLine   int64         `json:"startLine,omitempty"`
+	StartColumn int64         `json:"startColumn,omitempty"`
+	EndLine     int64         `json:"endLine,omitempty"`
+	EndColumn   int64         `json:"endColumn,omitempty"`
+	Snippet     *SARIFMessage `json:"snippet,omitempty"`
+}
+
+// DecodeSARIF reads a SARIF log from r.
+func DecodeSARIF(r io.Reader) (*SARIFLog, error) {
+	var log SARIFLog
+	if err := json.NewDecoder(r).Decode(&log); err != nil {
+		return nil, errors.Wrap(err, "failed to decode SARIF log")
+	}
+	if log.Version != "2.1.0" {
+		return nil, errors.Errorf("unsupported SARIF version: %q", log.Version)
+	}
+	return &log, nil
+}
+
+// Status reports Failure if any tool invocation of the log did not complete, and Success otherwise.
+func (l *SARIFLog) Status() Status {
+	for _, run := range l.Runs {
+		for _, inv := range run.Invocations {
+			if !inv.ExecutionSuccessful {
+				return Failure
+			}
+		}
+	}
+	return Success
+}
+
+// Findings converts every result of every run of the log into a Finding.
+func (l *SARIFLog) Findings() Findings {
+	findings := Findings{}
+	for _, run := range l.Runs {
+		rules := newSARIFRules(&run.Tool)
+		for i := range run.Results {
+			findings = append(findings, run.Results[i].finding(run.Tool.Driver.Name, rules.rule(&run.Results[i])))
+		}
+	}
+	return findings
+}
+
+// sarifRules resolves the rules of the results of a run.
+type sarifRules struct {
+	tool *SARIFTool
+	byID map[*SARIFDriver]map[string]*SARIFRule
+}
+
+func newSARIFRules(tool *SARIFTool) *sarifRules {
+	return &sarifRules{
+		tool: tool,
+		byID: make(map[*SARIFDriver]map[string]*SARIFRule),
+	}
+}
+
+// rule returns the rule of result, nil if it is not known. The rule is looked up in the tool component that
+// result.rule points at, the driver by default, by ID then by index. An index of -1, the default of SARIF, means that
+// the rule is not known.
+func (rs *sarifRules) rule(result *SARIFResult) *SARIFRule {
+	component := &rs.tool.Driver
+	id, index := result.RuleID, result.RuleIndex
+	if ref := result.Rule; ref != nil {
+		if id == "" {
+			id = ref.ID
+		}
+		if ref.Index != nil {
+			index = ref.Index
+		}
+		if ref.ToolComponent != nil {
+			if component = rs.component(ref.ToolComponent); component == nil {
+				return nil
+			}
+		}
+	}
+
+	if rule := rs.ruleByID(component, id); rule != nil {
+		return rule
+	}
+	if index != nil && *index >= 0 && *index < len(component.Rules) {
+		return &component.Rules[*index]
+	}
+	return nil
+}
+
+// component returns the tool component that ref points at, nil if there is none.
+func (rs *sarifRules) component(ref *SARIFComponentRef) *SARIFDriver {
+	if ref.Index != nil {
+		if *ref.Index >= 0 && *ref.Index < len(rs.tool.Extensions) {
+			return &rs.tool.Extensions[*ref.Index]
+		}
+		return nil
+	}
+	if ref.Name == "" || ref.Name == rs.tool.Driver.Name {
+		return &rs.tool.Driver
+	}
+	for i := range rs.tool.Extensions {
+		if rs.tool.Extensions[i].Name == ref.Name {
+			return &rs.tool.Extensions[i]
+		}
+	}
+	return nil
+}
+
+func (rs *sarifRules) ruleByID(component *SARIFDriver, id string) *SARIFRule {
+	if id == "" {
+		return nil
+	}
+	rules, ok := rs.byID[component]
+	if !ok {
+		rules = make(map[string]*SARIFRule, len(component.Rules))
+		for i := range component.Rules {
+			rules[component.Rules[i].ID] = &component.Rules[i]
+		}
+		rs.byID[component] = rules
+	}
+	return rules[id]
+}
+
+func (r *SARIFResult) finding(tool string, rule *SARIFRule) Finding {
+	f := Finding{
+		Type:   FindingSAST,
+		RuleID: r.RuleID,
+		Tool:   tool,
+		Metadata: Metadata{
+			Description: r.Message.Text,
+			Severity:    sarifSeverity(r.Level, rule),
+		},
+	}
+
+	if rule != nil {
+		if f.RuleID == "" {
+			f.RuleID = rule.ID
+		}
+		f.Metadata.Name = rule.Name
+		f.Metadata.HelpURI = rule.HelpURI
+		if f.Metadata.Description == "" && rule.ShortDescription != nil {
+			f.Metadata.Description = rule.ShortDescription.Text
+		}
+	}
+
+	if len(r.Locations) > 0 {
+		loc := r.Locations[0].PhysicalLocation
+		f.Location.Path = strings.TrimPrefix(loc.ArtifactLocation.URI, "file://")
+		if loc.Region != nil {
+			f.Location.Positions = Positions{
+				Begin: Begin{Line: loc.Region.StartLine, Column: loc.Region.StartColumn},
+				End:   End{Line: loc.Region.EndLine, Column: loc.Region.EndColumn},
+			}
+			if loc.Region.Snippet != nil {
+				f.Location.Snippet = loc.Region.Snippet.Text
+			}
+		}
+	}
+
+	return f
+}
+
+// sarifSeverity maps the level of a result to a severity.
+// The security-severity score that CodeQL, gosec and others attach to rules takes precedence over the level.
+func sarifSeverity(level string, rule *SARIFRule) Severity {
+	if rule != nil {
+		if score, ok := securitySeverity(rule.Properties); ok {
+			switch {
+			case score >= 9:
+				return SeverityCritical
+			case score >= 7:
+				return SeverityHigh
+			case score >= 4:
+				return SeverityMedium
+			case score > 0:
+				return SeverityLow
+			default:
+				return SeverityInfo
+			}
+		}
+		if level == "" && rule.DefaultConfiguration != nil {
+			level = rule.DefaultConfiguration.Level
+		}
+	}
+
+	switch level {
+	case "error":
+		return SeverityHigh
+	case "note":
+		return SeverityLow
+	case "none":
+		return SeverityInfo
+	default:
+		// warning is the default level of a SARIF result
+		return SeverityMedium
+	}
+}
+
+func securitySeverity(properties map[string]interface{}) (float64, bool) {
+	switch v := properties["security-severity"].(type) {
+	case string:
+		score, err := strconv.ParseFloat(v, 64)
+		return score, err == nil
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// sarifSchema is the location of the JSON schema that exported logs conform to.
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// NewSARIFLog renders the findings of scan as a SARIF log with one run per tool.
+// Findings without a tool are reported under "ssr".
+func NewSARIFLog(scan *Scan) *SARIFLog {
+	log := &SARIFLog{
+		Version: "2.1.0",
+		Schema:  sarifSchema,
+		Runs:    []SARIFRun{},
+	}
+
+	runs := make(map[string]*SARIFRun)
+	ruleIndexes := make(map[string]map[string]int)
+	var tools []string
+	for _, f := range scan.Findings {
+		tool := f.Tool
+		if tool == "" {
+			tool = "ssr"
+		}
+
+		run, ok := runs[tool]
+		if !ok {
+			run = &SARIFRun{
+				Tool:    SARIFTool{Driver: SARIFDriver{Name: tool, Rules: []SARIFRule{}}},
+				Results: []SARIFResult{},
+			}
+			if scan.Status == Success || scan.Status == Failure {
+				run.Invocations = []SARIFInvocation{{ExecutionSuccessful: scan.Status == Success}}
+			}
+			runs[tool] = run
+			ruleIndexes[tool] = make(map[string]int)
+			tools = append(tools, tool)
+		}
+
+		index, ok := ruleIndexes[tool][f.RuleID]
+		if !ok {
+			index = len(run.Tool.Driver.Rules)
+			ruleIndexes[tool][f.RuleID] = index
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule(f))
+		}
+		run.Results = append(run.Results, sarifResult(f, index))
+	}
+
+	for _, tool := range tools {
+		log.Runs = append(log.Runs, *runs[tool])
+	}
+	if len(log.Runs) == 0 {
+		// A clean scan is still reported, so that consumers know its previous results are gone.
+		log.Runs = append(log.Runs, SARIFRun{
+			Tool:    SARIFTool{Driver: SARIFDriver{Name: "ssr"}},
+			Results: []SARIFResult{},
+		})
+	}
+	return log
+}
+
+func sarifRule(f Finding) SARIFRule {
+	rule := SARIFRule{
+		ID:      f.RuleID,
+		Name:    f.Metadata.Name,
+		HelpURI: f.Metadata.HelpURI,
+		DefaultConfiguration: &SARIFConfiguration{
+			Level: sarifLevel(f.Metadata.Severity),
+		},
+	}
+	if f.Metadata.Description != "" {
+		rule.ShortDescription = &SARIFMessage{Text: f.Metadata.Description}
+	}
+	if score, ok := sarifSecuritySeverity[f.Metadata.Severity]; ok {
+		rule.Properties = map[string]interface{}{
+			"security-severity": score,
+		}
+	}
+	return rule
+}
+
+func sarifResult(f Finding, ruleIndex int) SARIFResult {
+	result := SARIFResult{
+		RuleID:    f.RuleID,
+		RuleIndex: &ruleIndex,
+		Level:     sarifLevel(f.Metadata.Severity),
+		Message:   SARIFMessage{Text: f.Metadata.Description},
+	}
+	if result.Message.Text == "" {
+		result.Message.Text = f.RuleID
+	}
+	if f.Fingerprint != "" {
+		result.PartialFingerprints = map[string]string{
+			"ssr/v1": f.Fingerprint,
+		}
+	}
+
+	if f.Suppressed {
+		// The suppression is managed by ssr rather than in the source code.
+		result.Suppressions = []SARIFSuppression{{Kind: "external", Status: "accepted"}}
+	}
+
+	if f.Location.Path != "" {
+		loc := SARIFLocation{
+			PhysicalLocation: SARIFPhysicalLocation{
+				ArtifactLocation: SARIFArtifactLocation{URI: f.Location.Path},
+			},
+		}
+		// SARIF lines are 1-based, a zero line means that the position is unknown.
+		if begin := f.Location.Positions.Begin; begin.Line > 0 {
+			loc.PhysicalLocation.Region = &SARIFRegion{
+				StartLine:   begin.Line,
+				StartColumn: begin.Column,
+				EndLine:     f.Location.Positions.End.Line,
+				EndColumn:   f.Location.Positions.End.Column,
+			}
+			if f.Location.Snippet != "" {
+				loc.PhysicalLocation.Region.Snippet = &SARIFMessage{Text: f.Location.Snippet}
+			}
+		}
+		result.Locations = []SARIFLocation{loc}
+	}
+	return result
+}
+
+// sarifSecuritySeverity scores each severity so that sarifSeverity maps it back to the same severity.
+var sarifSecuritySeverity = map[Severity]string{
+	SeverityCritical: "9.5",
+	SeverityHigh:     "8.0",
+	SeverityMedium:   "5.5",
+	SeverityLow:      "2.0",
+}
+
+func sarifLevel(severity Severity) string {
+	switch severity {
+	case SeverityCritical, SeverityHigh:
+		return "error"
+	case SeverityMedium:
+		return "warning"
+	case SeverityLow:
+		return "note"
+	default:
+		return "none"
+	}
+}
@@ -0,0 +1,224 @@
+package ssr
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeSARIF(t *testing.T) {
+	f, err := os.Open("testdata/gosec.sarif")
+	require.NoError(t, err)
+	defer f.Close()
+
+	log, err := DecodeSARIF(f)
+	require.NoError(t, err)
+	assert.Equal(t, Success, log.Status())
+
+	findings := log.Findings()
+	require.Equal(t, 2, len(findings))
+
+	assert.Equal(t, Finding{
+		Type:   "sast",
+		RuleID: "G402",
+		Tool:   "gosec",
+		Location: Location{
+			Path: "connectors/apigateway.go",
+			Positions: Positions{
+				Begin: Begin{Line: 60, Column: 4},
+				End:   End{Line: 60, Column: 31},
+			},
+		},
+		Metadata: Metadata{
+			Description: "TLS InsecureSkipVerify set true.",
+			Severity:    "HIGH",
+			Name:        "Bad TLS connection settings",
+			HelpURI:     "https://cwe.mitre.org/data/definitions/295.html",
+		},
+	}, findings[0])
+
+	assert.Equal(t, "G404", findings[1].RuleID)
+	assert.Equal(t, "util/util.go", findings[1].Location.Path)
+	assert.Equal(t, int64(32), findings[1].Location.Positions.Begin.Line)
+	assert.Equal(t, SeverityLow, findings[1].Metadata.Severity)
+	assert.Equal(t, "Use of weak random number generator (math/rand instead of crypto/rand)", findings[1].Metadata.Description)
+}
+
+func TestDecodeSARIFExtensionRules(t *testing.T) {
+	f, err := os.Open("testdata/codeql.sarif")
+	require.NoError(t, err)
+	defer f.Close()
+
+	log, err := DecodeSARIF(f)
+	require.NoError(t, err)
+
+	findings := log.Findings()
+	require.Len(t, findings, 2)
+	assert.Equal(t, Finding{
+		Type:   "sast",
+		RuleID: "go/sql-injection",
+		Tool:   "CodeQL",
+		Location: Location{
+			Path: "postgresql/repository.go",
+			Positions: Positions{
+				Begin: Begin{Line: 42, Column: 19},
+				End:   End{Column: 24},
+			},
+		},
+		Metadata: Metadata{
+			Description: "This query depends on a [user-provided value](1).",
+			Severity:    SeverityHigh,
+			Name:        "go/sql-injection",
+		},
+	}, findings[0])
+	assert.Equal(t, "go/log-injection", findings[1].RuleID)
+	assert.Equal(t, SeverityHigh, findings[1].Metadata.Severity, "the security-severity of the rule in the extension")
+}
+
+func TestSARIFRules(t *testing.T) {
+	index := func(i int) *int { return &i }
+	tool := &SARIFTool{
+		Driver: SARIFDriver{Name: "CodeQL", Rules: []SARIFRule{{ID: "driver/rule"}}},
+		Extensions: []SARIFDriver{
+			{Name: "codeql/javascript-queries", Rules: []SARIFRule{{ID: "js/xss"}, {ID: "js/sql-injection"}}},
+		},
+	}
+	for name, tt := range map[string]struct {
+		result SARIFResult
+		rule   string
+	}{
+		"rule ID in the driver":     {SARIFResult{RuleID: "driver/rule"}, "driver/rule"},
+		"rule index in the driver":  {SARIFResult{RuleIndex: index(0)}, "driver/rule"},
+		"extension by index":        {SARIFResult{RuleID: "js/sql-injection", Rule: &SARIFRuleRef{ToolComponent: &SARIFComponentRef{Index: index(0)}}}, "js/sql-injection"},
+		"extension by name":         {SARIFResult{Rule: &SARIFRuleRef{Index: index(0), ToolComponent: &SARIFComponentRef{Name: "codeql/javascript-queries"}}}, "js/xss"},
+		"driver by name":            {SARIFResult{Rule: &SARIFRuleRef{ID: "driver/rule", ToolComponent: &SARIFComponentRef{Name: "CodeQL"}}}, "driver/rule"},
+		"rule of an extension only": {SARIFResult{RuleID: "js/xss", RuleIndex: index(0)}, "driver/rule"},
+		"unknown extension":         {SARIFResult{RuleID: "js/xss", Rule: &SARIFRuleRef{ToolComponent: &SARIFComponentRef{Index: index(3)}}}, ""},
+		"unknown rule index":        {SARIFResult{RuleID: "custom", Rule: &SARIFRuleRef{Index: index(-1), ToolComponent: &SARIFComponentRef{Index: index(0)}}}, ""},
+	} {
+		rule := newSARIFRules(tool).rule(&tt.result)
+		if tt.rule == "" {
+			assert.Nil(t, rule, name)
+			continue
+		}
+		if assert.NotNil(t, rule, name) {
+			assert.Equal(t, tt.rule, rule.ID, name)
+		}
+	}
+}
+
+func TestDecodeSARIFFailedRun(t *testing.T) {
+	log, err := DecodeSARIF(strings.NewReader(`{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "semgrep"}}, "invocations": [{"executionSuccessful": false}], "results": []}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, Failure, log.Status())
+	assert.Empty(t, log.Findings())
+}
+
+func TestDecodeSARIFUnknownRuleIndex(t *testing.T) {
+	log, err := DecodeSARIF(strings.NewReader(`{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "eslint", "rules": [{"id": "no-eval"}]}}, "results": [
+		{"ruleId": "custom/rule", "ruleIndex": -1, "level": "error", "message": {"text": "unknown rule"}},
+		{"ruleId": "custom/other", "ruleIndex": 1, "level": "warning", "message": {"text": "out of range"}}
+	]}]}`))
+	require.NoError(t, err)
+
+	findings := log.Findings()
+	require.Len(t, findings, 2)
+	assert.Equal(t, "custom/rule", findings[0].RuleID)
+	assert.Equal(t, SeverityHigh, findings[0].Metadata.Severity)
+	assert.Equal(t, "custom/other", findings[1].RuleID)
+	assert.Empty(t, findings[1].Metadata.Name)
+}
+
+func TestDecodeSARIFUnsupportedVersion(t *testing.T) {
+	_, err := DecodeSARIF(strings.NewReader(`{"version": "1.0.0", "runs": []}`))
+	assert.Error(t, err)
+}
+
+func TestSARIFSeverity(t *testing.T) {
+	tests := []struct {
+		level    string
+		rule     *SARIFRule
+		severity Severity
+	}{
+		{"error", nil, "HIGH"},
+		{"warning", nil, "MEDIUM"},
+		{"", nil, "MEDIUM"},
+		{"note", nil, "LOW"},
+		{"none", nil, "INFO"},
+		{"", &SARIFRule{DefaultConfiguration: &SARIFConfiguration{Level: "error"}}, "HIGH"},
+		{"note", &SARIFRule{Properties: map[string]interface{}{"security-severity": "9.8"}}, "CRITICAL"},
+		{"error", &SARIFRule{Properties: map[string]interface{}{"security-severity": 5.3}}, "MEDIUM"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.severity, sarifSeverity(tt.level, tt.rule))
+	}
+}
+
+func TestNewSARIFLog(t *testing.T) {
+	f, err := os.Open("testdata/gosec.sarif")
+	require.NoError(t, err)
+	defer f.Close()
+
+	imported, err := DecodeSARIF(f)
+	require.NoError(t, err)
+
+	scan := &Scan{
+		Status: Success,
+		Findings: append(imported.Findings(), Finding{
+			Type:   "sast",
+			RuleID: "G402",
+			Tool:   "gosec",
+			Location: Location{
+				Path: "connectors/s3.go",
+				Positions: Positions{
+					Begin: Begin{Line: 12},
+				},
+			},
+			Metadata: Metadata{
+				Description: "TLS InsecureSkipVerify set true.",
+				Severity:    "HIGH",
+				Name:        "Bad TLS connection settings",
+				HelpURI:     "https://cwe.mitre.org/data/definitions/295.html",
+			},
+		}, Finding{
+			Type:   "sast",
+			RuleID: "custom",
+			Metadata: Metadata{
+				Description: "Custom check",
+				Severity:    "INFO",
+			},
+		}),
+	}
+
+	log := NewSARIFLog(scan)
+	require.Equal(t, 2, len(log.Runs))
+	assert.Equal(t, "gosec", log.Runs[0].Tool.Driver.Name)
+	assert.Equal(t, 2, len(log.Runs[0].Tool.Driver.Rules), "rules are deduplicated")
+	assert.Equal(t, 3, len(log.Runs[0].Results))
+	assert.Equal(t, 0, *log.Runs[0].Results[2].RuleIndex)
+	assert.Equal(t, "ssr", log.Runs[1].Tool.Driver.Name)
+	assert.Empty(t, log.Runs[1].Results[0].Locations)
+
+	// exporting then importing again gives back the same findings
+	var buf bytes.Buffer
+	require.NoError(t, json.NewEncoder(&buf).Encode(log))
+	exported, err := DecodeSARIF(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, Success, exported.Status())
+
+	findings := exported.Findings()
+	findings[3].Tool = ""
+	assert.Equal(t, scan.Findings, findings)
+}
+
+func TestNewSARIFLogWithoutFindings(t *testing.T) {
+	log := NewSARIFLog(&Scan{Status: Queued})
+	require.Equal(t, 1, len(log.Runs))
+	assert.Empty(t, log.Runs[0].Results)
+	assert.Empty(t, log.Runs[0].Invocations)
+}
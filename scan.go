@@ -1,9 +1,8 @@
 package ssr
 
 import (
-	"database/sql/driver"
-	"encoding/json"
-	"errors"
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,18 +19,65 @@ const (
 )
 
 func (s Status) String() string {
+	if s < Queued || s > Failure {
+		return fmt.Sprintf("Status(%d)", s)
+	}
 	return [...]string{"Queued", "In Progress", "Success", "Failure"}[s]
 }
 
+// transitions lists the statuses a scan can move to from each status.
+// A failed scan can be retried by queueing it again.
+var transitions = map[Status][]Status{
+	Queued:     {InProgress},
+	InProgress: {Success, Failure},
+	Failure:    {Queued},
+}
+
+// CanTransitionTo reports whether a scan in status s can move to next.
+func (s Status) CanTransitionTo(next Status) bool {
+	for _, t := range transitions[s] {
+		if t == next {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionError is returned when a scan is asked to move to a status that cannot follow its current one.
+type TransitionError struct {
+	From Status
+	To   Status
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot move scan from %s to %s", e.From, e.To)
+}
+
+func (e *TransitionError) ErrorKind() ErrorKind {
+	return KindConflict
+}
+
+// ErrScanNotFound is returned when a scan does not exist.
+var ErrScanNotFound = NewError(KindNotFound, "scan not found")
+
 type Scan struct {
 	ID uuid.UUID `json:"id" gorm:"type:uuid"`
 	Status     Status   `json:"status"`
 	RepositoryID uint64 `json:"repository_id"`
-	Findings   Findings `json:"findings"`
+	// Branch is the branch that was scanned, an empty branch being the default branch of the repository.
+	Branch     string   `json:"branch,omitempty"`
+	Findings   Findings `json:"findings" gorm:"foreignKey:ScanID"`
 	QueuedAt time.Time `json:"queued_at"`
 	ScanningAt time.Time `json:"scanning_at"`
 	FinishedAt time.Time `json:"finished_at"`
+	// Gate is evaluated when the scan succeeds, see EvaluatePolicies.
+	Gate *Gate `json:"gate,omitempty" gorm:"type:jsonb"`
 	Repository Repository `gorm:"foreignKey:RepositoryID"`
+
+	// Lease fields are only written by the ScanQueue.
+	Attempts       int       `json:"-" gorm:"<-:false;not null;default:0"`
+	LeaseOwner     string    `json:"-" gorm:"<-:false"`
+	LeaseExpiresAt time.Time `json:"-" gorm:"<-:false;index"`
 }
 
 func (Scan) TableName() string {
@@ -43,49 +89,126 @@ func (s *Scan) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+// Stamp sets the timestamps of a new scan according to its status.
+func (s *Scan) Stamp(now time.Time) {
+	s.QueuedAt = now
+	s.ScanningAt = time.Time{}
+	s.FinishedAt = time.Time{}
+	if s.Status != Queued {
+		s.ScanningAt = now
+	}
+	if s.Status == Success || s.Status == Failure {
+		s.FinishedAt = now
+	}
+}
+
+// Transition moves the scan to next and stamps the matching timestamp.
+// It returns a *TransitionError if next cannot follow the current status.
+func (s *Scan) Transition(next Status, now time.Time) error {
+	if !s.Status.CanTransitionTo(next) {
+		return &TransitionError{From: s.Status, To: next}
+	}
+
+	switch next {
+	case Queued:
+		s.QueuedAt = now
+		s.ScanningAt = time.Time{}
+		s.FinishedAt = time.Time{}
+	case InProgress:
+		s.ScanningAt = now
+	case Success, Failure:
+		s.FinishedAt = now
+	}
+	s.Status = next
+	return nil
+}
+
 type Findings []Finding
 
+// Finding is stored in its own table, one row per finding of a scan.
 type Finding struct {
-	Type string `json:"type"`
+	ID uint64 `json:"-" gorm:"primaryKey"`
+	ScanID uuid.UUID `json:"-" gorm:"type:uuid;index;not null"`
+	Type FindingType `json:"type"`
 	RuleID string `json:"rule_id"`
-	Location Location `json:"location"`
-	Metadata Metadata `json:"metadata"`
+	Tool string `json:"tool,omitempty"`
+	Location Location `json:"location" gorm:"embedded"`
+	Metadata Metadata `json:"metadata" gorm:"embedded"`
+	// Fingerprint is computed on ingest, see Findings.Fingerprint.
+	Fingerprint string `json:"fingerprint,omitempty" gorm:"index"`
+	// Suppressed is set on ingest when a suppression of the repository matches the finding.
+	Suppressed    bool    `json:"suppressed,omitempty" gorm:"not null;default:false"`
+	SuppressionID *uint64 `json:"suppression_id,omitempty" gorm:"index"`
+	// Status is the status of the fingerprint in the repository, it is only set when listing findings.
+	Status string `json:"status,omitempty" gorm:"->;-:migration"`
+}
+
+func (Finding) TableName() string {
+	return "finding"
 }
 
 type Location struct {
 	Path string `json:"path"`
-	Positions Positions
+	Positions Positions `gorm:"embedded"`
+	// Snippet is the source code the finding points at.
+	Snippet string `json:"snippet,omitempty"`
 }
 
 type Positions struct {
-	Begin Begin `json:"begin"`
+	Begin Begin `json:"begin" gorm:"embedded;embeddedPrefix:begin_"`
+	End End `json:"end" gorm:"embedded;embeddedPrefix:end_"`
 }
 
 type Begin struct {
 	Line int64 `json:"line"`
+	Column int64 `json:"column,omitempty"`
 }
 
-type Metadata struct {
-	Description string `json:"description"`
-	Severity string `json:"severity"`
+type End struct {
+	Line int64 `json:"line,omitempty"`
+	Column int64 `json:"column,omitempty"`
 }
 
-func (f Findings) Value() (driver.Value, error) {
-	return json.Marshal(f)
+type Metadata struct {
+	Description string `json:"description"`
+	Severity Severity `json:"severity"`
+	// Name and HelpURI describe the rule that produced the finding.
+	Name string `json:"name,omitempty" gorm:"column:rule_name"`
+	HelpURI string `json:"help_uri,omitempty"`
+	// Commit is the commit a secret was found in, InstalledVersion the version of a vulnerable package. They are kept
+	// out of Description, which is fingerprinted: a finding keeps its fingerprint across commits and version bumps.
+	Commit string `json:"commit,omitempty"`
+	InstalledVersion string `json:"installed_version,omitempty"`
 }
 
-func (f *Findings) Scan(value interface{}) error {
-	b, ok := value.([]byte)
-	if !ok {
-		return errors.New("type assertion to []byte failed")
-	}
-	return json.Unmarshal(b, &f)
+type ScanService interface {
+	CreateScan(ctx context.Context, s *Scan) (*Scan, error)
+	GetScan(ctx context.Context, id uuid.UUID) (*Scan, error)
+	ListScans(ctx context.Context, page, limit int) (scans []*Scan, err error)
+	// UpdateScan moves the scan to status and replaces its findings.
+	// It returns a *TransitionError if status cannot follow the current status of the scan.
+	UpdateScan(ctx context.Context, id uuid.UUID, status Status, findings Findings) (*Scan, error)
+	DeleteScan(ctx context.Context, id uuid.UUID) error
+	// GetLatestDefaultBranchScan returns the last successful scan of the default branch of a repository,
+	// or ErrScanNotFound if there is none.
+	GetLatestDefaultBranchScan(ctx context.Context, repositoryID uint64) (*Scan, error)
 }
 
-type ScanService interface {
-	CreateScan(s *Scan) (*Scan, error)
-	GetScan(id uuid.UUID) (*Scan, error)
-	ListScans(page, limit int) (scans []*Scan, err error)
-	UpdateScan(id uuid.UUID, status Status, findings Findings) (*Scan, error)
-	DeleteScan(id uuid.UUID) error
+// ErrLeaseLost is returned when a worker touches a scan it no longer holds the lease of,
+// e.g. because it was requeued after the lease expired.
+var ErrLeaseLost = NewError(KindConflict, "scan lease lost")
+
+// ScanQueue hands queued scans out to workers.
+// A claimed scan is leased to the worker that claimed it: the worker must renew the lease with Heartbeat
+// before it expires, otherwise Requeue puts the scan back in the queue for another worker.
+type ScanQueue interface {
+	// Claim moves the oldest queued scan to InProgress and returns it.
+	// It returns nil when there is no queued scan.
+	Claim() (*Scan, error)
+	// Heartbeat extends the lease of a claimed scan.
+	Heartbeat(id uuid.UUID) error
+	// Finish stores the findings of a claimed scan along with its final status.
+	Finish(id uuid.UUID, status Status, findings Findings) error
+	// Requeue returns the scans whose lease has expired to the queue, and reports how many there were.
+	Requeue() (int64, error)
 }
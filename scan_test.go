@@ -0,0 +1,52 @@
+package ssr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanTransition(t *testing.T) {
+	queuedAt := time.Now().Add(-time.Hour)
+	scan := &Scan{Status: Queued, QueuedAt: queuedAt}
+
+	now := time.Now()
+	require.NoError(t, scan.Transition(InProgress, now))
+	assert.Equal(t, InProgress, scan.Status)
+	assert.Equal(t, now, scan.ScanningAt)
+
+	require.NoError(t, scan.Transition(Failure, now.Add(time.Minute)))
+	assert.Equal(t, now.Add(time.Minute), scan.FinishedAt)
+
+	// retry
+	require.NoError(t, scan.Transition(Queued, now.Add(time.Hour)))
+	assert.Equal(t, now.Add(time.Hour), scan.QueuedAt)
+	assert.True(t, scan.ScanningAt.IsZero())
+	assert.True(t, scan.FinishedAt.IsZero())
+
+	require.NoError(t, scan.Transition(InProgress, now))
+	require.NoError(t, scan.Transition(Success, now))
+
+	for _, next := range []Status{Queued, InProgress, Success, Failure, Status(42)} {
+		err := scan.Transition(next, now)
+		assert.Equal(t, &TransitionError{From: Success, To: next}, err)
+	}
+	assert.Equal(t, Success, scan.Status)
+}
+
+func TestScanStamp(t *testing.T) {
+	now := time.Now()
+
+	scan := &Scan{Status: Queued, ScanningAt: now.Add(-time.Hour)}
+	scan.Stamp(now)
+	assert.Equal(t, now, scan.QueuedAt)
+	assert.True(t, scan.ScanningAt.IsZero())
+
+	scan = &Scan{Status: Success}
+	scan.Stamp(now)
+	assert.Equal(t, now, scan.QueuedAt)
+	assert.Equal(t, now, scan.ScanningAt)
+	assert.Equal(t, now, scan.FinishedAt)
+}
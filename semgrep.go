@@ -0,0 +1,81 @@
+package ssr
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// semgrepReport is the output of `semgrep --json`.
+type semgrepReport struct {
+	Results []struct {
+		CheckID string          `json:"check_id"`
+		Path    string          `json:"path"`
+		Start   semgrepPosition `json:"start"`
+		End     semgrepPosition `json:"end"`
+		Extra   struct {
+			Message  string `json:"message"`
+			Lines    string `json:"lines"`
+			Severity string `json:"severity"`
+			Metadata struct {
+				References []string `json:"references"`
+			} `json:"metadata"`
+		} `json:"extra"`
+	} `json:"results"`
+}
+
+type semgrepPosition struct {
+	Line int64 `json:"line"`
+	Col  int64 `json:"col"`
+}
+
+func init() {
+	RegisterImporter("semgrep", ImporterFunc(importSemgrep))
+}
+
+func importSemgrep(r io.Reader) (Findings, error) {
+	var report semgrepReport
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, errors.Wrap(err, "failed to decode semgrep report")
+	}
+
+	findings := Findings{}
+	for _, result := range report.Results {
+		f := Finding{
+			Type:   FindingSAST,
+			RuleID: result.CheckID,
+			Tool:   "semgrep",
+			Location: Location{
+				Path: result.Path,
+				Positions: Positions{
+					Begin: Begin{Line: result.Start.Line, Column: result.Start.Col},
+					End:   End{Line: result.End.Line, Column: result.End.Col},
+				},
+				Snippet: result.Extra.Lines,
+			},
+			Metadata: Metadata{
+				Description: strings.TrimSpace(result.Extra.Message),
+				Severity:    semgrepSeverity(result.Extra.Severity),
+			},
+		}
+		if refs := result.Extra.Metadata.References; len(refs) > 0 {
+			f.Metadata.HelpURI = refs[0]
+		}
+		findings = append(findings, f)
+	}
+	return findings, nil
+}
+
+// semgrepSeverity maps the severity of a rule, INFO rules being ranked as LOW like SARIF notes.
+func semgrepSeverity(severity string) Severity {
+	switch strings.ToUpper(severity) {
+	case "ERROR":
+		return SeverityHigh
+	case "WARNING":
+		return SeverityMedium
+	default:
+		return SeverityLow
+	}
+}
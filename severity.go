@@ -0,0 +1,97 @@
+package ssr
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidSeverity is returned for a severity that is neither a known severity nor an alias of one.
+var ErrInvalidSeverity = NewError(KindInvalid, "invalid severity")
+
+// Severity ranks how serious a finding is.
+type Severity string
+
+const (
+	SeverityInfo     Severity = "INFO"
+	SeverityLow      Severity = "LOW"
+	SeverityMedium   Severity = "MEDIUM"
+	SeverityHigh     Severity = "HIGH"
+	SeverityCritical Severity = "CRITICAL"
+)
+
+// severityAliases maps the vocabularies of common tools to severities.
+// Keys are in lower case, since severities are parsed case-insensitively.
+var severityAliases = map[string]Severity{
+	"info":          SeverityInfo,
+	"informational": SeverityInfo,
+	"information":   SeverityInfo,
+	"none":          SeverityInfo,
+	"unknown":       SeverityInfo,
+	"negligible":    SeverityInfo,
+	"low":           SeverityLow,
+	"note":          SeverityLow,
+	"minor":         SeverityLow,
+	"medium":        SeverityMedium,
+	"moderate":      SeverityMedium,
+	"warning":       SeverityMedium,
+	"warn":          SeverityMedium,
+	"high":          SeverityHigh,
+	"error":         SeverityHigh,
+	"major":         SeverityHigh,
+	"important":     SeverityHigh,
+	"critical":      SeverityCritical,
+	"blocker":       SeverityCritical,
+	"fatal":         SeverityCritical,
+}
+
+// ParseSeverity maps s, or a known alias of it such as SARIF's "error", to a severity regardless of case.
+func ParseSeverity(s string) (Severity, error) {
+	if severity, ok := severityAliases[strings.ToLower(strings.TrimSpace(s))]; ok {
+		return severity, nil
+	}
+	return "", fmt.Errorf("%w %q", ErrInvalidSeverity, s)
+}
+
+// toSeverity is ParseSeverity for importers, which rank what they cannot map as INFO.
+func toSeverity(s string) Severity {
+	severity, err := ParseSeverity(s)
+	if err != nil {
+		return SeverityInfo
+	}
+	return severity
+}
+
+// Rank orders severities from the least to the most severe. An empty severity ranks as INFO.
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityCritical:
+		return 4
+	case SeverityHigh:
+		return 3
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// UnmarshalJSON accepts any case and the aliases of ParseSeverity. An empty severity is left unset.
+func (s *Severity) UnmarshalJSON(b []byte) error {
+	var v string
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if v == "" {
+		*s = ""
+		return nil
+	}
+	severity, err := ParseSeverity(v)
+	if err != nil {
+		return err
+	}
+	*s = severity
+	return nil
+}
@@ -0,0 +1,74 @@
+package ssr
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSeverity(t *testing.T) {
+	for s, want := range map[string]Severity{
+		"HIGH":          SeverityHigh,
+		"high":          SeverityHigh,
+		"Critical":      SeverityCritical,
+		"error":         SeverityHigh,
+		"warning":       SeverityMedium,
+		"note":          SeverityLow,
+		"informational": SeverityInfo,
+		" low ":         SeverityLow,
+	} {
+		severity, err := ParseSeverity(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, severity, s)
+	}
+
+	_, err := ParseSeverity("urgent")
+	assert.ErrorIs(t, err, ErrInvalidSeverity)
+	_, err = ParseSeverity("")
+	assert.ErrorIs(t, err, ErrInvalidSeverity)
+}
+
+func TestSeverityRank(t *testing.T) {
+	assert.Greater(t, SeverityCritical.Rank(), SeverityHigh.Rank())
+	assert.Greater(t, SeverityHigh.Rank(), SeverityMedium.Rank())
+	assert.Greater(t, SeverityMedium.Rank(), SeverityLow.Rank())
+	assert.Greater(t, SeverityLow.Rank(), SeverityInfo.Rank())
+	assert.Equal(t, SeverityInfo.Rank(), Severity("").Rank())
+}
+
+func TestUnmarshalFinding(t *testing.T) {
+	var f Finding
+	require.NoError(t, json.Unmarshal([]byte(`{"type": "SAST", "metadata": {"severity": "Critical"}}`), &f))
+	assert.Equal(t, FindingSAST, f.Type)
+	assert.Equal(t, SeverityCritical, f.Metadata.Severity)
+
+	f = Finding{}
+	require.NoError(t, json.Unmarshal([]byte(`{"type": "", "metadata": {"severity": ""}}`), &f))
+	assert.Empty(t, f.Type)
+	assert.Empty(t, f.Metadata.Severity)
+
+	err := json.Unmarshal([]byte(`{"metadata": {"severity": "urgent"}}`), &f)
+	assert.ErrorIs(t, err, ErrInvalidSeverity)
+	err = json.Unmarshal([]byte(`{"type": "dast"}`), &f)
+	assert.ErrorIs(t, err, ErrInvalidFindingType)
+}
+
+func TestParseFindingType(t *testing.T) {
+	for s, want := range map[string]FindingType{
+		"sast":             FindingSAST,
+		"SCA":              FindingSCA,
+		"vulnerability":    FindingSCA,
+		"secrets":          FindingSecret,
+		"misconfiguration": FindingIaC,
+		"Licence":          FindingLicense,
+	} {
+		findingType, err := ParseFindingType(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, findingType, s)
+	}
+
+	_, err := ParseFindingType("dast")
+	assert.ErrorIs(t, err, ErrInvalidFindingType)
+}
@@ -0,0 +1,71 @@
+package ssr
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// ErrSuppressionNotFound is returned when a suppression does not exist.
+var ErrSuppressionNotFound = NewError(KindNotFound, "suppression not found")
+
+// Suppression waives the findings of a repository that match its rule and path prefix, until it expires.
+// Suppressed findings are still stored, but marked as such.
+type Suppression struct {
+	ID           uint64 `json:"id"`
+	RepositoryID uint64 `json:"repository_id" gorm:"index;not null"`
+	// RuleID and PathPrefix scope the suppression, an empty one matches every finding.
+	RuleID     string    `json:"rule_id,omitempty"`
+	PathPrefix string    `json:"path_prefix,omitempty"`
+	Reason     string    `json:"reason"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	// ExpiresAt is when the findings are re-opened, a suppression without expiry date is permanent.
+	ExpiresAt *time.Time `json:"expires_at,omitempty" gorm:"index"`
+}
+
+func (Suppression) TableName() string {
+	return "suppression"
+}
+
+// Active reports whether the suppression applies at the given time.
+func (s *Suppression) Active(now time.Time) bool {
+	return s.ExpiresAt == nil || s.ExpiresAt.After(now)
+}
+
+// Matches reports whether the suppression covers f.
+func (s *Suppression) Matches(f Finding) bool {
+	if s.RuleID != "" && s.RuleID != f.RuleID {
+		return false
+	}
+	return strings.HasPrefix(f.Location.Path, s.PathPrefix)
+}
+
+// Suppress marks every finding matched by an active suppression as suppressed by the first one that matches it,
+// and clears the mark of the others.
+func (fs Findings) Suppress(suppressions []*Suppression, now time.Time) {
+	for i := range fs {
+		fs[i].Suppressed = false
+		fs[i].SuppressionID = nil
+		for _, s := range suppressions {
+			if s.Active(now) && s.Matches(fs[i]) {
+				id := s.ID
+				fs[i].Suppressed = true
+				fs[i].SuppressionID = &id
+				break
+			}
+		}
+	}
+}
+
+type SuppressionService interface {
+	// Create stores a suppression, it applies to the findings stored from then on.
+	Create(ctx context.Context, s *Suppression) error
+	Get(ctx context.Context, id uint64) (*Suppression, error)
+	// List returns the suppressions of a repository, expired ones included.
+	List(ctx context.Context, repositoryID uint64) ([]*Suppression, error)
+	// Delete removes a suppression and re-opens the findings it suppressed.
+	Delete(ctx context.Context, id uint64) error
+	// Expire re-opens the findings of the suppressions that expired before now, and reports how many there were.
+	Expire(ctx context.Context, now time.Time) (int64, error)
+}
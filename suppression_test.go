@@ -0,0 +1,58 @@
+package ssr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindingsSuppress(t *testing.T) {
+	now := time.Now()
+	expired := now.Add(-time.Hour)
+	later := now.Add(time.Hour)
+	finding := func(ruleID, path string) Finding {
+		return Finding{RuleID: ruleID, Location: Location{Path: path}}
+	}
+
+	findings := Findings{
+		finding("G404", "testdata/rand.go"),
+		finding("G404", "util/util.go"),
+		finding("G402", "testdata/tls.go"),
+		finding("G101", "config/secrets.go"),
+		finding("G304", "vendor/file.go"),
+	}
+	findings[4].Suppressed = true
+
+	findings.Suppress([]*Suppression{
+		{ID: 1, RuleID: "G404", PathPrefix: "testdata/", ExpiresAt: &later},
+		{ID: 2, PathPrefix: "testdata/"},
+		{ID: 3, RuleID: "G101", ExpiresAt: &expired},
+	}, now)
+
+	require.NotNil(t, findings[0].SuppressionID)
+	assert.True(t, findings[0].Suppressed)
+	assert.Equal(t, uint64(1), *findings[0].SuppressionID)
+	assert.False(t, findings[1].Suppressed)
+	require.NotNil(t, findings[2].SuppressionID)
+	assert.Equal(t, uint64(2), *findings[2].SuppressionID)
+	assert.False(t, findings[3].Suppressed, "an expired suppression does not apply")
+	assert.False(t, findings[4].Suppressed, "suppressions are evaluated again")
+	assert.Nil(t, findings[4].SuppressionID)
+}
+
+func TestSuppressedFindingAsSARIF(t *testing.T) {
+	log := NewSARIFLog(&Scan{
+		Status: Success,
+		Findings: Findings{
+			{RuleID: "G404", Suppressed: true},
+			{RuleID: "G402"},
+		},
+	})
+
+	results := log.Runs[0].Results
+	require.Len(t, results, 2)
+	assert.Equal(t, []SARIFSuppression{{Kind: "external", Status: "accepted"}}, results[0].Suppressions)
+	assert.Empty(t, results[1].Suppressions)
+}
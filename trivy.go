@@ -0,0 +1,122 @@
+package ssr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// trivyReport is the output of `trivy fs --format json` (schema version 2).
+type trivyReport struct {
+	Results []struct {
+		Target          string `json:"Target"`
+		Vulnerabilities []struct {
+			VulnerabilityID  string `json:"VulnerabilityID"`
+			PkgName          string `json:"PkgName"`
+			InstalledVersion string `json:"InstalledVersion"`
+			FixedVersion     string `json:"FixedVersion"`
+			Title            string `json:"Title"`
+			Severity         string `json:"Severity"`
+			PrimaryURL       string `json:"PrimaryURL"`
+		} `json:"Vulnerabilities"`
+		Misconfigurations []struct {
+			ID            string `json:"ID"`
+			Title         string `json:"Title"`
+			Message       string `json:"Message"`
+			Severity      string `json:"Severity"`
+			PrimaryURL    string `json:"PrimaryURL"`
+			Status        string `json:"Status"`
+			CauseMetadata struct {
+				StartLine int64 `json:"StartLine"`
+				EndLine   int64 `json:"EndLine"`
+			} `json:"CauseMetadata"`
+		} `json:"Misconfigurations"`
+		Secrets []struct {
+			RuleID    string `json:"RuleID"`
+			Title     string `json:"Title"`
+			Severity  string `json:"Severity"`
+			StartLine int64  `json:"StartLine"`
+			EndLine   int64  `json:"EndLine"`
+		} `json:"Secrets"`
+	} `json:"Results"`
+}
+
+func init() {
+	RegisterImporter("trivy", ImporterFunc(importTrivy))
+}
+
+func importTrivy(r io.Reader) (Findings, error) {
+	var report trivyReport
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, errors.Wrap(err, "failed to decode trivy report")
+	}
+
+	findings := Findings{}
+	for _, result := range report.Results {
+		for _, v := range result.Vulnerabilities {
+			description := fmt.Sprintf("%s: %s", v.PkgName, v.Title)
+			if v.FixedVersion != "" {
+				description += fmt.Sprintf(" (fixed in %s)", v.FixedVersion)
+			}
+			findings = append(findings, Finding{
+				Type:     FindingSCA,
+				RuleID:   v.VulnerabilityID,
+				Tool:     "trivy",
+				Location: Location{Path: result.Target},
+				Metadata: Metadata{
+					Description:      description,
+					Severity:         toSeverity(v.Severity),
+					Name:             v.PkgName,
+					HelpURI:          v.PrimaryURL,
+					InstalledVersion: v.InstalledVersion,
+				},
+			})
+		}
+
+		for _, m := range result.Misconfigurations {
+			if m.Status != "FAIL" {
+				continue
+			}
+			findings = append(findings, Finding{
+				Type:   FindingIaC,
+				RuleID: m.ID,
+				Tool:   "trivy",
+				Location: Location{
+					Path: result.Target,
+					Positions: Positions{
+						Begin: Begin{Line: m.CauseMetadata.StartLine},
+						End:   End{Line: m.CauseMetadata.EndLine},
+					},
+				},
+				Metadata: Metadata{
+					Description: m.Message,
+					Severity:    toSeverity(m.Severity),
+					Name:        m.Title,
+					HelpURI:     m.PrimaryURL,
+				},
+			})
+		}
+
+		for _, s := range result.Secrets {
+			findings = append(findings, Finding{
+				Type:   FindingSecret,
+				RuleID: s.RuleID,
+				Tool:   "trivy",
+				Location: Location{
+					Path: result.Target,
+					Positions: Positions{
+						Begin: Begin{Line: s.StartLine},
+						End:   End{Line: s.EndLine},
+					},
+				},
+				Metadata: Metadata{
+					Description: s.Title,
+					Severity:    toSeverity(s.Severity),
+				},
+			})
+		}
+	}
+	return findings, nil
+}
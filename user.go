@@ -0,0 +1,61 @@
+package ssr
+
+import (
+	"context"
+)
+
+// User is who a request is made by: a person authenticated by a token of the identity provider,
+// or a client authenticated by an API key.
+// Users are stored to give them a role, see AccessService.
+type User struct {
+	// ID identifies the user at the identity provider, or is "apikey:<id>" for an API key.
+	ID string `json:"id" gorm:"primaryKey"`
+	// OrganizationID is the tenant whose data the user works on, see Organization.
+	OrganizationID uint64 `json:"organization_id" gorm:"primaryKey;autoIncrement:false"`
+	Email          string `json:"email,omitempty"`
+	// EmailVerified tells whether the identity provider verified that Email belongs to the user.
+	EmailVerified bool `json:"-" gorm:"-"`
+	// Role applies to every repository, see Access.
+	Role   Role     `json:"role,omitempty"`
+	Groups []string `json:"groups,omitempty" gorm:"-"`
+	// Scopes are granted by the API key, or by the groups of the user.
+	Scopes ScopeList `json:"scopes,omitempty" gorm:"-"`
+	// RepositoryID restricts the user to a repository, on which it has Role, e.g. for the API key of its CI.
+	RepositoryID *uint64 `json:"-" gorm:"-"`
+}
+
+func (User) TableName() string {
+	return "user_account"
+}
+
+// Name is how the user is recorded as the author of a change, e.g. of a triage decision.
+// It is the email address of the user only once verified, since anyone could claim any address otherwise.
+func (u *User) Name() string {
+	if u.Email != "" && u.EmailVerified {
+		return u.Email
+	}
+	return u.ID
+}
+
+// HasScope reports whether u is granted scope.
+func (u *User) HasScope(scope Scope) bool {
+	for _, s := range u.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type userContextKey struct{}
+
+// NewContextWithUser returns a copy of ctx that carries u.
+func NewContextWithUser(ctx context.Context, u *User) context.Context {
+	return context.WithValue(ctx, userContextKey{}, u)
+}
+
+// UserFromContext returns the user that ctx carries, nil if there is none, e.g. when authentication is disabled.
+func UserFromContext(ctx context.Context) *User {
+	u, _ := ctx.Value(userContextKey{}).(*User)
+	return u
+}
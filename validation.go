@@ -0,0 +1,121 @@
+package ssr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError reports an invalid field of a request, named by its path in the JSON body,
+// e.g. "findings[0].rule_id".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists the invalid fields of a request.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+" "+f.Message)
+	}
+	return "invalid request: " + strings.Join(msgs, ", ")
+}
+
+func (e *ValidationError) ErrorKind() ErrorKind {
+	return KindInvalid
+}
+
+// Add reports field as invalid.
+func (e *ValidationError) Add(field, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, FieldError{
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Err returns e if any field was reported, nil otherwise.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Validate checks a scan submitted by a client. The repository is only checked to be set,
+// its existence is up to the ScanService.
+func (s *Scan) Validate() error {
+	var v ValidationError
+	if s.RepositoryID == 0 {
+		v.Add("repository_id", "is required")
+	}
+	// The branch is passed to git by the worker, it must not be taken for an option.
+	if strings.HasPrefix(s.Branch, "-") {
+		v.Add("branch", "must not start with -")
+	}
+	s.Status.validate(&v)
+	s.Findings.validate(&v)
+	return v.Err()
+}
+
+// ValidateUpdate checks a scan submitted by a client to update a scan, which keeps its repository and branch.
+func (s *Scan) ValidateUpdate() error {
+	var v ValidationError
+	s.Status.validate(&v)
+	s.Findings.validate(&v)
+	return v.Err()
+}
+
+func (s Status) validate(v *ValidationError) {
+	if s < Queued || s > Failure {
+		v.Add("status", "must be one of %d (Queued) to %d (Failure)", Queued, Failure)
+	}
+}
+
+// Validate checks findings submitted by a client.
+func (fs Findings) Validate() error {
+	var v ValidationError
+	fs.validate(&v)
+	return v.Err()
+}
+
+func (fs Findings) validate(v *ValidationError) {
+	for i, f := range fs {
+		field := func(name string) string {
+			return fmt.Sprintf("findings[%d].%s", i, name)
+		}
+
+		if strings.TrimSpace(f.RuleID) == "" {
+			v.Add(field("rule_id"), "is required")
+		}
+		if f.Type == "" {
+			v.Add(field("type"), "is required")
+		} else if _, err := ParseFindingType(string(f.Type)); err != nil {
+			v.Add(field("type"), "is not a valid finding type")
+		}
+		if f.Metadata.Severity != "" {
+			if _, err := ParseSeverity(string(f.Metadata.Severity)); err != nil {
+				v.Add(field("metadata.severity"), "is not a valid severity")
+			}
+		}
+
+		begin, end := f.Location.Positions.Begin, f.Location.Positions.End
+		if begin.Line < 0 {
+			v.Add(field("location.positions.begin.line"), "must not be negative")
+		}
+		if begin.Column < 0 {
+			v.Add(field("location.positions.begin.column"), "must not be negative")
+		}
+		if end.Line < 0 {
+			v.Add(field("location.positions.end.line"), "must not be negative")
+		} else if end.Line > 0 && end.Line < begin.Line {
+			v.Add(field("location.positions.end.line"), "must not be before begin.line")
+		}
+		if end.Column < 0 {
+			v.Add(field("location.positions.end.column"), "must not be negative")
+		}
+	}
+}
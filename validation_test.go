@@ -0,0 +1,67 @@
+package ssr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanValidate(t *testing.T) {
+	valid := Finding{
+		Type:   FindingSAST,
+		RuleID: "G402",
+		Location: Location{
+			Path:      "connectors/apigateway.go",
+			Positions: Positions{Begin: Begin{Line: 60}, End: End{Line: 61}},
+		},
+		Metadata: Metadata{Severity: SeverityHigh},
+	}
+
+	scan := &Scan{RepositoryID: 1, Status: Success, Findings: Findings{valid}}
+	assert.NoError(t, scan.Validate())
+
+	invalid := valid
+	invalid.RuleID = " "
+	invalid.Type = ""
+	invalid.Metadata.Severity = "urgent"
+	invalid.Location.Positions = Positions{Begin: Begin{Line: 10, Column: -1}, End: End{Line: 9}}
+	scan = &Scan{Status: Status(7), Branch: "--upload-pack=touch /tmp/pwned", Findings: Findings{valid, invalid}}
+
+	err := scan.Validate()
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []FieldError{
+		{Field: "repository_id", Message: "is required"},
+		{Field: "branch", Message: "must not start with -"},
+		{Field: "status", Message: "must be one of 0 (Queued) to 3 (Failure)"},
+		{Field: "findings[1].rule_id", Message: "is required"},
+		{Field: "findings[1].type", Message: "is required"},
+		{Field: "findings[1].metadata.severity", Message: "is not a valid severity"},
+		{Field: "findings[1].location.positions.begin.column", Message: "must not be negative"},
+		{Field: "findings[1].location.positions.end.line", Message: "must not be before begin.line"},
+	}, validationErr.Fields)
+	assert.Contains(t, err.Error(), "findings[1].rule_id is required")
+}
+
+func TestScanValidateUpdate(t *testing.T) {
+	assert.NoError(t, (&Scan{Status: Success}).ValidateUpdate(), "the repository is that of the scan")
+
+	err := (&Scan{Status: Status(-1), Findings: Findings{{Type: FindingSAST}}}).ValidateUpdate()
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []FieldError{
+		{Field: "status", Message: "must be one of 0 (Queued) to 3 (Failure)"},
+		{Field: "findings[0].rule_id", Message: "is required"},
+	}, validationErr.Fields)
+}
+
+func TestFindingsValidate(t *testing.T) {
+	assert.NoError(t, Findings{}.Validate())
+
+	err := Findings{{Type: FindingSecret, RuleID: "aws-access-key", Location: Location{Positions: Positions{Begin: Begin{Line: -3}}}}}.Validate()
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []FieldError{{Field: "findings[0].location.positions.begin.line", Message: "must not be negative"}}, validationErr.Fields)
+}
@@ -0,0 +1,338 @@
+package ssr
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrWebhookNotFound is returned when a webhook does not exist.
+	ErrWebhookNotFound = NewError(KindNotFound, "webhook not found")
+	// ErrWebhookDeliveryNotFound is returned when a delivery does not exist.
+	ErrWebhookDeliveryNotFound = NewError(KindNotFound, "webhook delivery not found")
+	// ErrInvalidEventType is returned for an event type that is not one of EventTypes.
+	ErrInvalidEventType = NewError(KindInvalid, "invalid event type")
+)
+
+// EventType is what happened to a scan that webhooks subscribe to.
+type EventType string
+
+const (
+	EventScanQueued    EventType = "scan.queued"
+	EventScanStarted   EventType = "scan.started"
+	EventScanSucceeded EventType = "scan.succeeded"
+	EventScanFailed    EventType = "scan.failed"
+	// EventFindingNew is sent for each finding of a successful scan whose fingerprint the repository had not seen before.
+	EventFindingNew EventType = "finding.new"
+)
+
+// EventTypes lists every event type.
+var EventTypes = []EventType{EventScanQueued, EventScanStarted, EventScanSucceeded, EventScanFailed, EventFindingNew}
+
+// ParseEventType returns s if it is one of EventTypes.
+func ParseEventType(s string) (EventType, error) {
+	for _, t := range EventTypes {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q", ErrInvalidEventType, s)
+}
+
+// UnmarshalJSON only accepts one of EventTypes.
+func (t *EventType) UnmarshalJSON(b []byte) error {
+	var v string
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	eventType, err := ParseEventType(v)
+	if err != nil {
+		return err
+	}
+	*t = eventType
+	return nil
+}
+
+// ScanEventType returns the event of a scan entering status.
+func ScanEventType(status Status) EventType {
+	switch status {
+	case InProgress:
+		return EventScanStarted
+	case Success:
+		return EventScanSucceeded
+	case Failure:
+		return EventScanFailed
+	default:
+		return EventScanQueued
+	}
+}
+
+// EventTypeList is stored as a JSON array.
+type EventTypeList []EventType
+
+func (l EventTypeList) Value() (driver.Value, error) {
+	return json.Marshal(l)
+}
+
+func (l *EventTypeList) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(b, &l)
+}
+
+// Event is the payload of a delivery. Its ID stays the same across the attempts and the redeliveries of an event,
+// so that receivers can ignore the events they have already handled.
+type Event struct {
+	ID           uuid.UUID `json:"id"`
+	Type         EventType `json:"type"`
+	CreatedAt    time.Time `json:"created_at"`
+	RepositoryID uint64    `json:"repository_id"`
+	ScanID       uuid.UUID `json:"scan_id"`
+	ScanStatus   Status    `json:"scan_status"`
+	Branch       string    `json:"branch,omitempty"`
+	// Gate is only set on scan.succeeded, and Finding on finding.new.
+	Gate    *Gate    `json:"gate,omitempty"`
+	Finding *Finding `json:"finding,omitempty"`
+}
+
+// ScanEvents returns the events of a scan that entered its status: the event of the status, followed by
+// finding.new for each of newFindings when the scan succeeded.
+func ScanEvents(s *Scan, newFindings Findings, now time.Time) []*Event {
+	event := func(t EventType) *Event {
+		return &Event{
+			ID:           uuid.New(),
+			Type:         t,
+			CreatedAt:    now,
+			RepositoryID: s.RepositoryID,
+			ScanID:       s.ID,
+			ScanStatus:   s.Status,
+			Branch:       s.Branch,
+		}
+	}
+
+	e := event(ScanEventType(s.Status))
+	events := []*Event{e}
+	if s.Status != Success {
+		return events
+	}
+	e.Gate = s.Gate
+	for i := range newFindings {
+		e := event(EventFindingNew)
+		e.Finding = &newFindings[i]
+		events = append(events, e)
+	}
+	return events
+}
+
+// Webhook subscribes a URL to the events of a repository, or of every repository of its organization when it has
+// no repository.
+type Webhook struct {
+	ID             uint64  `json:"id"`
+	OrganizationID uint64  `json:"organization_id" gorm:"index;not null"`
+	RepositoryID   *uint64 `json:"repository_id,omitempty" gorm:"index"`
+	URL            string  `json:"url" gorm:"not null"`
+	// Secret signs the deliveries, see Sign. It is only returned when the webhook is created.
+	Secret    string        `json:"-" gorm:"not null"`
+	Events    EventTypeList `json:"events" gorm:"type:jsonb;not null"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+func (Webhook) TableName() string {
+	return "webhook"
+}
+
+// Subscribes reports whether w is sent the events of type t.
+func (w *Webhook) Subscribes(t EventType) bool {
+	for _, e := range w.Events {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// NewWebhookSecret generates a secret for a webhook created without one.
+func NewWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// signaturePrefix names the hash of a signature, the way receivers such as GitHub's expect it.
+const signaturePrefix = "sha256="
+
+// Sign returns the signature of the body of a delivery: the hex-encoded HMAC-SHA256 of body keyed with secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature is the signature of body, in constant time.
+func VerifySignature(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Statuses of a delivery.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	// DeliveryFailed is the status of a delivery that was attempted as many times as allowed.
+	DeliveryFailed = "failed"
+)
+
+// maxRetryDelay bounds the delay between two attempts of a delivery.
+const maxRetryDelay = 6 * time.Hour
+
+// Payload is a JSON document, such as the body of a delivery, stored and sent as is.
+type Payload []byte
+
+func (p Payload) Value() (driver.Value, error) {
+	return string(p), nil
+}
+
+func (p *Payload) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*p = append((*p)[:0], v...)
+	case string:
+		*p = Payload(v)
+	default:
+		return errors.New("type assertion to []byte failed")
+	}
+	return nil
+}
+
+func (p Payload) MarshalJSON() ([]byte, error) {
+	if len(p) == 0 {
+		return []byte("null"), nil
+	}
+	return p, nil
+}
+
+func (p *Payload) UnmarshalJSON(b []byte) error {
+	*p = append((*p)[:0], b...)
+	return nil
+}
+
+// WebhookDelivery is an event sent, or to be sent, to a webhook. Deliveries are kept once they are done,
+// as the log of the webhook.
+type WebhookDelivery struct {
+	ID        uint64    `json:"id"`
+	WebhookID uint64    `json:"webhook_id" gorm:"index;not null"`
+	EventID   uuid.UUID `json:"event_id" gorm:"type:uuid;not null"`
+	Event     EventType `json:"event" gorm:"not null"`
+	Payload   Payload   `json:"payload" gorm:"type:jsonb;not null"`
+	Status    string    `json:"status" gorm:"not null"`
+	Attempts  int       `json:"attempts" gorm:"not null;default:0"`
+	// NextAttemptAt is when a pending delivery is attempted next.
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" gorm:"index"`
+	// ResponseStatus and Error describe the outcome of the last attempt.
+	ResponseStatus int        `json:"response_status,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+
+	Webhook *Webhook `json:"-" gorm:"foreignKey:WebhookID"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
+
+// NewWebhookDelivery returns a pending delivery of e to the webhook webhookID, due at now.
+func NewWebhookDelivery(webhookID uint64, e *Event, now time.Time) (*WebhookDelivery, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookDelivery{
+		WebhookID:     webhookID,
+		EventID:       e.ID,
+		Event:         e.Type,
+		Payload:       payload,
+		Status:        DeliveryPending,
+		NextAttemptAt: &now,
+	}, nil
+}
+
+// RetryDelay is how long to wait after the given failed attempt, counted from 1: backoff, doubled on each attempt.
+func RetryDelay(backoff time.Duration, attempt int) time.Duration {
+	delay := backoff
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// Record records the outcome of an attempt made at now: err is the error of the request, otherwise status is the
+// status code of the response, which must be 2xx. A failed attempt is retried after RetryDelay, until maxAttempts.
+func (d *WebhookDelivery) Record(now time.Time, status int, err error, maxAttempts int, backoff time.Duration) {
+	d.Attempts++
+	d.ResponseStatus = status
+	d.Error = ""
+	if err == nil && status >= 200 && status < 300 {
+		d.Status = DeliverySucceeded
+		d.NextAttemptAt = nil
+		d.DeliveredAt = &now
+		return
+	}
+
+	if err != nil {
+		d.Error = err.Error()
+	} else {
+		d.Error = fmt.Sprintf("unexpected status: %d", status)
+	}
+	if d.Attempts >= maxAttempts {
+		d.Status = DeliveryFailed
+		d.NextAttemptAt = nil
+		return
+	}
+	next := now.Add(RetryDelay(backoff, d.Attempts))
+	d.NextAttemptAt = &next
+}
+
+type WebhookService interface {
+	Create(ctx context.Context, w *Webhook) error
+	Get(ctx context.Context, id uint64) (*Webhook, error)
+	// List lists the webhooks of a repository, or the global webhooks when repositoryID is zero.
+	List(ctx context.Context, repositoryID uint64) ([]*Webhook, error)
+	// Delete removes a webhook along with its deliveries.
+	Delete(ctx context.Context, id uint64) error
+	// ListDeliveries lists the deliveries of a webhook, newest first.
+	ListDeliveries(ctx context.Context, webhookID uint64, page, limit int) ([]*WebhookDelivery, error)
+	// Redeliver queues the event of a delivery again, as a new delivery with the same event ID.
+	Redeliver(ctx context.Context, webhookID, deliveryID uint64) (*WebhookDelivery, error)
+}
+
+// WebhookQueue hands the deliveries that are due out to the dispatcher.
+// Claimed deliveries are leased: a delivery whose outcome is not saved in time is attempted again.
+type WebhookQueue interface {
+	// Claim leases up to limit due deliveries, loaded with their webhook.
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*WebhookDelivery, error)
+	// Save stores the outcome of an attempt, see WebhookDelivery.Record.
+	Save(ctx context.Context, d *WebhookDelivery) error
+}
+
+// WebhookSender sends deliveries.
+type WebhookSender interface {
+	// Send posts the payload of d to the URL of w and returns the status code of the response.
+	Send(ctx context.Context, w *Webhook, d *WebhookDelivery) (int, error)
+}
@@ -0,0 +1,117 @@
+package ssr
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEventType(t *testing.T) {
+	for _, e := range EventTypes {
+		got, err := ParseEventType(string(e))
+		require.NoError(t, err)
+		assert.Equal(t, e, got)
+	}
+
+	_, err := ParseEventType("scan.deleted")
+	assert.ErrorIs(t, err, ErrInvalidEventType)
+
+	var events []EventType
+	err = json.Unmarshal([]byte(`["scan.queued", "scan.deleted"]`), &events)
+	assert.Equal(t, KindInvalid, KindOf(err))
+}
+
+func TestScanEvents(t *testing.T) {
+	now := time.Now()
+	scan := &Scan{ID: uuid.New(), RepositoryID: 1, Branch: "main", Status: InProgress}
+	findings := Findings{{RuleID: "G402"}, {RuleID: "G404"}}
+
+	events := ScanEvents(scan, findings, now)
+	require.Len(t, events, 1)
+	assert.Equal(t, EventScanStarted, events[0].Type)
+	assert.Equal(t, scan.ID, events[0].ScanID)
+	assert.Equal(t, "main", events[0].Branch)
+
+	scan.Status = Success
+	scan.Gate = &Gate{Status: GatePassed}
+	events = ScanEvents(scan, findings, now)
+	require.Len(t, events, 3)
+	assert.Equal(t, EventScanSucceeded, events[0].Type)
+	assert.Equal(t, scan.Gate, events[0].Gate)
+	for i, e := range events[1:] {
+		assert.Equal(t, EventFindingNew, e.Type)
+		assert.Equal(t, findings[i].RuleID, e.Finding.RuleID)
+		assert.Nil(t, e.Gate)
+		assert.NotEqual(t, events[0].ID, e.ID)
+	}
+
+	assert.Equal(t, EventScanQueued, ScanEventType(Queued))
+	assert.Equal(t, EventScanFailed, ScanEventType(Failure))
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"type":"scan.succeeded"}`)
+	signature := Sign("s3cr3t", body)
+	assert.Equal(t, "sha256=", signature[:7])
+	assert.Len(t, signature, 7+64)
+
+	assert.True(t, VerifySignature("s3cr3t", body, signature))
+	assert.False(t, VerifySignature("other", body, signature))
+	assert.False(t, VerifySignature("s3cr3t", []byte(`{"type":"scan.failed"}`), signature))
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, RetryDelay(30*time.Second, 1))
+	assert.Equal(t, time.Minute, RetryDelay(30*time.Second, 2))
+	assert.Equal(t, 4*time.Minute, RetryDelay(30*time.Second, 4))
+	assert.Equal(t, maxRetryDelay, RetryDelay(30*time.Second, 20))
+}
+
+func TestWebhookDeliveryRecord(t *testing.T) {
+	now := time.Now()
+	d, err := NewWebhookDelivery(2, &Event{ID: uuid.New(), Type: EventScanFailed}, now)
+	require.NoError(t, err)
+	assert.Equal(t, DeliveryPending, d.Status)
+	assert.Equal(t, EventScanFailed, d.Event)
+
+	d.Record(now, 0, errors.New("connection refused"), 3, time.Minute)
+	assert.Equal(t, DeliveryPending, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.Equal(t, "connection refused", d.Error)
+	assert.Equal(t, now.Add(time.Minute), *d.NextAttemptAt)
+
+	d.Record(now, 500, nil, 3, time.Minute)
+	assert.Equal(t, DeliveryPending, d.Status)
+	assert.Equal(t, 500, d.ResponseStatus)
+	assert.Equal(t, "unexpected status: 500", d.Error)
+	assert.Equal(t, now.Add(2*time.Minute), *d.NextAttemptAt)
+
+	d.Record(now, 500, nil, 3, time.Minute)
+	assert.Equal(t, DeliveryFailed, d.Status)
+	assert.Nil(t, d.NextAttemptAt)
+
+	d = &WebhookDelivery{Status: DeliveryPending, Error: "unexpected status: 500"}
+	d.Record(now, 204, nil, 3, time.Minute)
+	assert.Equal(t, DeliverySucceeded, d.Status)
+	assert.Empty(t, d.Error)
+	assert.Equal(t, now, *d.DeliveredAt)
+	assert.Nil(t, d.NextAttemptAt)
+}
+
+func TestPayload(t *testing.T) {
+	b, err := json.Marshal(WebhookDelivery{Payload: Payload(`{"type":"scan.queued"}`)})
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"payload":{"type":"scan.queued"}`)
+
+	var p Payload
+	require.NoError(t, p.Scan([]byte(`{"a":1}`)))
+	assert.Equal(t, Payload(`{"a":1}`), p)
+	v, err := p.Value()
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, v)
+}